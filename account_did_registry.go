@@ -1,7 +1,6 @@
 package contracts
 
 import (
-	"encoding/json"
 	"fmt"
 
 	"github.com/meshplus/bitxhub-core/agency"
@@ -9,6 +8,7 @@ import (
 	"github.com/meshplus/bitxhub-model/constant"
 	"github.com/meshplus/bitxid"
 	"github.com/meshplus/did-registry/converter"
+	"github.com/meshplus/did-registry/didpb"
 )
 
 const (
@@ -16,6 +16,10 @@ const (
 	adminDIDKey           = "admin-did"
 )
 
+// DIDDeactivated is status of an account did deactivated by its owner,
+// the did is kept as a tombstone so that it can never be registered again.
+const DIDDeactivated bitxid.StatusType = "Deactivated"
+
 // NewAccountDIDManager .
 func NewAccountDIDManager() agency.Contract {
 	return &AccountDIDManager{}
@@ -25,15 +29,6 @@ func init() {
 	agency.RegisterContractConstructor("account did registry", constant.DIDRegistryContractAddr.Address(), NewAccountDIDManager)
 }
 
-// DIDInfo is used for return struct.
-type DIDInfo struct {
-	DID     string            // did name
-	DocAddr string            // address where the doc file stored
-	DocHash []byte            // hash of the doc file
-	Doc     bitxid.AccountDoc // doc content
-	Status  string            // status of did
-}
-
 // AccountDIDManager .
 type AccountDIDManager struct {
 	boltvm.Stub
@@ -58,6 +53,15 @@ type AccountDIDRegistry struct {
 	SelfID     bitxid.DID
 	ParentID   bitxid.DID // not used
 	ChildIDs   []bitxid.DID
+	Quorum     Quorum // approval rule of sensitive actions
+
+	SuperAdmin        bitxid.DID   // super admin of the registry, first admin if empty
+	PendingSuperAdmin bitxid.DID   // admin that super admin is transferring to
+	Roles             RoleSet      // roles granted to non-admin dids
+	SelfFreezes       SelfFreezes  // self freeze states of dids frozen by their owners
+	KeyHistories      KeyHistories // authoritative keys of dids which rotated keys
+	Recoveries        Recoveries   // social recovery settings of dids which added guardians
+	Controllers       Controllers  // controlling addresses of dids rebound by recovery
 }
 
 // if you need to use registry table, you have to manully load it, so does docdb,
@@ -73,7 +77,8 @@ func (dr *AccountDIDRegistry) loadTable(stub boltvm.Stub) error {
 }
 
 // Init sets up the whole registry,
-// caller should be admin.
+// caller should be admin,
+// the registry is seeded from genesis config set by the node, if any.
 func (dm *AccountDIDManager) Init(caller string) *boltvm.Response {
 	dr := dm.getAccountDIDRegistry()
 
@@ -83,16 +88,17 @@ func (dm *AccountDIDManager) Init(caller string) *boltvm.Response {
 
 	callerDID := bitxid.DID(caller)
 	if dm.Caller() != admin {
-		return boltvm.Error("caller (" + dm.Caller() + ") is not admin(" + admin + ")")
+		return errorResponse(ErrNotAdmin, "caller ("+dm.Caller()+") is not admin("+admin+")")
 	}
 
 	if dm.Caller() != callerDID.GetAddress() {
-		return boltvm.Error(callerNotMatchError(dm.Caller(), caller))
+		return errorResponse(ErrCallerMismatch, callerNotMatchError(dm.Caller(), caller))
 	}
 
 	if dr.Initalized {
-		return boltvm.Error("init err, already init")
+		return errorResponse(ErrAlreadyInitialized, "init err, already init")
 	}
+	config := getGenesisConfig()
 	s := converter.StubToStorage(dm.Stub)
 	r, err := bitxid.NewAccountDIDRegistry(
 		s,
@@ -103,14 +109,22 @@ func (dm *AccountDIDManager) Init(caller string) *boltvm.Response {
 		),
 	)
 	if err != nil {
-		return boltvm.Error("init err, " + err.Error())
+		return wrapErrorResponse(ErrRegistryRejected, "init err, ", err)
 	}
 	dr.Registry = r
 	err = dr.Registry.SetupGenesis()
 	if err != nil {
-		return boltvm.Error("init genesis err, " + err.Error())
+		return wrapErrorResponse(ErrInvalidArgument, "init genesis err, ", err)
 	}
 	dr.SelfID = dr.Registry.GetSelfID()
+	dr.SuperAdmin = callerDID
+
+	if config != nil {
+		if err := config.AccountDID.seed(dr, callerDID); err != nil {
+			return wrapErrorResponse(ErrInvalidArgument, "init genesis err, ", err)
+		}
+		dm.Logger().Info("Account DID Registry seeded from genesis")
+	}
 	dr.Initalized = true
 
 	dm.SetObject(AccountDIDRegistryKey, dr)
@@ -122,7 +136,7 @@ func (dm *AccountDIDManager) Init(caller string) *boltvm.Response {
 func (dm *AccountDIDManager) GetChainDID() *boltvm.Response {
 	dr := dm.getAccountDIDRegistry()
 
-	return boltvm.Success([]byte(dr.SelfID))
+	return stringResponse(string(dr.SelfID))
 }
 
 // SetChainDID sets chain did of the registtry,
@@ -130,20 +144,14 @@ func (dm *AccountDIDManager) GetChainDID() *boltvm.Response {
 func (dm *AccountDIDManager) SetChainDID(caller, chainDID string) *boltvm.Response {
 	dr := dm.getAccountDIDRegistry()
 
-	if !dr.Initalized {
-		return boltvm.Error("Registry not initialized")
-	}
-
 	callerDID := bitxid.DID(caller)
-	if dm.Caller() != callerDID.GetAddress() {
-		return boltvm.Error(callerNotMatchError(dm.Caller(), caller))
-	}
-	if !dr.Registry.HasAdmin(callerDID) {
-		return boltvm.Error("caller has no permission")
+	if res := dr.authorize(dm.Stub, caller, requireAdmin(dr, callerDID)); res != nil {
+		return res
 	}
 	dr.SelfID = bitxid.DID(chainDID)
 
 	dm.SetObject(AccountDIDRegistryKey, dr)
+	postDIDEvent(dm.Stub, accountDIDRegistryName, EventSetChainDID, dr.SelfID, "", "", callerDID)
 	return boltvm.Success(nil)
 }
 
@@ -151,24 +159,24 @@ func (dm *AccountDIDManager) SetChainDID(caller, chainDID string) *boltvm.Respon
 func (dm *AccountDIDManager) Register(caller string, docAddr string, docHash []byte, sig []byte) *boltvm.Response {
 	dr := dm.getAccountDIDRegistry()
 
-	if !dr.Initalized {
-		return boltvm.Error("Registry not initialized")
-	}
-
 	callerDID := bitxid.DID(caller)
-	if dm.Caller() != callerDID.GetAddress() {
-		return boltvm.Error(callerNotMatchError(dm.Caller(), caller))
+	if res := dr.authorize(dm.Stub, caller); res != nil {
+		return res
 	}
 	if dr.SelfID != callerDID.GetChainDID() {
-		return boltvm.Error(didNotOnThisChainError(string(callerDID), string(dr.SelfID)))
+		return errorResponse(ErrNotOnThisChain, didNotOnThisChainError(string(callerDID), string(dr.SelfID)))
+	}
+	if dr.deactivated(callerDID) {
+		return errorResponse(ErrInvalidStatus, deactivatedError(string(callerDID)))
 	}
 
 	docAddr, docHash, err := dr.Registry.Register(bitxid.DID(callerDID), docAddr, docHash)
 	if err != nil {
-		return boltvm.Error(err.Error())
+		return wrapErrorResponse(ErrRegistryRejected, "register err, ", err)
 	}
 
 	dm.SetObject(AccountDIDRegistryKey, dr)
+	postDIDEvent(dm.Stub, accountDIDRegistryName, EventRegister, callerDID, "", dr.statusOf(callerDID), callerDID)
 	return boltvm.Success(nil)
 }
 
@@ -176,24 +184,26 @@ func (dm *AccountDIDManager) Register(caller string, docAddr string, docHash []b
 func (dm *AccountDIDManager) Update(caller string, docAddr string, docHash []byte, sig []byte) *boltvm.Response {
 	dr := dm.getAccountDIDRegistry()
 
-	if !dr.Initalized {
-		return boltvm.Error("Registry not initialized")
-	}
-
 	callerDID := bitxid.DID(caller)
-	if dm.Caller() != callerDID.GetAddress() {
-		return boltvm.Error(callerNotMatchError(dm.Caller(), caller))
+	if res := dr.authorize(dm.Stub, caller); res != nil {
+		return res
 	}
 	if dr.SelfID != callerDID.GetChainDID() {
-		return boltvm.Error(didNotOnThisChainError(string(callerDID), string(dr.SelfID)))
+		return errorResponse(ErrNotOnThisChain, didNotOnThisChainError(string(callerDID), string(dr.SelfID)))
+	}
+
+	if dr.deactivated(callerDID) {
+		return errorResponse(ErrInvalidStatus, deactivatedError(caller))
 	}
 
+	oldStatus := dr.statusOf(callerDID)
 	docAddr, docHash, err := dr.Registry.Update(bitxid.DID(callerDID), docAddr, docHash)
 	if err != nil {
-		return boltvm.Error(err.Error())
+		return wrapErrorResponse(ErrRegistryRejected, "update err, ", err)
 	}
 
 	dm.SetObject(AccountDIDRegistryKey, dr)
+	postDIDEvent(dm.Stub, accountDIDRegistryName, EventUpdate, callerDID, oldStatus, dr.statusOf(callerDID), callerDID)
 	return boltvm.Success(nil)
 }
 
@@ -201,130 +211,205 @@ func (dm *AccountDIDManager) Update(caller string, docAddr string, docHash []byt
 func (dm *AccountDIDManager) Resolve(caller string) *boltvm.Response {
 	dr := dm.getAccountDIDRegistry()
 
-	if !dr.Initalized {
-		return boltvm.Error("Registry not initialized")
+	if res := checkInitialized(dr.Initalized); res != nil {
+		return res
 	}
 
 	callerDID := bitxid.DID(caller)
 
 	item, _, exist, err := dr.Registry.Resolve(callerDID)
 	if err != nil {
-		return boltvm.Error(err.Error())
+		return wrapErrorResponse(ErrNotFound, "resolve err, ", err)
 	}
-	didInfo := DIDInfo{}
+	didInfo := &didpb.DIDInfo{}
 	if exist {
-		didInfo = DIDInfo{
-			DID:     string(item.ID),
-			DocAddr: item.DocAddr,
-			DocHash: item.DocHash,
-			Status:  string(item.Status),
+		didInfo = &didpb.DIDInfo{
+			Did:         string(item.ID),
+			DocAddr:     item.DocAddr,
+			DocHash:     item.DocHash,
+			Status:      string(item.Status),
+			RetiredKeys: dr.KeyHistories.retired(callerDID),
+			Controller:  dr.controllerOf(callerDID),
 		}
 	}
-	b, err := bitxid.Marshal(didInfo)
-	if err != nil {
-		return boltvm.Error(err.Error())
-	}
-	return boltvm.Success(b)
+	return success(didInfo)
 }
 
 // Freeze freezes the did in this registry,
-// caller should be admin.
+// caller should be admin or freezer,
+// only allowed if quorum is not enabled, otherwise propose it.
 func (dm *AccountDIDManager) Freeze(caller, callerToFreeze string, sig []byte) *boltvm.Response {
 	dr := dm.getAccountDIDRegistry()
 
-	if !dr.Initalized {
-		return boltvm.Error("Registry not initialized")
-	}
-
 	callerDID := bitxid.DID(caller)
 	callerToFreezeDID := bitxid.DID(callerToFreeze)
-	if dm.Caller() != callerDID.GetAddress() {
-		return boltvm.Error(callerNotMatchError(dm.Caller(), caller))
+	if res := dr.authorize(dm.Stub, caller, requireRole(dr, callerDID, RoleFreezer)); res != nil {
+		return res
 	}
-	if !dr.Registry.HasAdmin(callerDID) {
-		return boltvm.Error("caller has no permission")
+	if dr.Quorum.enabled() {
+		return errorResponse(ErrQuorumRequired, quorumRequiredError(ProposalFreeze, &dr.Quorum))
 	}
 
-	item, _, _, err := dr.Registry.Resolve(callerToFreezeDID)
-	if item.Status == bitxid.Frozen {
-		return boltvm.Error(callerToFreeze + " was already frozen")
-	}
-
-	err = dr.Registry.Freeze(callerToFreezeDID)
+	oldStatus := dr.statusOf(callerToFreezeDID)
+	err := dr.freeze(callerToFreezeDID)
 	if err != nil {
-		return boltvm.Error(err.Error())
+		return wrapErrorResponse(ErrRegistryRejected, "freeze err, ", err)
 	}
 
 	dm.SetObject(AccountDIDRegistryKey, dr)
+	postDIDEvent(dm.Stub, accountDIDRegistryName, EventFreeze, callerToFreezeDID, oldStatus, dr.statusOf(callerToFreezeDID), callerDID)
 	return boltvm.Success(nil)
 }
 
 // UnFreeze unfreezes the did in the registry,
-// caller should be admin.
+// caller should be admin or freezer,
+// only allowed if quorum is not enabled, otherwise propose it.
 func (dm *AccountDIDManager) UnFreeze(caller, callerToUnfreeze string, sig []byte) *boltvm.Response {
 	dr := dm.getAccountDIDRegistry()
 
-	if !dr.Initalized {
-		return boltvm.Error("Registry not initialized")
-	}
-
 	callerDID := bitxid.DID(caller)
 	callerToUnfreezeDID := bitxid.DID(callerToUnfreeze)
-	if dm.Caller() != callerDID.GetAddress() {
-		return boltvm.Error(callerNotMatchError(dm.Caller(), caller))
+	if res := dr.authorize(dm.Stub, caller, requireRole(dr, callerDID, RoleFreezer)); res != nil {
+		return res
 	}
-	if !dr.Registry.HasAdmin(callerDID) {
-		return boltvm.Error("caller has no permission.")
+	if dr.Quorum.enabled() {
+		return errorResponse(ErrQuorumRequired, quorumRequiredError(ProposalUnFreeze, &dr.Quorum))
 	}
 
-	item, _, _, err := dr.Registry.Resolve(callerToUnfreezeDID)
-	if item.Status != bitxid.Frozen {
-		return boltvm.Error(callerToUnfreeze + " was not frozen")
-	}
-
-	err = dr.Registry.UnFreeze(callerToUnfreezeDID)
+	oldStatus := dr.statusOf(callerToUnfreezeDID)
+	err := dr.unfreeze(callerToUnfreezeDID)
 	if err != nil {
-		return boltvm.Error(err.Error())
+		return wrapErrorResponse(ErrRegistryRejected, "unfreeze err, ", err)
 	}
 
 	dm.SetObject(AccountDIDRegistryKey, dr)
+	postDIDEvent(dm.Stub, accountDIDRegistryName, EventUnFreeze, callerToUnfreezeDID, oldStatus, dr.statusOf(callerToUnfreezeDID), callerDID)
 	return boltvm.Success(nil)
 }
 
-// Delete deletes the did,
-// caller should be self, admin can not be deleted.
-func (dm *AccountDIDManager) Delete(caller, callerToDelete string, sig []byte) *boltvm.Response {
-	dr := dm.getAccountDIDRegistry()
+func (dr *AccountDIDRegistry) freeze(did bitxid.DID) error {
+	item, _, _, err := dr.Registry.Resolve(did)
+	if err != nil {
+		return err
+	}
+	if item.Status == bitxid.Frozen {
+		return newError(ErrAlreadyFrozen, "%s was already frozen", did)
+	}
+	if item.Status == DIDDeactivated {
+		return newError(ErrInvalidStatus, "%s", deactivatedError(string(did)))
+	}
+	return dr.Registry.Freeze(did)
+}
 
-	if !dr.Initalized {
-		return boltvm.Error("Registry not initialized")
+func (dr *AccountDIDRegistry) unfreeze(did bitxid.DID) error {
+	item, _, _, err := dr.Registry.Resolve(did)
+	if err != nil {
+		return err
 	}
+	if item.Status != bitxid.Frozen {
+		return newError(ErrNotFrozen, "%s was not frozen", did)
+	}
+	if err := dr.Registry.UnFreeze(did); err != nil {
+		return err
+	}
+	dr.SelfFreezes.unfreeze(did)
+	return nil
+}
+
+// Delete deletes the did as a moderation action, the reason is recorded
+// in the event, caller should be admin, admin can not be deleted,
+// a deactivated did can not be deleted so that it stays a tombstone.
+// @sig: signature of caller over callerToDelete followed by reason
+func (dm *AccountDIDManager) Delete(caller, callerToDelete, reason string, sig []byte) *boltvm.Response {
+	dr := dm.getAccountDIDRegistry()
 
 	callerDID := bitxid.DID(caller)
 	callerToDeleteDID := bitxid.DID(callerToDelete)
-	if dm.Caller() != callerDID.GetAddress() {
-		return boltvm.Error(callerNotMatchError(dm.Caller(), caller))
+	if res := dr.authorize(dm.Stub, caller,
+		requireAdmin(dr, callerDID),
+		requireAddrSig(dr.controllerOf(callerDID), []byte(callerToDelete+reason), sig),
+	); res != nil {
+		return res
+	}
+	if reason == "" {
+		return errorResponse(ErrInvalidArgument, "delete err, reason is empty")
 	}
-	if !dr.Registry.HasAdmin(callerDID) {
-		return boltvm.Error("caller has no permission.")
+	if dr.hasAdmin(callerToDeleteDID) {
+		return errorResponse(ErrInvalidArgument, "can not delete admin, rm admin first")
 	}
-	if dr.Registry.HasAdmin(callerToDeleteDID) {
-		return boltvm.Error("can not delete admin, rm admin first")
+	if dr.deactivated(callerToDeleteDID) {
+		return errorResponse(ErrInvalidStatus, deactivatedError(callerToDelete))
 	}
 
+	oldStatus := dr.statusOf(callerToDeleteDID)
 	err := dr.Registry.Delete(callerToDeleteDID)
 	if err != nil {
-		return boltvm.Error(err.Error())
+		return wrapErrorResponse(ErrRegistryRejected, "delete err, ", err)
+	}
+
+	dm.SetObject(AccountDIDRegistryKey, dr)
+	postModerationEvent(dm.Stub, accountDIDRegistryName, EventDelete, callerToDeleteDID, oldStatus, "", callerDID, reason)
+	return boltvm.Success(nil)
+}
+
+// Deactivate deactivates the did of caller permanently,
+// it resolves as Deactivated from then on and can never be registered again,
+// roles granted to it are revoked, caller should be self and not admin.
+// @sig: signature of caller over caller did
+func (dm *AccountDIDManager) Deactivate(caller string, sig []byte) *boltvm.Response {
+	dr := dm.getAccountDIDRegistry()
+
+	callerDID := bitxid.DID(caller)
+	if res := dr.authorize(dm.Stub, caller,
+		requireAddrSig(dr.controllerOf(callerDID), []byte(caller), sig),
+	); res != nil {
+		return res
+	}
+	if dr.hasAdmin(callerDID) {
+		return errorResponse(ErrInvalidArgument, "can not deactivate admin, rm admin first")
 	}
 
+	oldStatus := dr.statusOf(callerDID)
+	if err := dr.deactivate(callerDID); err != nil {
+		return wrapErrorResponse(ErrRegistryRejected, "deactivate err, ", err)
+	}
+	roles := dr.Roles[callerDID]
+	delete(dr.Roles, callerDID)
+
 	dm.SetObject(AccountDIDRegistryKey, dr)
+	postDIDEvent(dm.Stub, accountDIDRegistryName, EventDeactivate, callerDID, oldStatus, DIDDeactivated, callerDID)
+	for _, role := range roles {
+		postRoleEvent(dm.Stub, accountDIDRegistryName, RoleRevoked, callerDID, role, callerDID)
+	}
 	return boltvm.Success(nil)
 }
 
+// deactivate turns the did into a tombstone, only a did under Normal can be deactivated.
+func (dr *AccountDIDRegistry) deactivate(did bitxid.DID) error {
+	item, _, exist, err := dr.Registry.Resolve(did)
+	if err != nil || !exist {
+		return newError(ErrNotFound, "did %s not existed", did)
+	}
+	if item.Status != bitxid.Normal {
+		return newError(ErrInvalidStatus, "did %s is under status: %s", did, item.Status)
+	}
+	item.Status = DIDDeactivated
+	return dr.Registry.Table.UpdateItem(item)
+}
+
+// deactivated checks whether the did was deactivated by its owner.
+func (dr *AccountDIDRegistry) deactivated(did bitxid.DID) bool {
+	return dr.statusOf(did) == DIDDeactivated
+}
+
 // isSuperAdmin querys whether caller is the super admin of the registry.
 func (dr *AccountDIDRegistry) isSuperAdmin(caller bitxid.DID) bool {
-	admins := dr.Registry.GetAdmins()
-	return admins[0] == caller
+	return caller != "" && dr.superAdmin() == caller
+}
+
+// hasAdmin querys whether caller is an admin of the registry.
+func (dr *AccountDIDRegistry) hasAdmin(caller bitxid.DID) bool {
+	return dr.Registry.HasAdmin(caller)
 }
 
 // HasAdmin querys whether caller is an admin of the registry.
@@ -332,80 +417,91 @@ func (dm *AccountDIDManager) HasAdmin(caller string) *boltvm.Response {
 	dr := dm.getAccountDIDRegistry()
 
 	callerDID := bitxid.DID(caller)
-	if dm.Caller() != callerDID.GetAddress() {
-		return boltvm.Error(callerNotMatchError(dm.Caller(), caller))
+	if res := dr.authorize(dm.Stub, caller); res != nil {
+		return res
 	}
 
-	res := dr.Registry.HasAdmin(callerDID)
-	if res == true {
-		return boltvm.Success([]byte("1"))
-	}
-	return boltvm.Success([]byte("0"))
+	return boolResponse(dr.hasAdmin(callerDID))
 }
 
 // GetAdmins get admins of the registry.
 func (dm *AccountDIDManager) GetAdmins() *boltvm.Response {
 	dr := dm.getAccountDIDRegistry()
 
-	admins := dr.Registry.GetAdmins()
-	data, err := json.Marshal(admins)
-	if err != nil {
-		return boltvm.Error(err.Error())
+	if res := checkInitialized(dr.Initalized); res != nil {
+		return res
 	}
-	return boltvm.Success([]byte(data))
+
+	return didsResponse(dr.Registry.GetAdmins())
 }
 
 // AddAdmin add caller to the admin of the registry,
-// caller should be admin.
+// caller should be admin,
+// only allowed if quorum is not enabled, otherwise propose it.
 func (dm *AccountDIDManager) AddAdmin(caller string, adminToAdd string) *boltvm.Response {
 	dr := dm.getAccountDIDRegistry()
 
 	callerDID := bitxid.DID(caller)
-	if dm.Caller() != callerDID.GetAddress() {
-		return boltvm.Error(callerNotMatchError(dm.Caller(), caller))
+	if res := dr.authorize(dm.Stub, caller, requireSuperAdmin(dr, callerDID)); res != nil {
+		return res
 	}
-	if !dr.isSuperAdmin(callerDID) {
-		return boltvm.Error("caller" + string(callerDID) + "doesn't have enough permission")
+	if dr.Quorum.enabled() {
+		return errorResponse(ErrQuorumRequired, quorumRequiredError(ProposalAddAdmin, &dr.Quorum))
 	}
 
 	err := dr.Registry.AddAdmin(bitxid.DID(adminToAdd))
 	if err != nil {
-		return boltvm.Error(err.Error())
+		return wrapErrorResponse(ErrAlreadyExists, "add admin err, ", err)
 	}
 
 	dm.SetObject(AccountDIDRegistryKey, dr)
+	postAdminEvent(dm.Stub, accountDIDRegistryName, AdminAdded, bitxid.DID(adminToAdd), callerDID)
 	return boltvm.Success(nil)
 }
 
 // RemoveAdmin remove admin of the registry,
-// caller should be super admin, super admin can not rm self.
+// caller should be super admin, super admin can not rm self,
+// only allowed if quorum is not enabled, otherwise propose it.
 func (dm *AccountDIDManager) RemoveAdmin(caller string, adminToRm string) *boltvm.Response {
 	dr := dm.getAccountDIDRegistry()
 
 	callerDID := bitxid.DID(caller)
-	if dm.Caller() != callerDID.GetAddress() {
-		return boltvm.Error(callerNotMatchError(dm.Caller(), caller))
+	if res := dr.authorize(dm.Stub, caller, requireSuperAdmin(dr, callerDID)); res != nil {
+		return res
 	}
-	if !dr.isSuperAdmin(callerDID) {
-		return boltvm.Error("caller" + string(callerDID) + "doesn't have enough permission")
+	if dr.Quorum.enabled() {
+		return errorResponse(ErrQuorumRequired, quorumRequiredError(ProposalRemoveAdmin, &dr.Quorum))
 	}
 
-	if dr.isSuperAdmin(bitxid.DID(adminToRm)) {
-		return boltvm.Error("cannot rm super admin")
-	}
-	err := dr.Registry.RemoveAdmin(bitxid.DID(adminToRm))
+	err := dr.removeAdmin(bitxid.DID(adminToRm))
 	if err != nil {
-		return boltvm.Error(err.Error())
+		return wrapErrorResponse(ErrNotFound, "remove admin err, ", err)
 	}
 
 	dm.SetObject(AccountDIDRegistryKey, dr)
+	postAdminEvent(dm.Stub, accountDIDRegistryName, AdminRemoved, bitxid.DID(adminToRm), callerDID)
 	return boltvm.Success(nil)
 }
 
+// removeAdmin removes an admin other than the super admin.
+func (dr *AccountDIDRegistry) removeAdmin(admin bitxid.DID) error {
+	if dr.isSuperAdmin(admin) {
+		return newError(ErrInvalidArgument, "cannot rm super admin, transfer it first")
+	}
+	if dr.PendingSuperAdmin == admin {
+		dr.PendingSuperAdmin = ""
+	}
+	return dr.Registry.RemoveAdmin(admin)
+}
+
 func docIDNotMatchDidError(c1 string, c2 string) string {
 	return "doc ID(" + c1 + ") not match the did(" + c2 + ")"
 }
 
+func deactivatedError(did string) string {
+	return "DID(" + did + ") was deactivated"
+}
+
 func didNotOnThisChainError(did string, chainDID string) string {
 	return "DID(" + did + ") not on the chain(" + chainDID + ")"
 }
@@ -0,0 +1,668 @@
+package contracts
+
+import (
+	"testing"
+
+	"github.com/meshplus/bitxhub-core/boltvm"
+	"github.com/meshplus/bitxid"
+	"github.com/meshplus/did-registry/didpb"
+	"github.com/stretchr/testify/require"
+)
+
+func resolveAccountDID(t *testing.T, e *testEnv, did string) *didpb.DIDInfo {
+	info := &didpb.DIDInfo{}
+	decode(t, e.account.Resolve(did), info)
+	return info
+}
+
+// userFrozen freezes user in the account did registry.
+func userFrozen(e *testEnv) {
+	requireOK(e.t, e.as(e.admin).account.Freeze(e.admin.did, e.user.did, nil))
+}
+
+const testReason = "spam"
+
+// deleteAccount deletes did by admin for reason.
+func deleteAccount(e *testEnv, admin *testAccount, did, reason string) *boltvm.Response {
+	sig := admin.sign(e.t, []byte(did+reason))
+	return e.as(admin).account.Delete(admin.did, did, reason, sig)
+}
+
+// deactivateUser deactivates user by itself.
+func deactivateUser(e *testEnv) *boltvm.Response {
+	return e.as(e.user).account.Deactivate(e.user.did, e.user.sign(e.t, []byte(e.user.did)))
+}
+
+func userDeactivated(e *testEnv) {
+	requireOK(e.t, deactivateUser(e))
+}
+
+func grantAccountRole(e *testEnv, role Role) {
+	requireOK(e.t, e.as(e.admin).account.GrantRole(e.admin.did, e.user.did, string(role)))
+}
+
+func TestAccountDIDManager_Init(t *testing.T) {
+	stranger := newTestAccount(t)
+	tests := []struct {
+		name   string
+		caller func(e *testEnv) *testAccount
+		did    func(e *testEnv) string
+		twice  bool
+		code   ErrorCode
+	}{
+		{
+			name:   "not admin",
+			caller: func(e *testEnv) *testAccount { return stranger },
+			did:    func(e *testEnv) string { return stranger.did },
+			code:   ErrNotAdmin,
+		},
+		{
+			name:   "caller mismatch",
+			caller: func(e *testEnv) *testAccount { return e.admin },
+			did:    func(e *testEnv) string { return stranger.did },
+			code:   ErrCallerMismatch,
+		},
+		{
+			name:   "already initialized",
+			caller: func(e *testEnv) *testAccount { return e.admin },
+			did:    func(e *testEnv) string { return e.admin.did },
+			twice:  true,
+			code:   ErrAlreadyInitialized,
+		},
+		{
+			name:   "success",
+			caller: func(e *testEnv) *testAccount { return e.admin },
+			did:    func(e *testEnv) string { return e.admin.did },
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			e.as(tt.caller(e))
+			if tt.twice {
+				requireOK(t, e.account.Init(tt.did(e)))
+			}
+			requireCode(t, e.account.Init(tt.did(e)), tt.code)
+			if tt.code != "" {
+				return
+			}
+			admins := &didpb.StringSlice{}
+			decode(t, e.account.GetAdmins(), admins)
+			require.Equal(t, []string{e.admin.did}, admins.Slice)
+			require.Equal(t, string(bitxid.Normal), resolveAccountDID(t, e, e.admin.did).Status)
+		})
+	}
+}
+
+func TestAccountDIDManager_NotInitialized(t *testing.T) {
+	e := newTestEnv(t)
+	e.as(e.admin)
+	admin := e.admin.did
+	calls := map[string]func() *boltvm.Response{
+		"SetChainDID":          func() *boltvm.Response { return e.account.SetChainDID(admin, testChainDID) },
+		"Register":             func() *boltvm.Response { return e.account.Register(admin, testDocAddr, nil, nil) },
+		"Update":               func() *boltvm.Response { return e.account.Update(admin, testDocAddr, nil, nil) },
+		"Resolve":              func() *boltvm.Response { return e.account.Resolve(admin) },
+		"Freeze":               func() *boltvm.Response { return e.account.Freeze(admin, admin, nil) },
+		"UnFreeze":             func() *boltvm.Response { return e.account.UnFreeze(admin, admin, nil) },
+		"Delete":               func() *boltvm.Response { return e.account.Delete(admin, admin, testReason, nil) },
+		"Deactivate":           func() *boltvm.Response { return e.account.Deactivate(admin, nil) },
+		"SelfFreeze":           func() *boltvm.Response { return e.account.SelfFreeze(admin, nil, nil) },
+		"SelfUnFreeze":         func() *boltvm.Response { return e.account.SelfUnFreeze(admin, nil, nil) },
+		"RotateKey":            func() *boltvm.Response { return e.account.RotateKey(admin, nil, testDocAddr, nil, nil, nil) },
+		"GetKeyHistory":        func() *boltvm.Response { return e.account.GetKeyHistory(admin) },
+		"AddGuardian":          func() *boltvm.Response { return e.account.AddGuardian(admin, admin) },
+		"RemoveGuardian":       func() *boltvm.Response { return e.account.RemoveGuardian(admin, admin) },
+		"SetRecoveryRule":      func() *boltvm.Response { return e.account.SetRecoveryRule(admin, 1, 0) },
+		"ApproveRecovery":      func() *boltvm.Response { return e.account.ApproveRecovery(admin, admin, admin, nil) },
+		"CancelRecovery":       func() *boltvm.Response { return e.account.CancelRecovery(admin) },
+		"ExecuteRecovery":      func() *boltvm.Response { return e.account.ExecuteRecovery(admin, admin) },
+		"GetRecovery":          func() *boltvm.Response { return e.account.GetRecovery(admin) },
+		"HasAdmin":             func() *boltvm.Response { return e.account.HasAdmin(admin) },
+		"GetAdmins":            func() *boltvm.Response { return e.account.GetAdmins() },
+		"AddAdmin":             func() *boltvm.Response { return e.account.AddAdmin(admin, admin) },
+		"RemoveAdmin":          func() *boltvm.Response { return e.account.RemoveAdmin(admin, admin) },
+		"Propose":              func() *boltvm.Response { return e.account.Propose(admin, string(ProposalFreeze), admin, 0) },
+		"Vote":                 func() *boltvm.Response { return e.account.Vote(admin, 0, true) },
+		"GetProposal":          func() *boltvm.Response { return e.account.GetProposal(0) },
+		"ListProposals":        func() *boltvm.Response { return e.account.ListProposals("", 0, 0) },
+		"ExecuteProposal":      func() *boltvm.Response { return e.account.ExecuteProposal(admin, 0) },
+		"RejectRecovery":       func() *boltvm.Response { return e.account.RejectRecovery(admin, 0) },
+		"SetQuorum":            func() *boltvm.Response { return e.account.SetQuorum(admin, 2, 0) },
+		"GetQuorum":            func() *boltvm.Response { return e.account.GetQuorum() },
+		"GrantRole":            func() *boltvm.Response { return e.account.GrantRole(admin, admin, string(RoleFreezer)) },
+		"RevokeRole":           func() *boltvm.Response { return e.account.RevokeRole(admin, admin, string(RoleFreezer)) },
+		"GetRoles":             func() *boltvm.Response { return e.account.GetRoles(admin) },
+		"HasRole":              func() *boltvm.Response { return e.account.HasRole(admin, string(RoleFreezer)) },
+		"RegisterFor":          func() *boltvm.Response { return e.account.RegisterFor(admin, admin, testDocAddr, nil, nil) },
+		"TransferSuperAdmin":   func() *boltvm.Response { return e.account.TransferSuperAdmin(admin, admin) },
+		"AcceptSuperAdmin":     func() *boltvm.Response { return e.account.AcceptSuperAdmin(admin) },
+		"GetSuperAdmin":        func() *boltvm.Response { return e.account.GetSuperAdmin() },
+		"SetRecoveryThreshold": func() *boltvm.Response { return e.account.SetRecoveryThreshold(admin, 2) },
+	}
+	for name, fn := range calls {
+		requireCode(t, fn(), ErrNotInitialized)
+		require.Empty(t, e.accountStub.Events(), name)
+	}
+}
+
+func TestAccountDIDManager_ChainDID(t *testing.T) {
+	chainDID := func(e *testEnv) string {
+		res := &didpb.String{}
+		decode(e.t, e.account.GetChainDID(), res)
+		return res.Value
+	}
+	runCalls(t, []call{
+		{
+			name: "get",
+			run:  func(e *testEnv) *boltvm.Response { return e.account.GetChainDID() },
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, testChainDID, chainDID(e))
+			},
+		},
+		{
+			name: "set",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.SetChainDID(e.admin.did, testAppChainDID)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, testAppChainDID, chainDID(e))
+				require.Equal(t, EventSetChainDID, lastEvent(t, e.accountStub).Type)
+			},
+		},
+		{
+			name: "set by others",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).account.SetChainDID(e.user.did, testAppChainDID)
+			},
+			code: ErrNotAdmin,
+		},
+		{
+			name: "set with caller mismatch",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).account.SetChainDID(e.admin.did, testAppChainDID)
+			},
+			code: ErrCallerMismatch,
+		},
+	})
+}
+
+func TestAccountDIDManager_Register(t *testing.T) {
+	var acc *testAccount
+	runCalls(t, []call{
+		{
+			name: "success",
+			run: func(e *testEnv) *boltvm.Response {
+				acc = newTestAccount(e.t)
+				return e.as(acc).account.Register(acc.did, testDocAddr, []byte("hash"), nil)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				info := resolveAccountDID(t, e, acc.did)
+				require.Equal(t, acc.did, info.Did)
+				require.Equal(t, testDocAddr, info.DocAddr)
+				require.Equal(t, string(bitxid.Normal), info.Status)
+				ev := lastEvent(t, e.accountStub)
+				require.Equal(t, EventRegister, ev.Type)
+				require.Equal(t, bitxid.DID(acc.did), ev.DID)
+			},
+		},
+		{
+			name: "registered twice",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).account.Register(e.user.did, testDocAddr, []byte("hash"), nil)
+			},
+			code: ErrRegistryRejected,
+		},
+		{
+			name: "not on this chain",
+			run: func(e *testEnv) *boltvm.Response {
+				acc := newTestAccount(e.t)
+				return e.as(acc).account.Register("did:bitxhub:appchain002:"+acc.addr, testDocAddr, []byte("hash"), nil)
+			},
+			code: ErrNotOnThisChain,
+		},
+		{
+			name: "caller mismatch",
+			run: func(e *testEnv) *boltvm.Response {
+				acc := newTestAccount(e.t)
+				return e.as(e.admin).account.Register(acc.did, testDocAddr, []byte("hash"), nil)
+			},
+			code: ErrCallerMismatch,
+		},
+	})
+}
+
+func TestAccountDIDManager_Update(t *testing.T) {
+	runCalls(t, []call{
+		{
+			name: "success",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).account.Update(e.user.did, "/ipfs/QmNew", []byte("new"), nil)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				info := resolveAccountDID(t, e, e.user.did)
+				require.Equal(t, "/ipfs/QmNew", info.DocAddr)
+				require.Equal(t, []byte("new"), info.DocHash)
+				require.Equal(t, EventUpdate, lastEvent(t, e.accountStub).Type)
+			},
+		},
+		{
+			name: "not registered",
+			run: func(e *testEnv) *boltvm.Response {
+				acc := newTestAccount(e.t)
+				return e.as(acc).account.Update(acc.did, "/ipfs/QmNew", []byte("new"), nil)
+			},
+			code: ErrRegistryRejected,
+		},
+		{
+			name:  "frozen",
+			setup: userFrozen,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).account.Update(e.user.did, "/ipfs/QmNew", []byte("new"), nil)
+			},
+			code: ErrRegistryRejected,
+		},
+		{
+			name: "not on this chain",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).account.Update("did:bitxhub:appchain002:"+e.user.addr, "/ipfs/QmNew", []byte("new"), nil)
+			},
+			code: ErrNotOnThisChain,
+		},
+	})
+}
+
+func TestAccountDIDManager_Resolve(t *testing.T) {
+	runCalls(t, []call{
+		{
+			name: "existed",
+			run:  func(e *testEnv) *boltvm.Response { return e.account.Resolve(e.user.did) },
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				info := &didpb.DIDInfo{}
+				decode(t, res, info)
+				require.Equal(t, e.user.did, info.Did)
+				require.Equal(t, string(bitxid.Normal), info.Status)
+			},
+		},
+		{
+			name: "not existed",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.account.Resolve(newTestAccount(e.t).did)
+			},
+			code: ErrNotFound,
+		},
+	})
+}
+
+func TestAccountDIDManager_Freeze(t *testing.T) {
+	runCalls(t, []call{
+		{
+			name: "freeze",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.Freeze(e.admin.did, e.user.did, nil)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, string(bitxid.Frozen), resolveAccountDID(t, e, e.user.did).Status)
+				ev := lastEvent(t, e.accountStub)
+				require.Equal(t, EventFreeze, ev.Type)
+				require.Equal(t, bitxid.DID(e.admin.did), ev.Actor)
+			},
+		},
+		{
+			name: "freeze by freezer",
+			setup: func(e *testEnv) {
+				grantAccountRole(e, RoleFreezer)
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				other, _ := e.newAccount()
+				return e.as(e.user).account.Freeze(e.user.did, other.did, nil)
+			},
+		},
+		{
+			name: "freeze by others",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).account.Freeze(e.user.did, e.admin.did, nil)
+			},
+			code: ErrNotAdmin,
+		},
+		{
+			name:  "freeze twice",
+			setup: userFrozen,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.Freeze(e.admin.did, e.user.did, nil)
+			},
+			code: ErrAlreadyFrozen,
+		},
+		{
+			name: "freeze missing",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.Freeze(e.admin.did, newTestAccount(e.t).did, nil)
+			},
+			code: ErrRegistryRejected,
+		},
+		{
+			name:  "freeze under quorum",
+			setup: quorumEnabled,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.Freeze(e.admin.did, e.user.did, nil)
+			},
+			code: ErrQuorumRequired,
+		},
+		{
+			name:  "unfreeze",
+			setup: userFrozen,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.UnFreeze(e.admin.did, e.user.did, nil)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, string(bitxid.Normal), resolveAccountDID(t, e, e.user.did).Status)
+				require.Equal(t, EventUnFreeze, lastEvent(t, e.accountStub).Type)
+			},
+		},
+		{
+			name:  "unfreeze by others",
+			setup: userFrozen,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).account.UnFreeze(e.user.did, e.user.did, nil)
+			},
+			code: ErrNotAdmin,
+		},
+		{
+			name: "unfreeze not frozen",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.UnFreeze(e.admin.did, e.user.did, nil)
+			},
+			code: ErrNotFrozen,
+		},
+		{
+			name: "unfreeze under quorum",
+			setup: func(e *testEnv) {
+				userFrozen(e)
+				quorumEnabled(e)
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.UnFreeze(e.admin.did, e.user.did, nil)
+			},
+			code: ErrQuorumRequired,
+		},
+	})
+}
+
+func TestAccountDIDManager_Delete(t *testing.T) {
+	runCalls(t, []call{
+		{
+			name: "success",
+			run:  func(e *testEnv) *boltvm.Response { return deleteAccount(e, e.admin, e.user.did, testReason) },
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				requireCode(t, e.account.Resolve(e.user.did), ErrNotFound)
+				ev := lastEvent(t, e.accountStub)
+				require.Equal(t, EventDelete, ev.Type)
+				require.Equal(t, string(bitxid.Normal), ev.OldStatus)
+				require.Equal(t, testReason, ev.Reason)
+				require.Equal(t, bitxid.DID(e.admin.did), ev.Actor)
+
+				// deletion is not a tombstone
+				_, hash := e.user.doc(t)
+				requireOK(t, e.as(e.user).account.Register(e.user.did, testDocAddr, hash, nil))
+			},
+		},
+		{
+			name: "by others",
+			run:  func(e *testEnv) *boltvm.Response { return deleteAccount(e, e.user, e.user.did, testReason) },
+			code: ErrNotAdmin,
+		},
+		{
+			name: "without reason",
+			run:  func(e *testEnv) *boltvm.Response { return deleteAccount(e, e.admin, e.user.did, "") },
+			code: ErrInvalidArgument,
+		},
+		{
+			name: "invalid signature",
+			run: func(e *testEnv) *boltvm.Response {
+				sig := e.admin.sign(e.t, []byte(e.user.did+"other reason"))
+				return e.as(e.admin).account.Delete(e.admin.did, e.user.did, testReason, sig)
+			},
+			code: ErrSignatureInvalid,
+		},
+		{
+			name: "admin",
+			setup: func(e *testEnv) {
+				e.user = e.newAdmin()
+			},
+			run:  func(e *testEnv) *boltvm.Response { return deleteAccount(e, e.admin, e.user.did, testReason) },
+			code: ErrInvalidArgument,
+		},
+		{
+			name:  "deactivated",
+			setup: userDeactivated,
+			run:   func(e *testEnv) *boltvm.Response { return deleteAccount(e, e.admin, e.user.did, testReason) },
+			code:  ErrInvalidStatus,
+		},
+		{
+			name: "not existed",
+			run: func(e *testEnv) *boltvm.Response {
+				return deleteAccount(e, e.admin, newTestAccount(e.t).did, testReason)
+			},
+			code: ErrRegistryRejected,
+		},
+	})
+}
+
+func TestAccountDIDManager_Deactivate(t *testing.T) {
+	runCalls(t, []call{
+		{
+			name: "success",
+			run:  deactivateUser,
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, string(DIDDeactivated), resolveAccountDID(t, e, e.user.did).Status)
+				ev := lastEvent(t, e.accountStub)
+				require.Equal(t, EventDeactivate, ev.Type)
+				require.Equal(t, string(bitxid.Normal), ev.OldStatus)
+				require.Equal(t, string(DIDDeactivated), ev.NewStatus)
+				require.Equal(t, bitxid.DID(e.user.did), ev.Actor)
+			},
+		},
+		{
+			name: "roles revoked",
+			setup: func(e *testEnv) {
+				grantAccountRole(e, RoleRegistrar)
+			},
+			run: deactivateUser,
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.False(t, hasRole(t, e.account.HasRole(e.user.did, string(RoleRegistrar))))
+				ev := lastAdminEvent(t, e.accountStub)
+				require.Equal(t, RoleRevoked, ev.Action)
+				require.Equal(t, RoleRegistrar, ev.Role)
+			},
+		},
+		{
+			name:  "register again",
+			setup: userDeactivated,
+			run: func(e *testEnv) *boltvm.Response {
+				_, hash := e.user.doc(e.t)
+				return e.as(e.user).account.Register(e.user.did, testDocAddr, hash, nil)
+			},
+			code: ErrInvalidStatus,
+		},
+		{
+			name:  "register again by registrar",
+			setup: userDeactivated,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.RegisterFor(e.admin.did, e.user.did, testDocAddr, nil, nil)
+			},
+			code: ErrInvalidStatus,
+		},
+		{
+			name:  "update",
+			setup: userDeactivated,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).account.Update(e.user.did, testDocAddr, nil, nil)
+			},
+			code: ErrInvalidStatus,
+		},
+		{
+			name:  "freeze",
+			setup: userDeactivated,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.Freeze(e.admin.did, e.user.did, nil)
+			},
+			code: ErrInvalidStatus,
+		},
+		{
+			name:  "unfreeze",
+			setup: userDeactivated,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.UnFreeze(e.admin.did, e.user.did, nil)
+			},
+			code: ErrNotFrozen,
+		},
+		{
+			name:  "twice",
+			setup: userDeactivated,
+			run:   deactivateUser,
+			code:  ErrInvalidStatus,
+		},
+		{
+			name:  "frozen",
+			setup: userFrozen,
+			run:   deactivateUser,
+			code:  ErrInvalidStatus,
+		},
+		{
+			name: "invalid signature",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).account.Deactivate(e.user.did, e.admin.sign(e.t, []byte(e.user.did)))
+			},
+			code: ErrSignatureInvalid,
+		},
+		{
+			name: "by others",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.Deactivate(e.user.did, e.user.sign(e.t, []byte(e.user.did)))
+			},
+			code: ErrCallerMismatch,
+		},
+		{
+			name: "admin",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.Deactivate(e.admin.did, e.admin.sign(e.t, []byte(e.admin.did)))
+			},
+			code: ErrInvalidArgument,
+		},
+		{
+			name: "not existed",
+			run: func(e *testEnv) *boltvm.Response {
+				acc := newTestAccount(e.t)
+				return e.as(acc).account.Deactivate(acc.did, acc.sign(e.t, []byte(acc.did)))
+			},
+			code: ErrNotFound,
+		},
+	})
+}
+
+func TestAccountDIDManager_Admins(t *testing.T) {
+	admins := func(e *testEnv) []string {
+		res := &didpb.StringSlice{}
+		decode(e.t, e.account.GetAdmins(), res)
+		return res.Slice
+	}
+	runCalls(t, []call{
+		{
+			name: "has admin",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.HasAdmin(e.admin.did)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				has := &didpb.Bool{}
+				decode(t, res, has)
+				require.True(t, has.Value)
+			},
+		},
+		{
+			name: "has admin of non admin",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).account.HasAdmin(e.user.did)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				has := &didpb.Bool{}
+				decode(t, res, has)
+				require.False(t, has.Value)
+			},
+		},
+		{
+			name: "has admin with caller mismatch",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).account.HasAdmin(e.admin.did)
+			},
+			code: ErrCallerMismatch,
+		},
+		{
+			name: "add admin",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.AddAdmin(e.admin.did, e.user.did)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, []string{e.admin.did, e.user.did}, admins(e))
+				require.Equal(t, AdminAdded, lastAdminEvent(t, e.accountStub).Action)
+			},
+		},
+		{
+			name: "add admin by others",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).account.AddAdmin(e.user.did, e.user.did)
+			},
+			code: ErrNotSuperAdmin,
+		},
+		{
+			name: "add existing admin",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.AddAdmin(e.admin.did, e.admin.did)
+			},
+			code: ErrAlreadyExists,
+		},
+		{
+			name:  "add admin under quorum",
+			setup: quorumEnabled,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.AddAdmin(e.admin.did, e.user.did)
+			},
+			code: ErrQuorumRequired,
+		},
+		{
+			name: "remove admin",
+			setup: func(e *testEnv) {
+				requireOK(e.t, e.as(e.admin).account.AddAdmin(e.admin.did, e.user.did))
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.RemoveAdmin(e.admin.did, e.user.did)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, []string{e.admin.did}, admins(e))
+				require.Equal(t, AdminRemoved, lastAdminEvent(t, e.accountStub).Action)
+			},
+		},
+		{
+			name: "remove super admin",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.RemoveAdmin(e.admin.did, e.admin.did)
+			},
+			code: ErrInvalidArgument,
+		},
+		{
+			name: "remove non admin",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.RemoveAdmin(e.admin.did, e.user.did)
+			},
+			code: ErrNotFound,
+		},
+		{
+			name:  "remove admin under quorum",
+			setup: quorumEnabled,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.RemoveAdmin(e.admin.did, e.user.did)
+			},
+			code: ErrQuorumRequired,
+		},
+	})
+}
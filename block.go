@@ -0,0 +1,72 @@
+package contracts
+
+import (
+	"time"
+
+	"github.com/meshplus/bitxhub-core/boltvm"
+)
+
+// BlockContext is implemented by stubs which know about the block
+// executing current transaction.
+// The boltvm.Stub pinned in go.mod doesn't include it, so calls on such
+// a stub go on without block context wherever their result doesn't
+// depend on it, local time of the node is never used since it differs
+// between nodes.
+type BlockContext interface {
+	// CurrentHeight returns height of the current block
+	CurrentHeight() uint64
+	// GetTxTimeStamp returns timestamp of the current transaction in nanoseconds
+	GetTxTimeStamp() int64
+}
+
+// blockContext gets block context of the stub, returns err if it has none.
+func blockContext(stub boltvm.Stub) (BlockContext, error) {
+	bc, ok := stub.(BlockContext)
+	if !ok {
+		return nil, newError(ErrNoBlockContext, "stub provides no block height and transaction time")
+	}
+	return bc, nil
+}
+
+// blockTime returns timestamp of the current transaction in seconds,
+// for calls which can't go on without it, e.g. opening a time-lock.
+func blockTime(stub boltvm.Stub) (int64, error) {
+	bc, err := blockContext(stub)
+	if err != nil {
+		return 0, err
+	}
+	return bc.GetTxTimeStamp() / int64(time.Second), nil
+}
+
+// clock is block time in seconds for calls which can go on without it,
+// e.g. queries, known is false on a stub without block context,
+// and then only results which don't depend on time are given.
+type clock struct {
+	now   int64
+	known bool
+}
+
+func blockClock(stub boltvm.Stub) clock {
+	now, err := blockTime(stub)
+	return clock{now: now, known: err == nil}
+}
+
+// recordHeight returns height recorded in events and key histories,
+// 0 on a stub without block context.
+func recordHeight(stub boltvm.Stub) uint64 {
+	bc, err := blockContext(stub)
+	if err != nil {
+		return 0
+	}
+	return bc.CurrentHeight()
+}
+
+// recordTimestamp returns timestamp in nanoseconds recorded in ibtps,
+// 0 on a stub without block context.
+func recordTimestamp(stub boltvm.Stub) int64 {
+	bc, err := blockContext(stub)
+	if err != nil {
+		return 0
+	}
+	return bc.GetTxTimeStamp()
+}
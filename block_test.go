@@ -0,0 +1,64 @@
+package contracts
+
+import (
+	"testing"
+
+	"github.com/meshplus/bitxhub-core/boltvm"
+	"github.com/meshplus/did-registry/didpb"
+	"github.com/stretchr/testify/require"
+)
+
+// noBlockStub hides block context of the wrapped stub, like a node
+// whose stub provides no block height and transaction time.
+type noBlockStub struct {
+	boltvm.Stub
+}
+
+func TestBlockContext_Missing(t *testing.T) {
+	const expiringCID = "vc-expiring"
+	e := newInitedEnv(t)
+	issued(e)
+	c := e.credential(e.user, expiringCID, testCTID, claimOf(t, e.admin.did, map[string]string{"name": "alice"}))
+	c.Expiration = uint64(testTime + 60)
+	e.signCredential(e.user, c)
+	requireOK(t, e.storeVC(e.user, e.userDoc, c))
+
+	account := &AccountDIDManager{Stub: noBlockStub{e.accountStub}}
+	chain := &ChainDIDManager{Stub: noBlockStub{e.chainStub}}
+	vc := &VCManager{Stub: noBlockStub{e.vcStub}}
+	e.as(e.user)
+
+	// calls which don't depend on time go on
+	_, hash := e.user.doc(t)
+	requireOK(t, account.Update(e.user.did, testDocAddr, hash, nil))
+	requireOK(t, chain.Apply(e.user.did, testAppChainDID, nil))
+	status := &didpb.VCStatus{}
+	decode(t, vc.GetVCStatus(testCID), status)
+	require.Equal(t, string(VCActive), status.Status)
+	verdict := &didpb.VCVerdict{}
+	decode(t, vc.VerifyVC(testCID), verdict)
+	require.True(t, verdict.Valid)
+	requireOK(t, vc.ListVCsByIssuer(e.user.did, 0, 0))
+	requireOK(t, vc.RevokeVC(e.user.did, testCID, "leaked", e.user.sign(t, []byte(testCID+"leaked"))))
+	e.as(e.admin)
+	requireOK(t, account.AddAdmin(e.admin.did, e.user.did))
+
+	// expiration can't be told without block time
+	status = &didpb.VCStatus{}
+	decode(t, vc.GetVCStatus(expiringCID), status)
+	require.Equal(t, string(VCUnknown), status.Status)
+	verdict = &didpb.VCVerdict{}
+	decode(t, vc.VerifyVC(expiringCID), verdict)
+	require.False(t, verdict.Valid)
+
+	// calls which depend on time fail
+	c.ID = "vc-expiring-2"
+	e.signCredential(e.user, c)
+	cb, err := c.Marshal()
+	require.Nil(t, err)
+	e.as(e.user)
+	requireCode(t, vc.StoreVC(e.user.did, cb, e.userDoc), ErrNoBlockContext)
+	e.as(e.admin)
+	requireOK(t, e.account.SetQuorum(e.admin.did, 2, 0))
+	requireCode(t, account.Propose(e.admin.did, string(ProposalFreeze), e.user.did, 0), ErrNoBlockContext)
+}
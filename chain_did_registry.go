@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"time"
 
 	"github.com/meshplus/bitxhub-core/agency"
 	"github.com/meshplus/bitxhub-core/boltvm"
@@ -12,6 +11,7 @@ import (
 	"github.com/meshplus/bitxhub-model/pb"
 	"github.com/meshplus/bitxid"
 	"github.com/meshplus/did-registry/converter"
+	"github.com/meshplus/did-registry/didpb"
 	"github.com/mitchellh/go-homedir"
 )
 
@@ -20,17 +20,6 @@ const (
 	adminMethodKey      = "admin-method"
 )
 
-// ChainDIDInfo represents information of a chain did.
-// TDDO: move to pb.
-type ChainDIDInfo struct {
-	ChainDID string          // chainDID name
-	Owner    string          // owner of the chainDID, is a did
-	DocAddr  string          // address where the doc file stored
-	DocHash  []byte          // hash of the doc file
-	Doc      bitxid.ChainDoc // doc content
-	Status   string          // status of chainDID
-}
-
 // ChainDIDManager .
 type ChainDIDManager struct {
 	boltvm.Stub
@@ -54,13 +43,23 @@ type ChainDIDInterRelaychain struct {
 
 // ChainDIDRegistry represents all things of chain did registry.
 // @SelfID: self chainDID
+// @Quorum: approval rule of sensitive actions
+// @SuperAdmin: super admin of the registry, first admin if empty
+// @PendingSuperAdmin: admin that super admin is transferring to
+// @Roles: roles granted to non-admin dids
+// @SelfFreezes: self freeze states of chainDIDs frozen by their owners
 type ChainDIDRegistry struct {
-	Registry    *bitxid.ChainDIDRegistry
-	Initalized  bool
-	SelfID      bitxid.DID
-	ParentID    bitxid.DID
-	ChildIDs    []bitxid.DID
-	IDConverter map[bitxid.DID]string
+	Registry          *bitxid.ChainDIDRegistry
+	Initalized        bool
+	SelfID            bitxid.DID
+	ParentID          bitxid.DID
+	ChildIDs          []bitxid.DID
+	IDConverter       map[bitxid.DID]string
+	Quorum            Quorum
+	SuperAdmin        bitxid.DID
+	PendingSuperAdmin bitxid.DID
+	Roles             RoleSet
+	SelfFreezes       SelfFreezes
 }
 
 // if you need to use registry table, you have to manully load it, so do docdb
@@ -85,7 +84,8 @@ func init() {
 }
 
 // Init sets up the whole registry,
-// caller will be admin of the registry.
+// caller will be admin of the registry,
+// the registry is seeded from genesis config set by the node, if any.
 func (mm *ChainDIDManager) Init(caller string) *boltvm.Response {
 	mr := mm.getChainDIDRegistry()
 
@@ -95,15 +95,16 @@ func (mm *ChainDIDManager) Init(caller string) *boltvm.Response {
 
 	callerDID := bitxid.DID(caller)
 	if mm.Caller() != admin {
-		return boltvm.Error("caller (" + mm.Caller() + ") is not admin(" + admin + ")")
+		return errorResponse(ErrNotAdmin, "caller ("+mm.Caller()+") is not admin("+admin+")")
 	}
 
 	if mm.Caller() != callerDID.GetAddress() {
-		return boltvm.Error(callerNotMatchError(mm.Caller(), caller))
+		return errorResponse(ErrCallerMismatch, callerNotMatchError(mm.Caller(), caller))
 	}
 	if mr.Initalized {
-		return boltvm.Error("init err, already init")
+		return errorResponse(ErrAlreadyInitialized, "init err, already init")
 	}
+	config := getGenesisConfig()
 	s := converter.StubToStorage(mm.Stub)
 	r, err := bitxid.NewChainDIDRegistry(
 		s,
@@ -114,18 +115,26 @@ func (mm *ChainDIDManager) Init(caller string) *boltvm.Response {
 		),
 	)
 	if err != nil {
-		return boltvm.Error("init err, " + err.Error())
+		return wrapErrorResponse(ErrRegistryRejected, "init err, ", err)
 	}
 
 	mr.Registry = r
 	err = mr.Registry.SetupGenesis()
 	if err != nil {
-		return boltvm.Error("init genesis err, " + err.Error())
+		return wrapErrorResponse(ErrInvalidArgument, "init genesis err, ", err)
 	}
 	mr.SelfID = mr.Registry.GetSelfID()
+	mr.SuperAdmin = callerDID
 	mr.ParentID = "did:bitxhub:relayroot:." // default parent
 	mr.Initalized = true
 	mr.IDConverter = make(map[bitxid.DID]string)
+
+	if config != nil {
+		if err := config.ChainDID.seed(mr, callerDID); err != nil {
+			return wrapErrorResponse(ErrInvalidArgument, "init genesis err, ", err)
+		}
+		mm.Logger().Info("Chain DID Registry seeded from genesis")
+	}
 	mm.Logger().Info("Chain DID Registry init success with admin: " + string(callerDID))
 
 	mm.SetObject(ChainDIDRegistryKey, mr)
@@ -139,15 +148,13 @@ func (mm *ChainDIDManager) SetParent(caller, parentID string) *boltvm.Response {
 	mr := mm.getChainDIDRegistry()
 
 	callerDID := bitxid.DID(caller)
-	if mm.Caller() != callerDID.GetAddress() {
-		return boltvm.Error(callerNotMatchError(mm.Caller(), caller))
-	}
-	if !mr.Registry.HasAdmin(callerDID) { // require Admin
-		return boltvm.Error("caller(" + string(callerDID) + ") has no permission")
+	if res := authorize(mm.Stub, mr.Initalized, caller, requireAdmin(mr, callerDID)); res != nil {
+		return res
 	}
 	mr.ParentID = bitxid.DID(parentID)
 
 	mm.SetObject(ChainDIDRegistryKey, mr)
+	postDIDEvent(mm.Stub, chainDIDRegistryName, EventSetParent, mr.ParentID, "", "", callerDID)
 	return boltvm.Success(nil)
 }
 
@@ -157,16 +164,14 @@ func (mm *ChainDIDManager) AddChild(caller, childID string) *boltvm.Response {
 	mr := mm.getChainDIDRegistry()
 
 	callerDID := bitxid.DID(caller)
-	if mm.Caller() != callerDID.GetAddress() {
-		return boltvm.Error(callerNotMatchError(mm.Caller(), caller))
-	}
-	if !mr.Registry.HasAdmin(callerDID) { // require Admin
-		return boltvm.Error("caller(" + string(callerDID) + ") has no permission")
+	if res := authorize(mm.Stub, mr.Initalized, caller, requireAdmin(mr, callerDID)); res != nil {
+		return res
 	}
 
 	mr.ChildIDs = append(mr.ChildIDs, bitxid.DID(childID))
 
 	mm.SetObject(ChainDIDRegistryKey, mr)
+	postDIDEvent(mm.Stub, chainDIDRegistryName, EventAddChild, bitxid.DID(childID), "", "", callerDID)
 	return boltvm.Success(nil)
 }
 
@@ -176,21 +181,21 @@ func (mm *ChainDIDManager) RemoveChild(caller, childID string) *boltvm.Response
 	mr := mm.getChainDIDRegistry()
 
 	callerDID := bitxid.DID(caller)
-	if mm.Caller() != callerDID.GetAddress() {
-		return boltvm.Error(callerNotMatchError(mm.Caller(), caller))
-	}
-	if !mr.Registry.HasAdmin(callerDID) { // require Admin
-		return boltvm.Error("caller(" + string(callerDID) + ") has no permission")
+	if res := authorize(mm.Stub, mr.Initalized, caller, requireAdmin(mr, callerDID)); res != nil {
+		return res
 	}
 
 	for i, child := range mr.ChildIDs {
 		if child == bitxid.DID(childID) {
-			mr.ChildIDs = append(mr.ChildIDs[:i], mr.ChildIDs[i:]...)
+			mr.ChildIDs = append(mr.ChildIDs[:i], mr.ChildIDs[i+1:]...)
+
+			mm.SetObject(ChainDIDRegistryKey, mr)
+			postDIDEvent(mm.Stub, chainDIDRegistryName, EventRemoveChild, child, "", "", callerDID)
+			return boltvm.Success(nil)
 		}
 	}
 
-	mm.SetObject(ChainDIDRegistryKey, mr)
-	return boltvm.Success(nil)
+	return errorResponse(ErrNotFound, childID+" is not child of the registry")
 }
 
 func (mr *ChainDIDRegistry) setConvertMap(chainDID string, appID string) {
@@ -202,75 +207,70 @@ func (mr *ChainDIDRegistry) getConvertMap(chainDID string) string {
 }
 
 // SetConvertMap .
-// caller should be admin.
+// caller should be admin or converter-manager.
 func (mm *ChainDIDManager) SetConvertMap(caller, chainDID string, appID string) *boltvm.Response {
 	mr := mm.getChainDIDRegistry()
 
 	callerDID := bitxid.DID(caller)
-	if mm.Caller() != callerDID.GetAddress() {
-		return boltvm.Error(callerNotMatchError(mm.Caller(), caller))
-	}
-	if !mr.Registry.HasAdmin(callerDID) { // require Admin
-		return boltvm.Error("caller(" + string(callerDID) + ") has no permission")
+	if res := authorize(mm.Stub, mr.Initalized, caller, requireRole(mr, callerDID, RoleConverterManager)); res != nil {
+		return res
 	}
 
 	mr.setConvertMap(chainDID, appID)
 
 	mm.SetObject(ChainDIDRegistryKey, mr)
+	postDIDEvent(mm.Stub, chainDIDRegistryName, EventSetConvertMap, bitxid.DID(chainDID), "", "", callerDID)
 	return boltvm.Success(nil)
 }
 
-// GetConvertMap .
+// GetConvertMap querys appchain id of the chainDID, anyone can query,
+// caller is kept for compatibility and not checked.
 func (mm *ChainDIDManager) GetConvertMap(caller, chainDID string) *boltvm.Response {
 	mr := mm.getChainDIDRegistry()
-	callerDID := bitxid.DID(caller)
-	if mm.Caller() != callerDID.GetAddress() {
-		return boltvm.Error(callerNotMatchError(mm.Caller(), caller))
+
+	if res := checkInitialized(mr.Initalized); res != nil {
+		return res
 	}
-	return boltvm.Success([]byte(mr.getConvertMap(chainDID)))
+
+	return stringResponse(mr.getConvertMap(chainDID))
 }
 
 // Apply applys for a chainDID name.
 func (mm *ChainDIDManager) Apply(caller, chain string, sig []byte) *boltvm.Response {
 	mr := mm.getChainDIDRegistry()
 
-	if !mr.Initalized {
-		return boltvm.Error("Registry not initialized")
-	}
-
 	callerDID := bitxid.DID(caller)
-	if mm.Caller() != callerDID.GetAddress() {
-		return boltvm.Error(callerNotMatchError(mm.Caller(), caller))
+	if res := authorize(mm.Stub, mr.Initalized, caller); res != nil {
+		return res
 	}
 
 	chainDID := bitxid.DID(chain)
 	if !chainDID.IsValidFormat() {
-		return boltvm.Error("not valid chainDID format")
+		return errorResponse(ErrInvalidFormat, "not valid chainDID format")
 	}
+	oldStatus := mr.statusOf(chainDID)
 	err := mr.Registry.Apply(callerDID, bitxid.DID(chainDID)) // success
 	if err != nil {
-		return boltvm.Error("apply err, " + err.Error())
+		return wrapErrorResponse(ErrRegistryRejected, "apply err, ", err)
 	}
 
 	mm.SetObject(ChainDIDRegistryKey, mr)
+	postDIDEvent(mm.Stub, chainDIDRegistryName, EventApply, chainDID, oldStatus, mr.statusOf(chainDID), callerDID)
 	return boltvm.Success(nil)
 }
 
 // AuditApply audits apply-request by others,
-// caller should be admin.
+// caller should be admin or auditor,
+// only allowed if quorum is not enabled, otherwise propose it.
 func (mm *ChainDIDManager) AuditApply(caller, chainDID string, result int32, sig []byte) *boltvm.Response {
 	mr := mm.getChainDIDRegistry()
 
-	if !mr.Initalized {
-		return boltvm.Error("Registry not initialized")
-	}
-
 	callerDID := bitxid.DID(caller)
-	if mm.Caller() != callerDID.GetAddress() {
-		return boltvm.Error(callerNotMatchError(mm.Caller(), caller))
+	if res := authorize(mm.Stub, mr.Initalized, caller, requireRole(mr, callerDID, RoleAuditor)); res != nil {
+		return res
 	}
-	if !mr.Registry.HasAdmin(callerDID) { // require Admin
-		return boltvm.Error("caller(" + string(callerDID) + ") has no permission")
+	if mr.Quorum.enabled() {
+		return errorResponse(ErrQuorumRequired, quorumRequiredError(ProposalAuditApply, &mr.Quorum))
 	}
 
 	var res bool
@@ -280,78 +280,86 @@ func (mm *ChainDIDManager) AuditApply(caller, chainDID string, result int32, sig
 		res = false
 	}
 	// TODO: verify sig
+	oldStatus := mr.statusOf(bitxid.DID(chainDID))
 	err := mr.Registry.AuditApply(bitxid.DID(chainDID), res)
 	if err != nil {
-		return boltvm.Error("audit apply err, " + err.Error())
+		return wrapErrorResponse(ErrRegistryRejected, "audit apply err, ", err)
 	}
 
 	mm.SetObject(ChainDIDRegistryKey, mr)
+	postDIDEvent(mm.Stub, chainDIDRegistryName, EventAuditApply, bitxid.DID(chainDID), oldStatus, mr.statusOf(bitxid.DID(chainDID)), callerDID)
 	return boltvm.Success(nil)
 }
 
 // Audit audits arbitrary status of the chainDID,
-// caller should be admin.
+// caller should be admin or auditor, only allowed if quorum is not enabled.
 func (mm *ChainDIDManager) Audit(caller, chainDID string, status string, sig []byte) *boltvm.Response {
 	mr := mm.getChainDIDRegistry()
 
-	if !mr.Initalized {
-		return boltvm.Error("Registry not initialized")
-	}
-
 	callerDID := bitxid.DID(caller)
-	if mm.Caller() != callerDID.GetAddress() {
-		return boltvm.Error(callerNotMatchError(mm.Caller(), caller))
+	if res := authorize(mm.Stub, mr.Initalized, caller, requireRole(mr, callerDID, RoleAuditor)); res != nil {
+		return res
 	}
-
-	if !mr.Registry.HasAdmin(callerDID) {
-		return boltvm.Error("caller(" + string(callerDID) + ") has no permission")
+	if mr.Quorum.enabled() {
+		return errorResponse(ErrQuorumRequired, "audit err, arbitrary audit is disabled under quorum, propose AuditApply, Freeze or UnFreeze instead")
 	}
+	oldStatus := mr.statusOf(bitxid.DID(chainDID))
 	err := mr.Registry.Audit(bitxid.DID(chainDID), bitxid.StatusType(status))
 	if err != nil {
-		return boltvm.Error(err.Error())
+		return wrapErrorResponse(ErrRegistryRejected, "audit err, ", err)
 	}
 
 	mm.SetObject(ChainDIDRegistryKey, mr)
+	postDIDEvent(mm.Stub, chainDIDRegistryName, EventAudit, bitxid.DID(chainDID), oldStatus, mr.statusOf(bitxid.DID(chainDID)), callerDID)
 	return boltvm.Success(nil)
 }
 
-// Register anchors infomation for the chainDID.
+// Register anchors infomation for the chainDID,
+// caller should be owner of the chainDID, admin or registrar,
+// sig should be made by the owner over registerMsg if caller is not the owner.
 func (mm *ChainDIDManager) Register(caller, chainDID string, docAddr string, docHash []byte, sig []byte) *boltvm.Response {
 	mr := mm.getChainDIDRegistry()
 
-	if !mr.Initalized {
-		return boltvm.Error("Registry not initialized")
-	}
-
 	callerDID := bitxid.DID(caller)
-	if mm.Caller() != callerDID.GetAddress() {
-		return boltvm.Error(callerNotMatchError(mm.Caller(), caller))
+	if res := authorize(mm.Stub, mr.Initalized, caller); res != nil {
+		return res
 	}
 
-	item, _, _, err := mr.Registry.Resolve(bitxid.DID(chainDID))
+	item, err := mr.resolveItem(bitxid.DID(chainDID))
 	if err != nil {
-		return boltvm.Error(err.Error())
+		return wrapErrorResponse(ErrRegistryRejected, "register err, ", err)
 	}
 
-	if !mr.Registry.HasAdmin(callerDID) && item.Owner != callerDID {
-		return boltvm.Error(notAdminOrOwnerError(chainDID, caller))
+	if !mr.hasRole(callerDID, RoleRegistrar) && item.Owner != callerDID {
+		return errorResponse(ErrNotOwner, notAdminOrOwnerError(chainDID, caller))
 	}
-	// TODO: verify sig
+	if item.Owner != callerDID {
+		msg := registerMsg(chainDIDRegistryName, bitxid.DID(chainDID), docAddr, docHash)
+		if res := requireSig(mm.Stub, item.Owner, msg, sig)(); res != nil {
+			return res
+		}
+	}
+	oldStatus := item.Status
 	_, _, err = mr.Registry.Register(bitxid.DID(chainDID), docAddr, docHash)
 	if err != nil {
-		return boltvm.Error("register err, " + err.Error())
+		return wrapErrorResponse(ErrRegistryRejected, "register err, ", err)
 	}
 
-	item, _, _, err = mr.Registry.Resolve(bitxid.DID(chainDID))
+	item, err = mr.resolveItem(bitxid.DID(chainDID))
 	if err != nil {
-		return boltvm.Error(err.Error())
+		return wrapErrorResponse(ErrRegistryRejected, "register err, ", err)
 	}
 
 	mm.SetObject(ChainDIDRegistryKey, mr)
+	postDIDEvent(mm.Stub, chainDIDRegistryName, EventRegister, bitxid.DID(chainDID), oldStatus, item.Status, callerDID)
 	data, err := bitxid.Marshal(item)
+	if err != nil {
+		return wrapErrorResponse(ErrInternal, "", err)
+	}
 
 	// ibtp without index
 	ibtps, err := mr.constructIBTPs(
+		recordTimestamp(mm.Stub),
 		string(constant.MethodRegistryContractAddr),
 		"Synchronize",
 		string(mr.SelfID),
@@ -365,22 +373,27 @@ func (mm *ChainDIDManager) Register(caller, chainDID string, docAddr string, doc
 		data,
 	)
 	if err != nil {
-		return boltvm.Error(err.Error())
+		return wrapErrorResponse(ErrInternal, "", err)
 	}
 
 	ibtpsBytes, err := ibtps.Marshal()
 	if err != nil {
-		return boltvm.Error(err.Error())
+		return wrapErrorResponse(ErrInternal, "", err)
 	}
 
-	return mm.CrossInvoke(constant.InterRelayBrokerContractAddr.String(), "RecordIBTPs", pb.Bytes(ibtpsBytes))
+	res := mm.CrossInvoke(constant.InterRelayBrokerContractAddr.String(), "RecordIBTPs", pb.Bytes(ibtpsBytes))
+	if !res.Ok {
+		return errorResponse(ErrCrossInvoke, "record ibtps err, "+string(res.Result))
+	}
+	return res
 
 	// return boltvm.Success(nil)
 	// TODO: construct chain multi sigs
 	// return mr.synchronizeOut(string(callerDID), item, [][]byte{[]byte(".")})
 }
 
-func (mr *ChainDIDRegistry) constructIBTPs(contractID, function, fromChainDID string, toChainDIDs []string, data []byte) (*pb.IBTPs, error) {
+// constructIBTPs constructs ibtps stamped with timestamp of the current transaction in nanoseconds.
+func (mr *ChainDIDRegistry) constructIBTPs(timestamp int64, contractID, function, fromChainDID string, toChainDIDs []string, data []byte) (*pb.IBTPs, error) {
 	content := pb.Content{
 		SrcContractId: contractID,
 		DstContractId: contractID,
@@ -411,7 +424,7 @@ func (mr *ChainDIDRegistry) constructIBTPs(contractID, function, fromChainDID st
 			From:      from,
 			To:        to,
 			Type:      pb.IBTP_INTERCHAIN,
-			Timestamp: time.Now().UnixNano(),
+			Timestamp: timestamp,
 			Proof:     []byte("1"),
 			Payload:   payload,
 		})
@@ -420,38 +433,30 @@ func (mr *ChainDIDRegistry) constructIBTPs(contractID, function, fromChainDID st
 	return &pb.IBTPs{Ibtps: ibtps}, nil
 }
 
-// Event .
-type Event struct {
-	contractID   string
-	function     string
-	fromChainDID string
-	data         []byte
-	tos          []string
-}
-
 // Update updates chainDID infomation.
 func (mm *ChainDIDManager) Update(caller, chainDID string, docAddr string, docHash []byte, sig []byte) *boltvm.Response {
 	mr := mm.getChainDIDRegistry()
 
-	if !mr.Initalized {
-		return boltvm.Error("Registry not initialized")
-	}
-
 	callerDID := bitxid.DID(caller)
-	if mm.Caller() != callerDID.GetAddress() {
-		return boltvm.Error(callerNotMatchError(mm.Caller(), caller))
+	if res := authorize(mm.Stub, mr.Initalized, caller); res != nil {
+		return res
 	}
 
-	item, _, _, err := mr.Registry.Resolve(bitxid.DID(chainDID))
-	if !mr.Registry.HasAdmin(callerDID) && item.Owner != callerDID {
-		return boltvm.Error(notAdminOrOwnerError(chainDID, caller))
+	item, err := mr.resolveItem(bitxid.DID(chainDID))
+	if err != nil {
+		return wrapErrorResponse(ErrRegistryRejected, "update err, ", err)
+	}
+	if !mr.hasAdmin(callerDID) && item.Owner != callerDID {
+		return errorResponse(ErrNotOwner, notAdminOrOwnerError(chainDID, caller))
 	}
+	oldStatus := mr.statusOf(bitxid.DID(chainDID))
 	_, _, err = mr.Registry.Update(bitxid.DID(chainDID), docAddr, docHash)
 	if err != nil {
-		return boltvm.Error("update err, " + err.Error())
+		return wrapErrorResponse(ErrRegistryRejected, "update err, ", err)
 	}
 
 	mm.SetObject(ChainDIDRegistryKey, mr)
+	postDIDEvent(mm.Stub, chainDIDRegistryName, EventUpdate, bitxid.DID(chainDID), oldStatus, mr.statusOf(bitxid.DID(chainDID)), callerDID)
 	return boltvm.Success(nil)
 }
 
@@ -459,19 +464,19 @@ func (mm *ChainDIDManager) Update(caller, chainDID string, docAddr string, docHa
 func (mm *ChainDIDManager) Resolve(chainDID string) *boltvm.Response {
 	mr := mm.getChainDIDRegistry()
 
-	if !mr.Initalized {
-		return boltvm.Error("Registry not initialized")
+	if res := checkInitialized(mr.Initalized); res != nil {
+		return res
 	}
 
 	item, _, exist, err := mr.Registry.Resolve(bitxid.DID(chainDID))
 	if err != nil {
-		return boltvm.Error(err.Error())
+		return wrapErrorResponse(ErrRegistryRejected, "resolve err, ", err)
 	}
 
-	chainDIDInfo := ChainDIDInfo{}
+	chainDIDInfo := &didpb.ChainDIDInfo{}
 	if exist {
-		chainDIDInfo = ChainDIDInfo{
-			ChainDID: string(item.ID),
+		chainDIDInfo = &didpb.ChainDIDInfo{
+			ChainDid: string(item.ID),
 			Owner:    string(item.Owner),
 			DocAddr:  item.DocAddr,
 			DocHash:  item.DocHash,
@@ -514,74 +519,95 @@ func (mm *ChainDIDManager) Resolve(chainDID string) *boltvm.Response {
 		// return boltvm.Success([]byte("routing..."))
 	}
 
-	b, err := bitxid.Marshal(chainDIDInfo)
-	if err != nil {
-		return boltvm.Error(err.Error())
-	}
-
-	return boltvm.Success(b)
+	return success(chainDIDInfo)
 }
 
 // Freeze freezes the chainDID in the registry,
-// caller should be admin.
+// caller should be admin or freezer,
+// only allowed if quorum is not enabled, otherwise propose it.
 func (mm *ChainDIDManager) Freeze(caller, chainDID string, sig []byte) *boltvm.Response {
 	mr := mm.getChainDIDRegistry()
 
-	if !mr.Initalized {
-		return boltvm.Error("Registry not initialized")
-	}
-
 	callerDID := bitxid.DID(caller)
-	if mm.Caller() != callerDID.GetAddress() {
-		return boltvm.Error(callerNotMatchError(mm.Caller(), caller))
+	if res := authorize(mm.Stub, mr.Initalized, caller, requireRole(mr, callerDID, RoleFreezer)); res != nil {
+		return res
 	}
-	if !mr.Registry.HasAdmin(callerDID) { // require Admin
-		return boltvm.Error("caller(" + string(callerDID) + ") has no permission")
+	if mr.Quorum.enabled() {
+		return errorResponse(ErrQuorumRequired, quorumRequiredError(ProposalFreeze, &mr.Quorum))
 	}
 
-	item, _, _, err := mr.Registry.Resolve(bitxid.DID(chainDID))
-	if item.Status == bitxid.Frozen {
-		return boltvm.Error(chainDID + " was already frozen")
-	}
-
-	err = mr.Registry.Freeze(bitxid.DID(chainDID))
+	oldStatus := mr.statusOf(bitxid.DID(chainDID))
+	err := mr.freeze(bitxid.DID(chainDID))
 	if err != nil {
-		return boltvm.Error(err.Error())
+		return wrapErrorResponse(ErrRegistryRejected, "freeze err, ", err)
 	}
 
 	mm.SetObject(ChainDIDRegistryKey, mr)
+	postDIDEvent(mm.Stub, chainDIDRegistryName, EventFreeze, bitxid.DID(chainDID), oldStatus, mr.statusOf(bitxid.DID(chainDID)), callerDID)
 	return boltvm.Success(nil)
 }
 
 // UnFreeze unfreezes the chainDID,
-// caller should be admin.
+// caller should be admin or freezer,
+// only allowed if quorum is not enabled, otherwise propose it.
 func (mm *ChainDIDManager) UnFreeze(caller, chainDID string, sig []byte) *boltvm.Response {
 	mr := mm.getChainDIDRegistry()
 
-	if !mr.Initalized {
-		return boltvm.Error("Registry not initialized")
+	callerDID := bitxid.DID(caller)
+	if res := authorize(mm.Stub, mr.Initalized, caller, requireRole(mr, callerDID, RoleFreezer)); res != nil {
+		return res
+	}
+	if mr.Quorum.enabled() {
+		return errorResponse(ErrQuorumRequired, quorumRequiredError(ProposalUnFreeze, &mr.Quorum))
 	}
 
-	callerDID := bitxid.DID(caller)
-	if mm.Caller() != callerDID.GetAddress() {
-		return boltvm.Error(callerNotMatchError(mm.Caller(), caller))
+	oldStatus := mr.statusOf(bitxid.DID(chainDID))
+	err := mr.unfreeze(bitxid.DID(chainDID))
+	if err != nil {
+		return wrapErrorResponse(ErrRegistryRejected, "unfreeze err, ", err)
 	}
-	if !mr.Registry.HasAdmin(callerDID) { // require Admin
-		return boltvm.Error("caller(" + string(callerDID) + ") has no permission")
+
+	mm.SetObject(ChainDIDRegistryKey, mr)
+	postDIDEvent(mm.Stub, chainDIDRegistryName, EventUnFreeze, bitxid.DID(chainDID), oldStatus, mr.statusOf(bitxid.DID(chainDID)), callerDID)
+	return boltvm.Success(nil)
+}
+
+func (mr *ChainDIDRegistry) freeze(chainDID bitxid.DID) error {
+	item, err := mr.resolveItem(chainDID)
+	if err != nil {
+		return err
 	}
+	if item.Status == bitxid.Frozen {
+		return newError(ErrAlreadyFrozen, "%s was already frozen", chainDID)
+	}
+	return mr.Registry.Freeze(chainDID)
+}
 
-	item, _, _, err := mr.Registry.Resolve(bitxid.DID(chainDID))
+func (mr *ChainDIDRegistry) unfreeze(chainDID bitxid.DID) error {
+	item, err := mr.resolveItem(chainDID)
+	if err != nil {
+		return err
+	}
 	if item.Status != bitxid.Frozen {
-		return boltvm.Error(chainDID + " was not frozen")
+		return newError(ErrNotFrozen, "%s was not frozen", chainDID)
+	}
+	if err := mr.Registry.UnFreeze(chainDID); err != nil {
+		return err
 	}
+	mr.SelfFreezes.unfreeze(chainDID)
+	return nil
+}
 
-	err = mr.Registry.UnFreeze(bitxid.DID(chainDID))
+// resolveItem resolves the chainDID, returns err if it doesn't exist.
+func (mr *ChainDIDRegistry) resolveItem(chainDID bitxid.DID) (*bitxid.ChainItem, error) {
+	item, _, exist, err := mr.Registry.Resolve(chainDID)
 	if err != nil {
-		return boltvm.Error(err.Error())
+		return nil, err
 	}
-
-	mm.SetObject(ChainDIDRegistryKey, mr)
-	return boltvm.Success(nil)
+	if !exist {
+		return nil, newError(ErrNotFound, "%s not existed", chainDID)
+	}
+	return item, nil
 }
 
 // Delete deletes the chainDID,
@@ -589,26 +615,27 @@ func (mm *ChainDIDManager) UnFreeze(caller, chainDID string, sig []byte) *boltvm
 func (mm *ChainDIDManager) Delete(caller, chainDID string, sig []byte) *boltvm.Response {
 	mr := mm.getChainDIDRegistry()
 
-	if !mr.Initalized {
-		return boltvm.Error("Registry not initialized")
-	}
-
 	callerDID := bitxid.DID(caller)
-	if mm.Caller() != callerDID.GetAddress() {
-		return boltvm.Error(callerNotMatchError(mm.Caller(), caller))
+	if res := authorize(mm.Stub, mr.Initalized, caller); res != nil {
+		return res
 	}
 
-	item, _, _, err := mr.Registry.Resolve(bitxid.DID(chainDID))
+	item, err := mr.resolveItem(bitxid.DID(chainDID))
+	if err != nil {
+		return wrapErrorResponse(ErrRegistryRejected, "delete err, ", err)
+	}
 	if item.Owner != callerDID {
-		return boltvm.Error("caller(" + string(callerDID) + ") is not the owner of " + chainDID)
+		return errorResponse(ErrNotOwner, "caller("+string(callerDID)+") is not the owner of "+chainDID)
 	}
 
+	oldStatus := item.Status
 	err = mr.Registry.Delete(bitxid.DID(chainDID))
 	if err != nil {
-		return boltvm.Error(err.Error())
+		return wrapErrorResponse(ErrRegistryRejected, "delete err, ", err)
 	}
 
 	mm.SetObject(ChainDIDRegistryKey, mr)
+	postDIDEvent(mm.Stub, chainDIDRegistryName, EventDelete, bitxid.DID(chainDID), oldStatus, "", callerDID)
 	return boltvm.Success(nil)
 }
 
@@ -618,14 +645,14 @@ func (mm *ChainDIDManager) Delete(caller, chainDID string, sig []byte) *boltvm.R
 func (mm *ChainDIDManager) Synchronize(from string, itemb []byte) *boltvm.Response {
 	mr := mm.getChainDIDRegistry()
 
-	if !mr.Initalized {
-		return boltvm.Error("Registry not initialized")
+	if res := checkInitialized(mr.Initalized); res != nil {
+		return res
 	}
 
 	item := &bitxid.ChainItem{}
 	err := bitxid.Unmarshal(itemb, item)
 	if err != nil {
-		return boltvm.Error("Synchronize err: " + err.Error())
+		return wrapErrorResponse(ErrInvalidFormat, "Synchronize err: ", err)
 	}
 	// TODO: verify multi sigs of from chain
 	// sigs := [][]byte{}
@@ -636,7 +663,7 @@ func (mm *ChainDIDManager) Synchronize(from string, itemb []byte) *boltvm.Respon
 
 	err = mr.Registry.Synchronize(item)
 	if err != nil {
-		return boltvm.Error("Synchronize err: " + err.Error())
+		return wrapErrorResponse(ErrRegistryRejected, "Synchronize err: ", err)
 	}
 
 	mm.SetObject(ChainDIDRegistryKey, mr)
@@ -649,11 +676,11 @@ func (mm *ChainDIDManager) synchronizeOut(from string, item *bitxid.ChainItem, s
 
 	itemBytes, err := bitxid.Marshal(item)
 	if err != nil {
-		return boltvm.Error(err.Error())
+		return wrapErrorResponse(ErrInternal, "", err)
 	}
 	sigsBytes, err := bitxid.Marshal(item)
 	if err != nil {
-		return boltvm.Error(err.Error())
+		return wrapErrorResponse(ErrInternal, "", err)
 	}
 	content := pb.Content{
 		SrcContractId: mm.Callee(),
@@ -664,14 +691,14 @@ func (mm *ChainDIDManager) synchronizeOut(from string, item *bitxid.ChainItem, s
 	}
 	bytes, err := content.Marshal()
 	if err != nil {
-		return boltvm.Error(err.Error())
+		return wrapErrorResponse(ErrInternal, "", err)
 	}
 	payload, err := json.Marshal(pb.Payload{
 		Encrypted: false,
 		Content:   bytes,
 	})
 	if err != nil {
-		return boltvm.Error(err.Error())
+		return wrapErrorResponse(ErrInternal, "", err)
 	}
 	fromChainID := mr.IDConverter[mr.SelfID]
 	for _, child := range mr.ChildIDs {
@@ -683,12 +710,12 @@ func (mm *ChainDIDManager) synchronizeOut(from string, item *bitxid.ChainItem, s
 		}
 		data, err := ibtp.Marshal()
 		if err != nil {
-			return boltvm.Error(err.Error())
+			return wrapErrorResponse(ErrInternal, "", err)
 		}
 		res := mm.CrossInvoke(constant.InterchainContractAddr.String(), "HandleDID", pb.Bytes(data))
 		if !res.Ok {
 			mm.Logger().Error("synchronizeOut err, ", string(res.Result))
-			return res
+			return errorResponse(ErrCrossInvoke, "synchronizeOut err, "+string(res.Result))
 		}
 	}
 
@@ -698,84 +725,98 @@ func (mm *ChainDIDManager) synchronizeOut(from string, item *bitxid.ChainItem, s
 
 // IsSuperAdmin querys whether caller is the super admin of the registry.
 func (mr *ChainDIDRegistry) isSuperAdmin(caller bitxid.DID) bool {
-	admins := mr.Registry.GetAdmins()
-	return admins[0] == caller
+	return caller != "" && mr.superAdmin() == caller
+}
+
+// hasAdmin querys whether caller is an admin of the registry.
+func (mr *ChainDIDRegistry) hasAdmin(caller bitxid.DID) bool {
+	return mr.Registry.HasAdmin(caller)
 }
 
 // HasAdmin querys whether caller is an admin of the registry.
 func (mm *ChainDIDManager) HasAdmin(caller string) *boltvm.Response {
 	mr := mm.getChainDIDRegistry()
 
-	res := mr.Registry.HasAdmin(bitxid.DID(caller))
-	if res == true {
-		return boltvm.Success([]byte("1"))
+	if res := checkInitialized(mr.Initalized); res != nil {
+		return res
 	}
-	return boltvm.Success([]byte("0"))
+
+	return boolResponse(mr.hasAdmin(bitxid.DID(caller)))
 }
 
 // GetAdmins get admin list of the registry.
 func (mm *ChainDIDManager) GetAdmins() *boltvm.Response {
-	mr := &ChainDIDRegistry{}
-	mm.GetObject(ChainDIDRegistryKey, &mr)
+	mr := mm.getChainDIDRegistry()
 
-	admins := mr.Registry.GetAdmins()
-	data, err := json.Marshal(admins)
-	if err != nil {
-		return boltvm.Error(err.Error())
+	if res := checkInitialized(mr.Initalized); res != nil {
+		return res
 	}
-	return boltvm.Success([]byte(data))
+
+	return didsResponse(mr.Registry.GetAdmins())
 }
 
 // AddAdmin adds caller to the admin of the registry,
-// caller should be super admin.
+// caller should be super admin,
+// only allowed if quorum is not enabled, otherwise propose it.
 func (mm *ChainDIDManager) AddAdmin(caller string, adminToAdd string) *boltvm.Response {
 	mr := mm.getChainDIDRegistry()
 
 	callerDID := bitxid.DID(caller)
-	if mm.Caller() != callerDID.GetAddress() {
-		return boltvm.Error(callerNotMatchError(mm.Caller(), caller))
+	if res := authorize(mm.Stub, mr.Initalized, caller, requireSuperAdmin(mr, callerDID)); res != nil {
+		return res
 	}
-	if !mr.isSuperAdmin(callerDID) { // require Admin
-		return boltvm.Error("caller(" + string(callerDID) + ") doesn't have enough permission")
+	if mr.Quorum.enabled() {
+		return errorResponse(ErrQuorumRequired, quorumRequiredError(ProposalAddAdmin, &mr.Quorum))
 	}
 
 	err := mr.Registry.AddAdmin(bitxid.DID(adminToAdd))
 	if err != nil {
-		return boltvm.Error(err.Error())
+		return wrapErrorResponse(ErrAlreadyExists, "add admin err, ", err)
 	}
 
 	mm.SetObject(ChainDIDRegistryKey, mr)
+	postAdminEvent(mm.Stub, chainDIDRegistryName, AdminAdded, bitxid.DID(adminToAdd), callerDID)
 	return boltvm.Success(nil)
 }
 
 // RemoveAdmin remove admin of the registry,
-// caller should be super admin, super admin can not rm self.
+// caller should be super admin, super admin can not rm self,
+// only allowed if quorum is not enabled, otherwise propose it.
 func (mm *ChainDIDManager) RemoveAdmin(caller string, adminToRm string) *boltvm.Response {
 	mr := mm.getChainDIDRegistry()
 
 	callerDID := bitxid.DID(caller)
-	if mm.Caller() != callerDID.GetAddress() {
-		return boltvm.Error(callerNotMatchError(mm.Caller(), caller))
-	}
-	if !mr.isSuperAdmin(callerDID) { // require super Admin
-		return boltvm.Error("caller(" + string(callerDID) + ") doesn't have enough permission")
+	if res := authorize(mm.Stub, mr.Initalized, caller, requireSuperAdmin(mr, callerDID)); res != nil {
+		return res
 	}
-	if !mr.Registry.HasAdmin(bitxid.DID(adminToRm)) {
-		return boltvm.Error("caller (" + caller + ") is not admin")
-	}
-	if mr.isSuperAdmin(bitxid.DID(adminToRm)) {
-		return boltvm.Error("cannot rm super admin")
+	if mr.Quorum.enabled() {
+		return errorResponse(ErrQuorumRequired, quorumRequiredError(ProposalRemoveAdmin, &mr.Quorum))
 	}
 
-	err := mr.Registry.RemoveAdmin(bitxid.DID(adminToRm))
+	err := mr.removeAdmin(bitxid.DID(adminToRm))
 	if err != nil {
-		return boltvm.Error(err.Error())
+		return wrapErrorResponse(ErrNotFound, "remove admin err, ", err)
 	}
 
 	mm.SetObject(ChainDIDRegistryKey, mr)
+	postAdminEvent(mm.Stub, chainDIDRegistryName, AdminRemoved, bitxid.DID(adminToRm), callerDID)
 	return boltvm.Success(nil)
 }
 
+// removeAdmin removes an admin other than the super admin.
+func (mr *ChainDIDRegistry) removeAdmin(admin bitxid.DID) error {
+	if !mr.hasAdmin(admin) {
+		return newError(ErrNotFound, "%s is not admin", admin)
+	}
+	if mr.isSuperAdmin(admin) {
+		return newError(ErrInvalidArgument, "cannot rm super admin, transfer it first")
+	}
+	if mr.PendingSuperAdmin == admin {
+		mr.PendingSuperAdmin = ""
+	}
+	return mr.Registry.RemoveAdmin(admin)
+}
+
 func callerNotMatchError(c1 string, c2 string) string {
 	return "tx.From(" + c1 + ") and callerDID:(" + c2 + ") not the comply"
 }
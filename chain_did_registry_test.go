@@ -0,0 +1,1021 @@
+package contracts
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/meshplus/bitxhub-core/boltvm"
+	"github.com/meshplus/bitxhub-model/constant"
+	"github.com/meshplus/bitxhub-model/pb"
+	"github.com/meshplus/bitxid"
+	"github.com/meshplus/did-registry/didpb"
+	"github.com/meshplus/did-registry/stubtest"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testAppChainDID  = "did:bitxhub:appchain002:."
+	testOtherChainID = "did:bitxhub:appchain003:."
+)
+
+// unmarshaler is a didpb message to decode results into.
+type unmarshaler interface {
+	Unmarshal([]byte) error
+}
+
+// decode asserts res succeeded and decodes its result into msg.
+func decode(t *testing.T, res *boltvm.Response, msg unmarshaler) {
+	t.Helper()
+	requireOK(t, res)
+	require.Nil(t, msg.Unmarshal(res.Result))
+}
+
+func resolveChainDID(t *testing.T, e *testEnv, chainDID string) *didpb.ChainDIDInfo {
+	info := &didpb.ChainDIDInfo{}
+	decode(t, e.chain.Resolve(chainDID), info)
+	return info
+}
+
+func chainDIDStatus(t *testing.T, e *testEnv, chainDID string) bitxid.StatusType {
+	return bitxid.StatusType(resolveChainDID(t, e, chainDID).Status)
+}
+
+// applied applies testAppChainDID by user.
+func applied(e *testEnv) {
+	e.as(e.user)
+	requireOK(e.t, e.chain.Apply(e.user.did, testAppChainDID, nil))
+}
+
+// audited applies and approves testAppChainDID for user.
+func audited(e *testEnv) {
+	applied(e)
+	e.as(e.admin)
+	requireOK(e.t, e.chain.AuditApply(e.admin.did, testAppChainDID, 1, nil))
+}
+
+// registered registers testAppChainDID owned by user.
+func registered(e *testEnv) {
+	e.newChainDID(e.user, testAppChainDID)
+}
+
+// frozen registers and freezes testAppChainDID owned by user.
+func frozen(e *testEnv) {
+	registered(e)
+	e.as(e.admin)
+	requireOK(e.t, e.chain.Freeze(e.admin.did, testAppChainDID, nil))
+}
+
+// quorumEnabled requires approvals of two admins in did registries.
+func quorumEnabled(e *testEnv) {
+	enableQuorum(e)
+}
+
+// enableQuorum adds another admin and requires approvals of both admins
+// in did registries, returns the added admin.
+func enableQuorum(e *testEnv) *testAccount {
+	other := e.newAdmin()
+	e.as(e.admin)
+	requireOK(e.t, e.chain.SetQuorum(e.admin.did, 2, 0))
+	requireOK(e.t, e.account.SetQuorum(e.admin.did, 2, 0))
+	return other
+}
+
+func grantChainRole(e *testEnv, role Role) {
+	e.as(e.admin)
+	requireOK(e.t, e.chain.GrantRole(e.admin.did, e.user.did, string(role)))
+}
+
+func TestChainDIDManager_Init(t *testing.T) {
+	stranger := newTestAccount(t)
+	tests := []struct {
+		name   string
+		caller func(e *testEnv) *testAccount
+		did    func(e *testEnv) string
+		twice  bool
+		code   ErrorCode
+	}{
+		{
+			name:   "not admin",
+			caller: func(e *testEnv) *testAccount { return stranger },
+			did:    func(e *testEnv) string { return stranger.did },
+			code:   ErrNotAdmin,
+		},
+		{
+			name:   "caller mismatch",
+			caller: func(e *testEnv) *testAccount { return e.admin },
+			did:    func(e *testEnv) string { return stranger.did },
+			code:   ErrCallerMismatch,
+		},
+		{
+			name:   "already initialized",
+			caller: func(e *testEnv) *testAccount { return e.admin },
+			did:    func(e *testEnv) string { return e.admin.did },
+			twice:  true,
+			code:   ErrAlreadyInitialized,
+		},
+		{
+			name:   "success",
+			caller: func(e *testEnv) *testAccount { return e.admin },
+			did:    func(e *testEnv) string { return e.admin.did },
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEnv(t)
+			e.as(tt.caller(e))
+			if tt.twice {
+				requireOK(t, e.chain.Init(tt.did(e)))
+			}
+			requireCode(t, e.chain.Init(tt.did(e)), tt.code)
+			if tt.code != "" {
+				return
+			}
+			admins := &didpb.StringSlice{}
+			decode(t, e.chain.GetAdmins(), admins)
+			require.Equal(t, []string{e.admin.did}, admins.Slice)
+			superAdmin := &didpb.String{}
+			decode(t, e.chain.GetSuperAdmin(), superAdmin)
+			require.Equal(t, e.admin.did, superAdmin.Value)
+			require.Equal(t, bitxid.Normal, chainDIDStatus(t, e, testChainDID))
+		})
+	}
+}
+
+func TestChainDIDManager_InitGenesis(t *testing.T) {
+	other := newTestAccount(t)
+	root, err := ioutil.TempDir("", "did")
+	require.Nil(t, err)
+	defer os.RemoveAll(root)
+	require.Nil(t, os.Setenv("BITXHUB_PATH", root))
+	defer os.Unsetenv("BITXHUB_PATH")
+
+	config, err := LoadGenesisConfig()
+	require.Nil(t, err)
+	require.Nil(t, config)
+
+	path := filepath.Join(root, genesisConfigName)
+	require.Nil(t, ioutil.WriteFile(path, []byte("[chain_did"), 0644))
+	_, err = LoadGenesisConfig()
+	require.NotNil(t, err)
+
+	genesis := fmt.Sprintf("[chain_did]\nadmins = [%q]\n", other.did)
+	require.Nil(t, ioutil.WriteFile(path, []byte(genesis), 0644))
+	config, err = LoadGenesisConfig()
+	require.Nil(t, err)
+	SetGenesisConfig(config)
+	defer SetGenesisConfig(nil)
+
+	e := newTestEnv(t)
+	requireOK(t, e.as(e.admin).chain.Init(e.admin.did))
+
+	admins := &didpb.StringSlice{}
+	decode(t, e.chain.GetAdmins(), admins)
+	require.Equal(t, []string{e.admin.did, other.did}, admins.Slice)
+}
+
+func TestInitGenesisInvalid(t *testing.T) {
+	chainInit := func(e *testEnv) *boltvm.Response { return e.chain.Init(e.admin.did) }
+	accountInit := func(e *testEnv) *boltvm.Response { return e.account.Init(e.admin.did) }
+	vcInit := func(e *testEnv) *boltvm.Response { return e.vc.Init(e.admin.did) }
+	tests := []struct {
+		name   string
+		config *GenesisConfig
+		init   func(e *testEnv) *boltvm.Response
+		admins func(e *testEnv) *boltvm.Response
+	}{
+		{
+			name:   "chain admin not account did",
+			config: &GenesisConfig{ChainDID: ChainDIDGenesis{Admins: []string{testAppChainDID}}},
+			init:   chainInit,
+			admins: func(e *testEnv) *boltvm.Response { return e.chain.GetAdmins() },
+		},
+		{
+			name:   "chain parent not chain did",
+			config: &GenesisConfig{ChainDID: ChainDIDGenesis{Parent: "parent"}},
+			init:   chainInit,
+			admins: func(e *testEnv) *boltvm.Response { return e.chain.GetAdmins() },
+		},
+		{
+			name:   "convert map of invalid did",
+			config: &GenesisConfig{ChainDID: ChainDIDGenesis{IDConverter: []IDConverterMap{{ChainDID: "chain", AppID: "app"}}}},
+			init:   chainInit,
+			admins: func(e *testEnv) *boltvm.Response { return e.chain.GetAdmins() },
+		},
+		{
+			name:   "account child not chain did",
+			config: &GenesisConfig{AccountDID: AccountDIDGenesis{Children: []string{"child"}}},
+			init:   accountInit,
+			admins: func(e *testEnv) *boltvm.Response { return e.account.GetAdmins() },
+		},
+		{
+			name:   "claim type without id",
+			config: &GenesisConfig{VC: VCGenesis{ClaimTypes: []GenesisClaimTyp{{}}}},
+			init:   vcInit,
+			admins: func(e *testEnv) *boltvm.Response { return e.vc.GetAdmins() },
+		},
+		{
+			name:   "claim type twice",
+			config: &GenesisConfig{VC: VCGenesis{ClaimTypes: []GenesisClaimTyp{{ID: testCTID}, {ID: testCTID}}}},
+			init:   vcInit,
+			admins: func(e *testEnv) *boltvm.Response { return e.vc.GetAdmins() },
+		},
+	}
+	defer SetGenesisConfig(nil)
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			SetGenesisConfig(tt.config)
+			e := newTestEnv(t)
+			e.as(e.admin)
+			requireCode(t, tt.init(e), ErrInvalidArgument)
+			requireCode(t, tt.admins(e), ErrNotInitialized)
+		})
+	}
+}
+
+func TestChainDIDManager_NotInitialized(t *testing.T) {
+	e := newTestEnv(t)
+	e.as(e.admin)
+	admin := e.admin.did
+	calls := map[string]func() *boltvm.Response{
+		"SetParent":            func() *boltvm.Response { return e.chain.SetParent(admin, testChainDID) },
+		"AddChild":             func() *boltvm.Response { return e.chain.AddChild(admin, testAppChainDID) },
+		"RemoveChild":          func() *boltvm.Response { return e.chain.RemoveChild(admin, testAppChainDID) },
+		"SetConvertMap":        func() *boltvm.Response { return e.chain.SetConvertMap(admin, testAppChainDID, "app") },
+		"GetConvertMap":        func() *boltvm.Response { return e.chain.GetConvertMap(admin, testAppChainDID) },
+		"Apply":                func() *boltvm.Response { return e.chain.Apply(admin, testAppChainDID, nil) },
+		"AuditApply":           func() *boltvm.Response { return e.chain.AuditApply(admin, testAppChainDID, 1, nil) },
+		"Audit":                func() *boltvm.Response { return e.chain.Audit(admin, testAppChainDID, string(bitxid.Normal), nil) },
+		"Register":             func() *boltvm.Response { return e.chain.Register(admin, testAppChainDID, testDocAddr, nil, nil) },
+		"Update":               func() *boltvm.Response { return e.chain.Update(admin, testAppChainDID, testDocAddr, nil, nil) },
+		"Resolve":              func() *boltvm.Response { return e.chain.Resolve(testAppChainDID) },
+		"Freeze":               func() *boltvm.Response { return e.chain.Freeze(admin, testAppChainDID, nil) },
+		"UnFreeze":             func() *boltvm.Response { return e.chain.UnFreeze(admin, testAppChainDID, nil) },
+		"Delete":               func() *boltvm.Response { return e.chain.Delete(admin, testAppChainDID, nil) },
+		"SelfFreeze":           func() *boltvm.Response { return e.chain.SelfFreeze(admin, testAppChainDID, nil, nil) },
+		"SelfUnFreeze":         func() *boltvm.Response { return e.chain.SelfUnFreeze(admin, testAppChainDID, nil, nil) },
+		"Synchronize":          func() *boltvm.Response { return e.chain.Synchronize(testChainDID, nil) },
+		"HasAdmin":             func() *boltvm.Response { return e.chain.HasAdmin(admin) },
+		"GetAdmins":            func() *boltvm.Response { return e.chain.GetAdmins() },
+		"AddAdmin":             func() *boltvm.Response { return e.chain.AddAdmin(admin, admin) },
+		"RemoveAdmin":          func() *boltvm.Response { return e.chain.RemoveAdmin(admin, admin) },
+		"Propose":              func() *boltvm.Response { return e.chain.Propose(admin, string(ProposalFreeze), testAppChainDID, 0) },
+		"Vote":                 func() *boltvm.Response { return e.chain.Vote(admin, 0, true) },
+		"GetProposal":          func() *boltvm.Response { return e.chain.GetProposal(0) },
+		"ListProposals":        func() *boltvm.Response { return e.chain.ListProposals("", 0, 0) },
+		"ExecuteProposal":      func() *boltvm.Response { return e.chain.ExecuteProposal(admin, 0) },
+		"RejectRecovery":       func() *boltvm.Response { return e.chain.RejectRecovery(admin, 0) },
+		"SetQuorum":            func() *boltvm.Response { return e.chain.SetQuorum(admin, 2, 0) },
+		"GetQuorum":            func() *boltvm.Response { return e.chain.GetQuorum() },
+		"GrantRole":            func() *boltvm.Response { return e.chain.GrantRole(admin, admin, string(RoleAuditor)) },
+		"RevokeRole":           func() *boltvm.Response { return e.chain.RevokeRole(admin, admin, string(RoleAuditor)) },
+		"GetRoles":             func() *boltvm.Response { return e.chain.GetRoles(admin) },
+		"HasRole":              func() *boltvm.Response { return e.chain.HasRole(admin, string(RoleAuditor)) },
+		"TransferSuperAdmin":   func() *boltvm.Response { return e.chain.TransferSuperAdmin(admin, admin) },
+		"AcceptSuperAdmin":     func() *boltvm.Response { return e.chain.AcceptSuperAdmin(admin) },
+		"GetSuperAdmin":        func() *boltvm.Response { return e.chain.GetSuperAdmin() },
+		"SetRecoveryThreshold": func() *boltvm.Response { return e.chain.SetRecoveryThreshold(admin, 2) },
+	}
+	for name, fn := range calls {
+		requireCode(t, fn(), ErrNotInitialized)
+		require.Empty(t, e.chainStub.Events(), name)
+	}
+}
+
+func TestChainDIDManager_Hierarchy(t *testing.T) {
+	runCalls(t, []call{
+		{
+			name: "set parent",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).chain.SetParent(e.admin.did, testOtherChainID)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, bitxid.DID(testOtherChainID), e.chain.getChainDIDRegistry().ParentID)
+				require.Equal(t, EventSetParent, lastEvent(t, e.chainStub).Type)
+			},
+		},
+		{
+			name: "set parent by non admin",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).chain.SetParent(e.user.did, testOtherChainID)
+			},
+			code: ErrNotAdmin,
+		},
+		{
+			name: "set parent with caller mismatch",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).chain.SetParent(e.admin.did, testOtherChainID)
+			},
+			code: ErrCallerMismatch,
+		},
+		{
+			name: "add child",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).chain.AddChild(e.admin.did, testAppChainDID)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, []bitxid.DID{testAppChainDID}, e.chain.getChainDIDRegistry().ChildIDs)
+				require.Equal(t, EventAddChild, lastEvent(t, e.chainStub).Type)
+			},
+		},
+		{
+			name: "add child by non admin",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).chain.AddChild(e.user.did, testAppChainDID)
+			},
+			code: ErrNotAdmin,
+		},
+		{
+			name: "remove child",
+			setup: func(e *testEnv) {
+				requireOK(e.t, e.as(e.admin).chain.AddChild(e.admin.did, testAppChainDID))
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).chain.RemoveChild(e.admin.did, testAppChainDID)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Empty(t, e.chain.getChainDIDRegistry().ChildIDs)
+				require.Equal(t, EventRemoveChild, lastEvent(t, e.chainStub).Type)
+			},
+		},
+		{
+			name: "remove missing child",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).chain.RemoveChild(e.admin.did, testAppChainDID)
+			},
+			code: ErrNotFound,
+		},
+		{
+			name: "remove child by non admin",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).chain.RemoveChild(e.user.did, testAppChainDID)
+			},
+			code: ErrNotAdmin,
+		},
+	})
+}
+
+func TestChainDIDManager_ConvertMap(t *testing.T) {
+	converted := func(t *testing.T, e *testEnv, res *boltvm.Response) {
+		appID := &didpb.String{}
+		decode(t, e.as(e.user).chain.GetConvertMap(e.user.did, testAppChainDID), appID)
+		require.Equal(t, "appchain-2", appID.Value)
+		ev := lastEvent(t, e.chainStub)
+		require.Equal(t, EventSetConvertMap, ev.Type)
+		require.Equal(t, bitxid.DID(testAppChainDID), ev.DID)
+	}
+	runCalls(t, []call{
+		{
+			name: "set by admin",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).chain.SetConvertMap(e.admin.did, testAppChainDID, "appchain-2")
+			},
+			check: converted,
+		},
+		{
+			name:  "set by converter manager",
+			setup: func(e *testEnv) { grantChainRole(e, RoleConverterManager) },
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).chain.SetConvertMap(e.user.did, testAppChainDID, "appchain-2")
+			},
+			check: converted,
+		},
+		{
+			name: "set by others",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).chain.SetConvertMap(e.user.did, testAppChainDID, "appchain-2")
+			},
+			code: ErrNotAdmin,
+		},
+		{
+			name: "get unmapped",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).chain.GetConvertMap(e.user.did, testAppChainDID)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				appID := &didpb.String{}
+				decode(t, res, appID)
+				require.Empty(t, appID.Value)
+			},
+		},
+		{
+			name: "get by others",
+			setup: func(e *testEnv) {
+				requireOK(e.t, e.as(e.admin).chain.SetConvertMap(e.admin.did, testAppChainDID, "appchain-1"))
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).chain.GetConvertMap(e.admin.did, testAppChainDID)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				appID := &didpb.String{}
+				decode(t, res, appID)
+				require.Equal(t, "appchain-1", appID.Value)
+			},
+		},
+	})
+}
+
+func TestChainDIDManager_Apply(t *testing.T) {
+	runCalls(t, []call{
+		{
+			name: "success",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).chain.Apply(e.user.did, testAppChainDID, nil)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				info := resolveChainDID(t, e, testAppChainDID)
+				require.Equal(t, e.user.did, info.Owner)
+				require.Equal(t, string(bitxid.ApplyAudit), info.Status)
+				ev := lastEvent(t, e.chainStub)
+				require.Equal(t, EventApply, ev.Type)
+				require.Equal(t, string(bitxid.ApplyAudit), ev.NewStatus)
+				require.Equal(t, bitxid.DID(e.user.did), ev.Actor)
+				require.Equal(t, uint64(testHeight), ev.Height)
+			},
+		},
+		{
+			name: "invalid format",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).chain.Apply(e.user.did, "appchain002", nil)
+			},
+			code: ErrInvalidFormat,
+		},
+		{
+			name:  "applied twice",
+			setup: applied,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).chain.Apply(e.user.did, testAppChainDID, nil)
+			},
+			code: ErrRegistryRejected,
+		},
+		{
+			name: "caller mismatch",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).chain.Apply(e.user.did, testAppChainDID, nil)
+			},
+			code: ErrCallerMismatch,
+		},
+	})
+}
+
+func TestChainDIDManager_AuditApply(t *testing.T) {
+	runCalls(t, []call{
+		{
+			name:  "approve",
+			setup: applied,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).chain.AuditApply(e.admin.did, testAppChainDID, 1, nil)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, bitxid.ApplySuccess, chainDIDStatus(t, e, testAppChainDID))
+				require.Equal(t, EventAuditApply, lastEvent(t, e.chainStub).Type)
+			},
+		},
+		{
+			name:  "reject",
+			setup: applied,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).chain.AuditApply(e.admin.did, testAppChainDID, 0, nil)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, bitxid.ApplyFailed, chainDIDStatus(t, e, testAppChainDID))
+			},
+		},
+		{
+			name: "by auditor",
+			setup: func(e *testEnv) {
+				applied(e)
+				grantChainRole(e, RoleAuditor)
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).chain.AuditApply(e.user.did, testAppChainDID, 1, nil)
+			},
+		},
+		{
+			name:  "by others",
+			setup: applied,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).chain.AuditApply(e.user.did, testAppChainDID, 1, nil)
+			},
+			code: ErrNotAdmin,
+		},
+		{
+			name: "not applied",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).chain.AuditApply(e.admin.did, testAppChainDID, 1, nil)
+			},
+			code: ErrRegistryRejected,
+		},
+		{
+			name: "under quorum",
+			setup: func(e *testEnv) {
+				applied(e)
+				quorumEnabled(e)
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).chain.AuditApply(e.admin.did, testAppChainDID, 1, nil)
+			},
+			code: ErrQuorumRequired,
+		},
+	})
+}
+
+func TestChainDIDManager_Audit(t *testing.T) {
+	runCalls(t, []call{
+		{
+			name:  "success",
+			setup: registered,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).chain.Audit(e.admin.did, testAppChainDID, string(bitxid.Frozen), nil)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, bitxid.Frozen, chainDIDStatus(t, e, testAppChainDID))
+				ev := lastEvent(t, e.chainStub)
+				require.Equal(t, EventAudit, ev.Type)
+				require.Equal(t, string(bitxid.Normal), ev.OldStatus)
+				require.Equal(t, string(bitxid.Frozen), ev.NewStatus)
+			},
+		},
+		{
+			name:  "by others",
+			setup: registered,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).chain.Audit(e.user.did, testAppChainDID, string(bitxid.Frozen), nil)
+			},
+			code: ErrNotAdmin,
+		},
+		{
+			name: "not existed",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).chain.Audit(e.admin.did, testAppChainDID, string(bitxid.Frozen), nil)
+			},
+			code: ErrRegistryRejected,
+		},
+		{
+			name: "under quorum",
+			setup: func(e *testEnv) {
+				registered(e)
+				quorumEnabled(e)
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).chain.Audit(e.admin.did, testAppChainDID, string(bitxid.Frozen), nil)
+			},
+			code: ErrQuorumRequired,
+		},
+	})
+}
+
+func TestChainDIDManager_Register(t *testing.T) {
+	runCalls(t, []call{
+		{
+			name: "by owner",
+			setup: func(e *testEnv) {
+				audited(e)
+				requireOK(e.t, e.as(e.admin).chain.AddChild(e.admin.did, testOtherChainID))
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).chain.Register(e.user.did, testAppChainDID, testDocAddr, []byte("hash"), nil)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				info := resolveChainDID(t, e, testAppChainDID)
+				require.Equal(t, string(bitxid.Normal), info.Status)
+				require.Equal(t, testDocAddr, info.DocAddr)
+				require.Equal(t, []byte("hash"), info.DocHash)
+				require.Equal(t, EventRegister, lastEvent(t, e.chainStub).Type)
+
+				// other invokes resolve controllers of callers
+				var invokes []stubtest.Invoke
+				for _, inv := range e.chainStub.Invokes() {
+					if inv.Address == constant.InterRelayBrokerContractAddr.String() {
+						invokes = append(invokes, inv)
+					}
+				}
+				require.Len(t, invokes, 1)
+				require.Equal(t, "RecordIBTPs", invokes[0].Method)
+				ibtps := &pb.IBTPs{}
+				require.Nil(t, ibtps.Unmarshal(invokes[0].Args[0].Value))
+				require.Len(t, ibtps.Ibtps, 1)
+				require.Equal(t, testOtherChainID, ibtps.Ibtps[0].To)
+			},
+		},
+		{
+			name: "by registrar",
+			setup: func(e *testEnv) {
+				requireOK(e.t, e.as(e.admin).chain.Apply(e.admin.did, testAppChainDID, nil))
+				requireOK(e.t, e.chain.AuditApply(e.admin.did, testAppChainDID, 1, nil))
+				grantChainRole(e, RoleRegistrar)
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).chain.Register(e.user.did, testAppChainDID, testDocAddr, []byte("hash"), e.admin.sign(e.t, registerMsg(chainDIDRegistryName, testAppChainDID, testDocAddr, []byte("hash"))))
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				info := resolveChainDID(t, e, testAppChainDID)
+				require.Equal(t, e.admin.did, info.Owner)
+				require.Equal(t, string(bitxid.Normal), info.Status)
+			},
+		},
+		{
+			name: "by registrar with owner sig over other doc",
+			setup: func(e *testEnv) {
+				requireOK(e.t, e.as(e.admin).chain.Apply(e.admin.did, testAppChainDID, nil))
+				requireOK(e.t, e.chain.AuditApply(e.admin.did, testAppChainDID, 1, nil))
+				grantChainRole(e, RoleRegistrar)
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				sig := e.admin.sign(e.t, registerMsg(chainDIDRegistryName, testAppChainDID, "other", []byte("hash")))
+				return e.as(e.user).chain.Register(e.user.did, testAppChainDID, testDocAddr, []byte("hash"), sig)
+			},
+			code: ErrSignatureInvalid,
+		},
+		{
+			name: "by registrar without owner sig",
+			setup: func(e *testEnv) {
+				requireOK(e.t, e.as(e.admin).chain.Apply(e.admin.did, testAppChainDID, nil))
+				requireOK(e.t, e.chain.AuditApply(e.admin.did, testAppChainDID, 1, nil))
+				grantChainRole(e, RoleRegistrar)
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).chain.Register(e.user.did, testAppChainDID, testDocAddr, []byte("hash"), e.user.sign(e.t, registerMsg(chainDIDRegistryName, testAppChainDID, testDocAddr, []byte("hash"))))
+			},
+			code: ErrSignatureInvalid,
+		},
+		{
+			name:  "by others",
+			setup: audited,
+			run: func(e *testEnv) *boltvm.Response {
+				other, _ := e.newAccount()
+				return e.as(other).chain.Register(other.did, testAppChainDID, testDocAddr, []byte("hash"), nil)
+			},
+			code: ErrNotOwner,
+		},
+		{
+			name: "not existed",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).chain.Register(e.user.did, testAppChainDID, testDocAddr, []byte("hash"), nil)
+			},
+			code: ErrNotFound,
+		},
+		{
+			name:  "not audited",
+			setup: applied,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).chain.Register(e.user.did, testAppChainDID, testDocAddr, []byte("hash"), nil)
+			},
+			code: ErrRegistryRejected,
+		},
+		{
+			name: "record ibtps failed",
+			setup: func(e *testEnv) {
+				audited(e)
+				e.chainStub.Handle(constant.InterRelayBrokerContractAddr.String(), func(method string, args ...*pb.Arg) *boltvm.Response {
+					return boltvm.Error("broker unavailable")
+				})
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).chain.Register(e.user.did, testAppChainDID, testDocAddr, []byte("hash"), nil)
+			},
+			code: ErrCrossInvoke,
+		},
+	})
+}
+
+func TestChainDIDManager_Update(t *testing.T) {
+	updated := func(t *testing.T, e *testEnv, res *boltvm.Response) {
+		info := resolveChainDID(t, e, testAppChainDID)
+		require.Equal(t, "/ipfs/QmNew", info.DocAddr)
+		require.Equal(t, []byte("new"), info.DocHash)
+		require.Equal(t, EventUpdate, lastEvent(t, e.chainStub).Type)
+	}
+	runCalls(t, []call{
+		{
+			name:  "by owner",
+			setup: registered,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).chain.Update(e.user.did, testAppChainDID, "/ipfs/QmNew", []byte("new"), nil)
+			},
+			check: updated,
+		},
+		{
+			name:  "by admin",
+			setup: registered,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).chain.Update(e.admin.did, testAppChainDID, "/ipfs/QmNew", []byte("new"), nil)
+			},
+			check: updated,
+		},
+		{
+			name: "by others",
+			setup: func(e *testEnv) {
+				registered(e)
+				e.user, e.userDoc = e.newAccount()
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).chain.Update(e.user.did, testAppChainDID, "/ipfs/QmNew", []byte("new"), nil)
+			},
+			code: ErrNotOwner,
+		},
+		{
+			name: "not existed",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).chain.Update(e.admin.did, testAppChainDID, "/ipfs/QmNew", []byte("new"), nil)
+			},
+			code: ErrNotFound,
+		},
+		{
+			name:  "not registered",
+			setup: audited,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).chain.Update(e.user.did, testAppChainDID, "/ipfs/QmNew", []byte("new"), nil)
+			},
+			code: ErrRegistryRejected,
+		},
+	})
+}
+
+func TestChainDIDManager_Resolve(t *testing.T) {
+	runCalls(t, []call{
+		{
+			name:  "existed",
+			setup: registered,
+			run:   func(e *testEnv) *boltvm.Response { return e.chain.Resolve(testAppChainDID) },
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				info := &didpb.ChainDIDInfo{}
+				decode(t, res, info)
+				require.Equal(t, testAppChainDID, info.ChainDid)
+				require.Equal(t, e.user.did, info.Owner)
+			},
+		},
+		{
+			name: "not existed",
+			run:  func(e *testEnv) *boltvm.Response { return e.chain.Resolve(testAppChainDID) },
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				info := &didpb.ChainDIDInfo{}
+				decode(t, res, info)
+				require.Empty(t, info.ChainDid)
+			},
+		},
+	})
+}
+
+func TestChainDIDManager_Freeze(t *testing.T) {
+	runCalls(t, []call{
+		{
+			name:  "freeze",
+			setup: registered,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).chain.Freeze(e.admin.did, testAppChainDID, nil)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, bitxid.Frozen, chainDIDStatus(t, e, testAppChainDID))
+				require.Equal(t, EventFreeze, lastEvent(t, e.chainStub).Type)
+			},
+		},
+		{
+			name: "freeze by freezer",
+			setup: func(e *testEnv) {
+				e.newChainDID(e.admin, testAppChainDID)
+				grantChainRole(e, RoleFreezer)
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).chain.Freeze(e.user.did, testAppChainDID, nil)
+			},
+		},
+		{
+			name:  "freeze by others",
+			setup: registered,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).chain.Freeze(e.user.did, testAppChainDID, nil)
+			},
+			code: ErrNotAdmin,
+		},
+		{
+			name:  "freeze twice",
+			setup: frozen,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).chain.Freeze(e.admin.did, testAppChainDID, nil)
+			},
+			code: ErrAlreadyFrozen,
+		},
+		{
+			name: "freeze missing",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).chain.Freeze(e.admin.did, testAppChainDID, nil)
+			},
+			code: ErrNotFound,
+		},
+		{
+			name: "freeze under quorum",
+			setup: func(e *testEnv) {
+				registered(e)
+				quorumEnabled(e)
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).chain.Freeze(e.admin.did, testAppChainDID, nil)
+			},
+			code: ErrQuorumRequired,
+		},
+		{
+			name:  "unfreeze",
+			setup: frozen,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).chain.UnFreeze(e.admin.did, testAppChainDID, nil)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, bitxid.Normal, chainDIDStatus(t, e, testAppChainDID))
+				require.Equal(t, EventUnFreeze, lastEvent(t, e.chainStub).Type)
+			},
+		},
+		{
+			name:  "unfreeze by others",
+			setup: frozen,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).chain.UnFreeze(e.user.did, testAppChainDID, nil)
+			},
+			code: ErrNotAdmin,
+		},
+		{
+			name:  "unfreeze not frozen",
+			setup: registered,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).chain.UnFreeze(e.admin.did, testAppChainDID, nil)
+			},
+			code: ErrNotFrozen,
+		},
+		{
+			name: "unfreeze under quorum",
+			setup: func(e *testEnv) {
+				frozen(e)
+				quorumEnabled(e)
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).chain.UnFreeze(e.admin.did, testAppChainDID, nil)
+			},
+			code: ErrQuorumRequired,
+		},
+	})
+}
+
+func TestChainDIDManager_Delete(t *testing.T) {
+	runCalls(t, []call{
+		{
+			name:  "by owner",
+			setup: registered,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).chain.Delete(e.user.did, testAppChainDID, nil)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Empty(t, resolveChainDID(t, e, testAppChainDID).ChainDid)
+				ev := lastEvent(t, e.chainStub)
+				require.Equal(t, EventDelete, ev.Type)
+				require.Empty(t, ev.NewStatus)
+			},
+		},
+		{
+			name:  "by admin",
+			setup: registered,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).chain.Delete(e.admin.did, testAppChainDID, nil)
+			},
+			code: ErrNotOwner,
+		},
+		{
+			name: "not existed",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).chain.Delete(e.user.did, testAppChainDID, nil)
+			},
+			code: ErrNotFound,
+		},
+	})
+}
+
+func TestChainDIDManager_Synchronize(t *testing.T) {
+	item := &bitxid.ChainItem{
+		BasicItem: bitxid.BasicItem{ID: testOtherChainID, Status: bitxid.Normal, DocAddr: testDocAddr},
+		Owner:     "did:bitxhub:appchain003:0x01",
+	}
+	itemb, err := bitxid.Marshal(item)
+	require.Nil(t, err)
+
+	runCalls(t, []call{
+		{
+			name: "success",
+			run:  func(e *testEnv) *boltvm.Response { return e.chain.Synchronize(testChainDID, itemb) },
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				info := resolveChainDID(t, e, testOtherChainID)
+				require.Equal(t, string(item.Owner), info.Owner)
+				require.Equal(t, string(bitxid.Normal), info.Status)
+			},
+		},
+		{
+			name: "invalid item",
+			run:  func(e *testEnv) *boltvm.Response { return e.chain.Synchronize(testChainDID, []byte("item")) },
+			code: ErrInvalidFormat,
+		},
+		{
+			name: "existed",
+			setup: func(e *testEnv) {
+				requireOK(e.t, e.chain.Synchronize(testChainDID, itemb))
+			},
+			run:  func(e *testEnv) *boltvm.Response { return e.chain.Synchronize(testChainDID, itemb) },
+			code: ErrRegistryRejected,
+		},
+	})
+}
+
+func TestChainDIDManager_Admins(t *testing.T) {
+	admins := func(e *testEnv) []string {
+		res := &didpb.StringSlice{}
+		decode(t, e.chain.GetAdmins(), res)
+		return res.Slice
+	}
+	runCalls(t, []call{
+		{
+			name: "has admin",
+			run:  func(e *testEnv) *boltvm.Response { return e.chain.HasAdmin(e.admin.did) },
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				has := &didpb.Bool{}
+				decode(t, res, has)
+				require.True(t, has.Value)
+				has = &didpb.Bool{}
+				decode(t, e.chain.HasAdmin(e.user.did), has)
+				require.False(t, has.Value)
+			},
+		},
+		{
+			name: "add admin",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).chain.AddAdmin(e.admin.did, e.user.did)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, []string{e.admin.did, e.user.did}, admins(e))
+				ev := lastAdminEvent(t, e.chainStub)
+				require.Equal(t, AdminAdded, ev.Action)
+				require.Equal(t, bitxid.DID(e.user.did), ev.Admin)
+			},
+		},
+		{
+			name: "add admin by regular admin",
+			setup: func(e *testEnv) {
+				e.user = e.newAdmin()
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				other, _ := e.newAccount()
+				return e.as(e.user).chain.AddAdmin(e.user.did, other.did)
+			},
+			code: ErrNotSuperAdmin,
+		},
+		{
+			name: "add existing admin",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).chain.AddAdmin(e.admin.did, e.admin.did)
+			},
+			code: ErrAlreadyExists,
+		},
+		{
+			name:  "add admin under quorum",
+			setup: quorumEnabled,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).chain.AddAdmin(e.admin.did, e.user.did)
+			},
+			code: ErrQuorumRequired,
+		},
+		{
+			name: "remove admin",
+			setup: func(e *testEnv) {
+				requireOK(e.t, e.as(e.admin).chain.AddAdmin(e.admin.did, e.user.did))
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).chain.RemoveAdmin(e.admin.did, e.user.did)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, []string{e.admin.did}, admins(e))
+				require.Equal(t, AdminRemoved, lastAdminEvent(t, e.chainStub).Action)
+			},
+		},
+		{
+			name: "remove super admin",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).chain.RemoveAdmin(e.admin.did, e.admin.did)
+			},
+			code: ErrInvalidArgument,
+		},
+		{
+			name: "remove non admin",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).chain.RemoveAdmin(e.admin.did, e.user.did)
+			},
+			code: ErrNotFound,
+		},
+		{
+			name: "remove admin by others",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).chain.RemoveAdmin(e.user.did, e.admin.did)
+			},
+			code: ErrNotSuperAdmin,
+		},
+	})
+}
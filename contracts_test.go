@@ -0,0 +1,303 @@
+package contracts
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/meshplus/bitxhub-core/boltvm"
+	"github.com/meshplus/bitxhub-kit/crypto"
+	"github.com/meshplus/bitxhub-kit/crypto/asym"
+	"github.com/meshplus/bitxhub-model/constant"
+	"github.com/meshplus/bitxhub-model/pb"
+	"github.com/meshplus/bitxid"
+	"github.com/meshplus/did-registry/didpb"
+	"github.com/meshplus/did-registry/stubtest"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testChainDID = "did:bitxhub:appchain001:."
+	testDocAddr  = "/ipfs/QmDoc"
+	testHeight   = 10
+)
+
+// testTime is the block time of tests in seconds.
+var testTime = time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC).Unix()
+
+// testAccount is a key pair with its account did under testChainDID.
+type testAccount struct {
+	key  crypto.PrivateKey
+	addr string
+	did  string
+}
+
+// sign signs sha256 of msg, as verifyAddrSig and verifyDocSig expect.
+func (a *testAccount) sign(t *testing.T, msg []byte) []byte {
+	digest := sha256.Sum256(msg)
+	sig, err := a.key.Sign(digest[:])
+	require.Nil(t, err)
+	return sig
+}
+
+// doc returns account doc listing the key of the account,
+// with its content and hash to be anchored.
+func (a *testAccount) doc(t *testing.T) ([]byte, []byte) {
+	pub, err := a.key.PublicKey().Bytes()
+	require.Nil(t, err)
+	doc := bitxid.AccountDoc{BasicDoc: bitxid.BasicDoc{
+		ID:   bitxid.DID(a.did),
+		Type: int(bitxid.AccountDIDType),
+		PublicKey: []bitxid.PubKey{{
+			ID:           a.did + "#key-1",
+			Type:         "Secp256k1",
+			PublicKeyPem: hex.EncodeToString(pub),
+		}},
+	}}
+	docb, err := bitxid.MarshalAccountDoc(doc)
+	require.Nil(t, err)
+	hash := sha256.Sum256(docb)
+	return docb, hash[:]
+}
+
+func newTestAccount(t *testing.T) *testAccount {
+	key, err := asym.GenerateKeyPair(crypto.Secp256k1)
+	require.Nil(t, err)
+	addr, err := key.PublicKey().Address()
+	require.Nil(t, err)
+	return &testAccount{
+		key:  key,
+		addr: addr.String(),
+		did:  "did:bitxhub:appchain001:" + addr.String(),
+	}
+}
+
+// testEnv holds the three registries deployed on in-memory stubs,
+// the vc registry resolves dids through the other two,
+// the chain registry resolves controllers of callers through the account one.
+type testEnv struct {
+	t *testing.T
+
+	chainStub   *stubtest.Stub
+	accountStub *stubtest.Stub
+	vcStub      *stubtest.Stub
+
+	chain   *ChainDIDManager
+	account *AccountDIDManager
+	vc      *VCManager
+
+	admin   *testAccount
+	user    *testAccount // registered account without any permission
+	userDoc []byte       // doc content of user
+}
+
+// newTestEnv deploys the registries with admin as the genesis admin,
+// registries are not initialized yet.
+func newTestEnv(t *testing.T) *testEnv {
+	e := &testEnv{
+		t:           t,
+		chainStub:   stubtest.New(constant.MethodRegistryContractAddr.String()),
+		accountStub: stubtest.New(constant.DIDRegistryContractAddr.String()),
+		vcStub:      stubtest.New(constant.VCRegistryContractAddr.String()),
+		admin:       newTestAccount(t),
+	}
+	e.chain = &ChainDIDManager{Stub: e.chainStub}
+	e.account = &AccountDIDManager{Stub: e.accountStub}
+	e.vc = &VCManager{Stub: e.vcStub}
+
+	e.chainStub.SetObject(adminMethodKey, e.admin.addr)
+	e.accountStub.SetObject(adminDIDKey, e.admin.addr)
+	e.vcStub.SetObject(adminVCKey, e.admin.addr)
+	e.chainStub.Handle(constant.InterRelayBrokerContractAddr.String(), func(method string, args ...*pb.Arg) *boltvm.Response {
+		return boltvm.Success(nil)
+	})
+	e.chainStub.Link(constant.DIDRegistryContractAddr.String(), e.account, e.accountStub)
+	e.vcStub.Link(constant.MethodRegistryContractAddr.String(), e.chain, e.chainStub)
+	e.vcStub.Link(constant.DIDRegistryContractAddr.String(), e.account, e.accountStub)
+	e.setBlock(testHeight, testTime)
+	return e
+}
+
+// newInitedEnv deploys and initializes the registries,
+// the account registry belongs to testChainDID, user is registered.
+func newInitedEnv(t *testing.T) *testEnv {
+	e := newTestEnv(t)
+	e.as(e.admin)
+	requireOK(t, e.chain.Init(e.admin.did))
+	requireOK(t, e.account.Init(e.admin.did))
+	requireOK(t, e.vc.Init(e.admin.did))
+	requireOK(t, e.account.SetChainDID(e.admin.did, testChainDID))
+	e.user, e.userDoc = e.newAccount()
+	e.clearRecords()
+	return e
+}
+
+// as makes following calls sent by acc.
+func (e *testEnv) as(acc *testAccount) *testEnv {
+	e.chainStub.SetCaller(acc.addr)
+	e.accountStub.SetCaller(acc.addr)
+	e.vcStub.SetCaller(acc.addr)
+	return e
+}
+
+// asUser makes following calls sent by the address controlling user,
+// which is rebound by key rotation and recovery.
+func (e *testEnv) asUser() *testEnv {
+	info := &didpb.DIDInfo{}
+	decode(e.t, e.account.Resolve(e.user.did), info)
+	return e.as(&testAccount{addr: info.Controller, did: e.user.did})
+}
+
+// setBlock sets block height and block time in seconds of following calls.
+func (e *testEnv) setBlock(height uint64, seconds int64) {
+	for _, s := range []*stubtest.Stub{e.chainStub, e.accountStub, e.vcStub} {
+		s.SetBlock(height, seconds*int64(time.Second))
+	}
+}
+
+func (e *testEnv) clearRecords() {
+	e.chainStub.ClearRecords()
+	e.accountStub.ClearRecords()
+	e.vcStub.ClearRecords()
+}
+
+// newAccount creates an account with its did registered,
+// returns the account and content of its doc.
+func (e *testEnv) newAccount() (*testAccount, []byte) {
+	acc := newTestAccount(e.t)
+	docb, hash := acc.doc(e.t)
+	e.as(acc)
+	requireOK(e.t, e.account.Register(acc.did, testDocAddr, hash, nil))
+	return acc, docb
+}
+
+// newAdmin creates a registered account and adds it as admin of all registries.
+func (e *testEnv) newAdmin() *testAccount {
+	acc, _ := e.newAccount()
+	e.as(e.admin)
+	requireOK(e.t, e.chain.AddAdmin(e.admin.did, acc.did))
+	requireOK(e.t, e.account.AddAdmin(e.admin.did, acc.did))
+	requireOK(e.t, e.vc.AddAdmin(e.admin.did, acc.did))
+	return acc
+}
+
+// newChainDID applies, approves and registers chainDID owned by owner.
+func (e *testEnv) newChainDID(owner *testAccount, chainDID string) {
+	e.as(owner)
+	requireOK(e.t, e.chain.Apply(owner.did, chainDID, nil))
+	e.as(e.admin)
+	requireOK(e.t, e.chain.AuditApply(e.admin.did, chainDID, 1, nil))
+	e.as(owner)
+	requireOK(e.t, e.chain.Register(owner.did, chainDID, testDocAddr, []byte("hash"), nil))
+}
+
+// newClaimTyp creates a claim type owned by owner.
+func (e *testEnv) newClaimTyp(owner *testAccount, ctid string, fields ...string) {
+	ct := &bitxid.ClaimTyp{ID: ctid}
+	for _, f := range fields {
+		ct.Content = append(ct.Content, &bitxid.FieldTyp{Field: f, Typ: "string"})
+	}
+	ctb, err := ct.Marshal()
+	require.Nil(e.t, err)
+	e.as(owner)
+	requireOK(e.t, e.vc.CreateClaimTyp(owner.did, ctb))
+}
+
+// credential returns a credential of ctid issued by issuer at block time
+// with the claim, signed by issuer.
+func (e *testEnv) credential(issuer *testAccount, cid, ctid, claim string) *bitxid.Credential {
+	c := &bitxid.Credential{
+		ID:     cid,
+		Typ:    ctid,
+		Issuer: bitxid.DID(issuer.did),
+		Issued: uint64(testTime),
+		Claim:  claim,
+	}
+	e.signCredential(issuer, c)
+	return c
+}
+
+func (e *testEnv) signCredential(issuer *testAccount, c *bitxid.Credential) {
+	msg, err := credentialDigest(c)
+	require.Nil(e.t, err)
+	c.Signature = bitxid.Sig{
+		Typ:     "Secp256k1",
+		Content: base64.StdEncoding.EncodeToString(issuer.sign(e.t, msg)),
+	}
+}
+
+// storeVC stores the credential issued by issuer with issuer doc docb.
+func (e *testEnv) storeVC(issuer *testAccount, docb []byte, c *bitxid.Credential) *boltvm.Response {
+	cb, err := c.Marshal()
+	require.Nil(e.t, err)
+	e.as(issuer)
+	return e.vc.StoreVC(issuer.did, cb, docb)
+}
+
+// requireOK asserts res succeeded.
+func requireOK(t *testing.T, res *boltvm.Response) {
+	t.Helper()
+	require.True(t, res.Ok, string(res.Result))
+}
+
+// requireCode asserts res failed with code, or succeeded if code is empty.
+func requireCode(t *testing.T, res *boltvm.Response, code ErrorCode) {
+	t.Helper()
+	if code == "" {
+		requireOK(t, res)
+		return
+	}
+	require.False(t, res.Ok, "want %s", code)
+	require.Equal(t, code, ParseError(res.Result).Code, string(res.Result))
+}
+
+// call is a case of table driven tests, each case runs on a new env.
+// @setup: prepares the env before the call
+// @run: calls the method under test
+// @code: expected error code, empty for success
+// @check: checks the env after the call
+type call struct {
+	name  string
+	setup func(e *testEnv)
+	run   func(e *testEnv) *boltvm.Response
+	code  ErrorCode
+	check func(t *testing.T, e *testEnv, res *boltvm.Response)
+}
+
+func runCalls(t *testing.T, calls []call) {
+	for _, c := range calls {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			e := newInitedEnv(t)
+			if c.setup != nil {
+				c.setup(e)
+			}
+			e.clearRecords()
+			res := c.run(e)
+			requireCode(t, res, c.code)
+			if c.check != nil {
+				c.check(t, e, res)
+			}
+		})
+	}
+}
+
+// lastEvent gets the last registry event posted on stub.
+func lastEvent(t *testing.T, stub *stubtest.Stub) *RegistryEvent {
+	events := stub.Events()
+	require.NotEmpty(t, events)
+	ev, ok := events[len(events)-1].(*RegistryEvent)
+	require.True(t, ok)
+	return ev
+}
+
+// lastAdminEvent gets the last admin event posted on stub.
+func lastAdminEvent(t *testing.T, stub *stubtest.Stub) *AdminEvent {
+	events := stub.Events()
+	require.NotEmpty(t, events)
+	ev, ok := events[len(events)-1].(*AdminEvent)
+	require.True(t, ok)
+	return ev
+}
@@ -0,0 +1,95 @@
+package contracts
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/meshplus/bitxhub-core/boltvm"
+)
+
+// ErrorCode identifies why a call to a registry failed,
+// clients should match on it instead of the message.
+type ErrorCode string
+
+// codes of failed responses
+const (
+	ErrNotInitialized     ErrorCode = "NotInitialized"     // registry is not initialized yet
+	ErrAlreadyInitialized ErrorCode = "AlreadyInitialized" // registry is already initialized
+	ErrCallerMismatch     ErrorCode = "CallerMismatch"     // caller did doesn't belong to tx.From
+	ErrNotAdmin           ErrorCode = "NotAdmin"           // caller is not admin, nor has the needed role
+	ErrNotSuperAdmin      ErrorCode = "NotSuperAdmin"      // caller is not super admin
+	ErrNotOwner           ErrorCode = "NotOwner"           // caller doesn't own the did, credential, claim type or status list
+	ErrNotFound           ErrorCode = "NotFound"           // did, credential, claim type, proposal or status list doesn't exist
+	ErrAlreadyExists      ErrorCode = "AlreadyExists"      // target already exists or is already in the set
+	ErrAlreadyFrozen      ErrorCode = "AlreadyFrozen"      // did is already frozen
+	ErrNotFrozen          ErrorCode = "NotFrozen"          // did is not frozen
+	ErrInvalidStatus      ErrorCode = "InvalidStatus"      // target is under a status not allowing the call
+	ErrInvalidFormat      ErrorCode = "InvalidFormat"      // malformed did, doc, signature or params
+	ErrInvalidArgument    ErrorCode = "InvalidArgument"    // well formed params with values not allowed
+	ErrSignatureInvalid   ErrorCode = "SignatureInvalid"   // signature doesn't verify
+	ErrNotOnThisChain     ErrorCode = "NotOnThisChain"     // did belongs to another chain
+	ErrQuorumRequired     ErrorCode = "QuorumRequired"     // action must be approved by a proposal
+	ErrExpired            ErrorCode = "Expired"            // credential or proposal has expired
+	ErrDeprecated         ErrorCode = "Deprecated"         // claim type is deprecated
+	ErrUntrustedIssuer    ErrorCode = "UntrustedIssuer"    // issuer is not trusted by the claim type
+	ErrCrossInvoke        ErrorCode = "CrossInvokeFailed"  // call to another contract failed
+	ErrNoBlockContext     ErrorCode = "NoBlockContext"     // stub provides no block time the call depends on
+	ErrRegistryRejected   ErrorCode = "RegistryRejected"   // underlying bitxid registry rejected the call
+	ErrInternal           ErrorCode = "Internal"           // unexpected failure, e.g. marshal err
+)
+
+// RegistryError is the payload of a failed response,
+// result of the response is the json encoding of it.
+type RegistryError struct {
+	Code    ErrorCode `json:"code"`
+	Message string    `json:"message"`
+}
+
+func (e *RegistryError) Error() string {
+	return e.Message
+}
+
+// newError creates a registry error, internal helpers return it
+// so that the code is kept when managers wrap it.
+func newError(code ErrorCode, format string, args ...interface{}) *RegistryError {
+	return &RegistryError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// errorCode gets code of err, or code if err is not a registry error.
+func errorCode(err error, code ErrorCode) ErrorCode {
+	var re *RegistryError
+	if errors.As(err, &re) {
+		return re.Code
+	}
+	return code
+}
+
+// errorResponse returns a failed response with the coded payload.
+func errorResponse(code ErrorCode, msg string) *boltvm.Response {
+	b, err := json.Marshal(&RegistryError{Code: code, Message: msg})
+	if err != nil {
+		return boltvm.Error(msg)
+	}
+	return boltvm.Error(string(b))
+}
+
+// wrapErrorResponse returns a failed response with msg followed by err,
+// code of err is kept if it's a registry error, otherwise code is used.
+func wrapErrorResponse(code ErrorCode, msg string, err error) *boltvm.Response {
+	return errorResponse(errorCode(err, code), msg+err.Error())
+}
+
+// ParseError decodes the payload of a failed response,
+// results not produced by the registries get ErrInternal.
+func ParseError(result []byte) *RegistryError {
+	re := &RegistryError{}
+	if err := json.Unmarshal(result, re); err != nil || re.Code == "" {
+		return &RegistryError{Code: ErrInternal, Message: string(result)}
+	}
+	return re
+}
+
+func notInitializedResponse() *boltvm.Response {
+	return errorResponse(ErrNotInitialized, "Registry not initialized")
+}
@@ -0,0 +1,65 @@
+package contracts
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/meshplus/bitxhub-core/boltvm"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorResponse(t *testing.T) {
+	tests := []struct {
+		name string
+		res  *boltvm.Response
+		code ErrorCode
+		msg  string
+	}{
+		{
+			name: "coded",
+			res:  errorResponse(ErrNotOwner, "not owner"),
+			code: ErrNotOwner,
+			msg:  "not owner",
+		},
+		{
+			name: "wrap registry error",
+			res:  wrapErrorResponse(ErrInternal, "get err, ", newError(ErrNotFound, "vc %s not existed", testCID)),
+			code: ErrNotFound,
+			msg:  "get err, vc " + testCID + " not existed",
+		},
+		{
+			name: "wrap wrapped registry error",
+			res:  wrapErrorResponse(ErrInternal, "", fmt.Errorf("resolve: %w", newError(ErrNotFound, "not existed"))),
+			code: ErrNotFound,
+			msg:  "resolve: not existed",
+		},
+		{
+			name: "wrap other error",
+			res:  wrapErrorResponse(ErrInvalidFormat, "params unmarshal err: ", errors.New("eof")),
+			code: ErrInvalidFormat,
+			msg:  "params unmarshal err: eof",
+		},
+		{
+			name: "not coded",
+			res:  boltvm.Error("plain"),
+			code: ErrInternal,
+			msg:  "plain",
+		},
+		{
+			name: "json without code",
+			res:  boltvm.Error(`{"message":"m"}`),
+			code: ErrInternal,
+			msg:  `{"message":"m"}`,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			require.False(t, tt.res.Ok)
+			re := ParseError(tt.res.Result)
+			require.Equal(t, tt.code, re.Code)
+			require.Equal(t, tt.msg, re.Message)
+		})
+	}
+}
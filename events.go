@@ -0,0 +1,144 @@
+package contracts
+
+import (
+	"github.com/meshplus/bitxhub-core/boltvm"
+	"github.com/meshplus/bitxid"
+)
+
+// vcRegistryName is name of the vc registry used in events.
+const vcRegistryName = "vc"
+
+// EventType is type of RegistryEvent.
+type EventType string
+
+// types of registry events
+const (
+	EventApply           EventType = "Apply"
+	EventAuditApply      EventType = "AuditApply"
+	EventAudit           EventType = "Audit"
+	EventRegister        EventType = "Register"
+	EventUpdate          EventType = "Update"
+	EventFreeze          EventType = "Freeze"
+	EventUnFreeze        EventType = "UnFreeze"
+	EventSelfFreeze      EventType = "SelfFreeze"
+	EventSelfUnFreeze    EventType = "SelfUnFreeze"
+	EventDelete          EventType = "Delete"
+	EventDeactivate      EventType = "Deactivate"
+	EventRotateKey       EventType = "RotateKey"
+	EventAddGuardian     EventType = "AddGuardian"
+	EventRemoveGuardian  EventType = "RemoveGuardian"
+	EventApproveRecovery EventType = "ApproveRecovery"
+	EventCancelRecovery  EventType = "CancelRecovery"
+	EventRecover         EventType = "Recover"
+	EventSetParent       EventType = "SetParent"
+	EventAddChild        EventType = "AddChild"
+	EventRemoveChild     EventType = "RemoveChild"
+	EventSetChainDID     EventType = "SetChainDID"
+	EventSetConvertMap   EventType = "SetConvertMap"
+	EventSetQuorum       EventType = "SetQuorum"
+	EventSetRecoveryRule EventType = "SetRecoveryRule"
+
+	EventCreateClaimTyp    EventType = "CreateClaimTyp"
+	EventDeprecateClaimTyp EventType = "DeprecateClaimTyp"
+	EventStoreVC           EventType = "StoreVC"
+	EventDeleteVC          EventType = "DeleteVC"
+	EventRevokeVC          EventType = "RevokeVC"
+	EventSuspendVC         EventType = "SuspendVC"
+	EventUnsuspendVC       EventType = "UnsuspendVC"
+	EventCreateStatusList  EventType = "CreateStatusList"
+	EventUpdateStatusList  EventType = "UpdateStatusList"
+)
+
+// RegistryEvent is posted through the boltvm event mechanism
+// on every state change of did and vc registries,
+// changes of admins and roles are posted as AdminEvent.
+// @Registry: chain-did, account-did or vc
+// @DID: did whose state changed, parent or child for hierarchy events, issuer for vc events
+// @ID: id of the claim type, vc or status list, only set by the vc registry
+// @OldStatus: status before the change, empty if not existed or not applicable
+// @NewStatus: status after the change, empty if deleted or not applicable
+// @Actor: caller of the change, or proposer if approved by quorum
+// @Reason: reason given by admin for moderation actions, e.g. Delete
+// @Height: height of the block executing the change
+type RegistryEvent struct {
+	Registry  string
+	Type      EventType
+	DID       bitxid.DID
+	ID        string
+	OldStatus string
+	NewStatus string
+	Actor     bitxid.DID
+	Reason    string
+	Height    uint64
+}
+
+func postDIDEvent(stub boltvm.Stub, registry string, typ EventType, did bitxid.DID, oldStatus, newStatus bitxid.StatusType, actor bitxid.DID) {
+	postModerationEvent(stub, registry, typ, did, oldStatus, newStatus, actor, "")
+}
+
+func postModerationEvent(stub boltvm.Stub, registry string, typ EventType, did bitxid.DID, oldStatus, newStatus bitxid.StatusType, actor bitxid.DID, reason string) {
+	stub.PostEvent(&RegistryEvent{
+		Registry:  registry,
+		Type:      typ,
+		DID:       did,
+		OldStatus: string(oldStatus),
+		NewStatus: string(newStatus),
+		Actor:     actor,
+		Reason:    reason,
+		Height:    recordHeight(stub),
+	})
+}
+
+func postVCEvent(stub boltvm.Stub, typ EventType, id string, issuer bitxid.DID, oldStatus, newStatus VCStatusType, actor bitxid.DID) {
+	stub.PostEvent(&RegistryEvent{
+		Registry:  vcRegistryName,
+		Type:      typ,
+		DID:       issuer,
+		ID:        id,
+		OldStatus: string(oldStatus),
+		NewStatus: string(newStatus),
+		Actor:     actor,
+		Height:    recordHeight(stub),
+	})
+}
+
+// statusOf gets status of the chain did, empty if not existed.
+func (mr *ChainDIDRegistry) statusOf(did bitxid.DID) bitxid.StatusType {
+	item, _, exist, err := mr.Registry.Resolve(did)
+	if err != nil || !exist {
+		return ""
+	}
+	return item.Status
+}
+
+// statusOf gets status of the account did, empty if not existed.
+func (dr *AccountDIDRegistry) statusOf(did bitxid.DID) bitxid.StatusType {
+	item, _, _, err := dr.Registry.Resolve(did)
+	if err != nil || item == nil {
+		return ""
+	}
+	return item.Status
+}
+
+// proposalEventType gets type of the event posted when the proposal is executed,
+// returns false if the action is not a did status change.
+func proposalEventType(action ProposalAction) (EventType, bool) {
+	switch action {
+	case ProposalAuditApply:
+		return EventAuditApply, true
+	case ProposalFreeze:
+		return EventFreeze, true
+	case ProposalUnFreeze:
+		return EventUnFreeze, true
+	}
+	return "", false
+}
+
+// postProposalDIDEvent posts did event of an executed proposal which changes did status.
+func postProposalDIDEvent(stub boltvm.Stub, r quorumRegistry, p *Proposal, oldStatus bitxid.StatusType) {
+	typ, ok := proposalEventType(p.Action)
+	if !ok || p.Status != ProposalExecuted {
+		return
+	}
+	postDIDEvent(stub, r.name(), typ, p.Target, oldStatus, r.statusOf(p.Target), p.Proposer)
+}
@@ -0,0 +1,92 @@
+package contracts
+
+import (
+	"testing"
+
+	"github.com/meshplus/bitxhub-core/boltvm"
+	"github.com/meshplus/bitxid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryEvents(t *testing.T) {
+	runCalls(t, []call{
+		{
+			name: "chain did apply",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).chain.Apply(e.user.did, testAppChainDID, nil)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				ev := lastEvent(t, e.chainStub)
+				require.Equal(t, chainDIDRegistryName, ev.Registry)
+				require.Equal(t, EventApply, ev.Type)
+				require.Equal(t, bitxid.DID(testAppChainDID), ev.DID)
+				require.Empty(t, ev.OldStatus)
+				require.Equal(t, string(chainDIDStatus(t, e, testAppChainDID)), ev.NewStatus)
+				require.Equal(t, bitxid.DID(e.user.did), ev.Actor)
+				require.Equal(t, uint64(testHeight), ev.Height)
+			},
+		},
+		{
+			name: "account did freeze",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.Freeze(e.admin.did, e.user.did, nil)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				ev := lastEvent(t, e.accountStub)
+				require.Equal(t, accountDIDRegistryName, ev.Registry)
+				require.Equal(t, EventFreeze, ev.Type)
+				require.Equal(t, bitxid.DID(e.user.did), ev.DID)
+				require.Equal(t, string(bitxid.Normal), ev.OldStatus)
+				require.Equal(t, string(bitxid.Frozen), ev.NewStatus)
+				require.Equal(t, bitxid.DID(e.admin.did), ev.Actor)
+				require.Equal(t, uint64(testHeight), ev.Height)
+			},
+		},
+		{
+			name:  "vc revoke",
+			setup: issued,
+			run: func(e *testEnv) *boltvm.Response {
+				e.setBlock(testHeight+1, testTime+1)
+				return e.as(e.user).vc.RevokeVC(e.user.did, testCID, "", e.user.sign(e.t, []byte(testCID)))
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				ev := lastEvent(t, e.vcStub)
+				require.Equal(t, vcRegistryName, ev.Registry)
+				require.Equal(t, testCID, ev.ID)
+				require.Equal(t, bitxid.DID(e.user.did), ev.DID)
+				require.Equal(t, uint64(testHeight+1), ev.Height)
+			},
+		},
+		{
+			name: "failed chain did change",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).chain.Freeze(e.user.did, testChainDID, nil)
+			},
+			code: ErrNotAdmin,
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Empty(t, e.chainStub.Events())
+			},
+		},
+		{
+			name: "failed account did change",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).account.Freeze(e.user.did, e.admin.did, nil)
+			},
+			code: ErrNotAdmin,
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Empty(t, e.accountStub.Events())
+			},
+		},
+		{
+			name:  "failed vc change",
+			setup: issued,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).vc.RevokeVC(e.user.did, testCID, "", nil)
+			},
+			code: ErrSignatureInvalid,
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Empty(t, e.vcStub.Events())
+			},
+		},
+	})
+}
@@ -0,0 +1,229 @@
+package contracts
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/meshplus/bitxid"
+	"github.com/pelletier/go-toml"
+)
+
+// GenesisConfig represents content of did.toml.
+type GenesisConfig struct {
+	ChainDID   ChainDIDGenesis   `toml:"chain_did"`
+	AccountDID AccountDIDGenesis `toml:"account_did"`
+	VC         VCGenesis         `toml:"vc"`
+}
+
+// ChainDIDGenesis represents genesis of the chain did registry.
+// @Admins: admins besides the caller of Init
+// @Parent: chain did of the parent registry, did:bitxhub:relayroot:. if empty
+// @IDConverter: chain did to appchain id mappings
+type ChainDIDGenesis struct {
+	Admins      []string         `toml:"admins"`
+	Parent      string           `toml:"parent"`
+	Children    []string         `toml:"children"`
+	IDConverter []IDConverterMap `toml:"id_converter"`
+}
+
+// IDConverterMap maps a chain did to an appchain id.
+type IDConverterMap struct {
+	ChainDID string `toml:"chain_did"`
+	AppID    string `toml:"app_id"`
+}
+
+// AccountDIDGenesis represents genesis of the account did registry.
+type AccountDIDGenesis struct {
+	Admins   []string `toml:"admins"`
+	Children []string `toml:"children"`
+}
+
+// VCGenesis represents genesis of the vc registry,
+// claim types are owned by the caller of Init.
+type VCGenesis struct {
+	Admins     []string          `toml:"admins"`
+	ClaimTypes []GenesisClaimTyp `toml:"claim_types"`
+}
+
+// GenesisClaimTyp represents a claim type in did.toml.
+type GenesisClaimTyp struct {
+	ID     string            `toml:"id"`
+	Fields []GenesisFieldTyp `toml:"fields"`
+}
+
+// GenesisFieldTyp represents a field of claim type in did.toml.
+type GenesisFieldTyp struct {
+	Field string `toml:"field"`
+	Typ   string `toml:"typ"`
+}
+
+// genesisConfigName is the file name of genesis config under the repo root of the node.
+const genesisConfigName = "did.toml"
+
+var (
+	genesisConfig     *GenesisConfig
+	genesisConfigLock sync.RWMutex
+)
+
+// LoadGenesisConfig loads did.toml under the repo root of the node,
+// returns nil if there is no such file.
+func LoadGenesisConfig() (*GenesisConfig, error) {
+	root, err := pathRoot()
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(filepath.Join(root, genesisConfigName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", genesisConfigName, err)
+	}
+	return parseGenesisConfig(data)
+}
+
+// SetGenesisConfig sets genesis config which Init seeds registries from,
+// should be called by the node on start, e.g. with LoadGenesisConfig.
+// Every node must set the same config, otherwise they seed different
+// state from the same Init transaction.
+func SetGenesisConfig(c *GenesisConfig) {
+	genesisConfigLock.Lock()
+	defer genesisConfigLock.Unlock()
+	genesisConfig = c
+}
+
+// getGenesisConfig gets genesis config set by the node, nil if not set.
+func getGenesisConfig() *GenesisConfig {
+	genesisConfigLock.RLock()
+	defer genesisConfigLock.RUnlock()
+	return genesisConfig
+}
+
+// parseGenesisConfig parses did.toml content,
+// returns nil if data is empty.
+func parseGenesisConfig(data []byte) (*GenesisConfig, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	config := &GenesisConfig{}
+	if err := toml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("unmarshal %s: %w", genesisConfigName, err)
+	}
+	return config, nil
+}
+
+// genesisAdmins returns caller followed by admins in config,
+// so that caller stays the super admin.
+func genesisAdmins(caller bitxid.DID, admins []string) ([]bitxid.DID, error) {
+	res := []bitxid.DID{caller}
+	for _, admin := range admins {
+		did := bitxid.DID(admin)
+		if !did.IsValidFormat() || did.GetType() != int(bitxid.AccountDIDType) {
+			return nil, fmt.Errorf("genesis admin %s is not a valid account did", admin)
+		}
+		if did != caller {
+			res = append(res, did)
+		}
+	}
+	return res, nil
+}
+
+// genesisChainDIDs checks and converts chain dids in config.
+func genesisChainDIDs(dids []string) ([]bitxid.DID, error) {
+	var res []bitxid.DID
+	for _, d := range dids {
+		did := bitxid.DID(d)
+		if !did.IsValidFormat() || did.GetType() != int(bitxid.ChainDIDType) {
+			return nil, fmt.Errorf("genesis %s is not a valid chain did", d)
+		}
+		res = append(res, did)
+	}
+	return res, nil
+}
+
+// seed applies the chain did genesis to the registry.
+func (g *ChainDIDGenesis) seed(mr *ChainDIDRegistry, caller bitxid.DID) error {
+	admins, err := genesisAdmins(caller, g.Admins)
+	if err != nil {
+		return err
+	}
+	if g.Parent != "" {
+		parents, err := genesisChainDIDs([]string{g.Parent})
+		if err != nil {
+			return err
+		}
+		mr.ParentID = parents[0]
+	}
+	children, err := genesisChainDIDs(g.Children)
+	if err != nil {
+		return err
+	}
+	for _, m := range g.IDConverter {
+		if _, err := genesisChainDIDs([]string{m.ChainDID}); err != nil {
+			return err
+		}
+		mr.setConvertMap(m.ChainDID, m.AppID)
+	}
+	mr.Registry.Admins = admins
+	mr.ChildIDs = children
+	return nil
+}
+
+// seed applies the account did genesis to the registry.
+func (g *AccountDIDGenesis) seed(dr *AccountDIDRegistry, caller bitxid.DID) error {
+	admins, err := genesisAdmins(caller, g.Admins)
+	if err != nil {
+		return err
+	}
+	children, err := genesisChainDIDs(g.Children)
+	if err != nil {
+		return err
+	}
+	dr.Registry.Admins = admins
+	dr.ChildIDs = children
+	return nil
+}
+
+// claimTyps converts claim types in config.
+func (g *VCGenesis) claimTyps() ([]*bitxid.ClaimTyp, error) {
+	var cts []*bitxid.ClaimTyp
+	for _, gct := range g.ClaimTypes {
+		if gct.ID == "" {
+			return nil, fmt.Errorf("genesis claim type without id")
+		}
+		ct := &bitxid.ClaimTyp{ID: gct.ID}
+		for _, f := range gct.Fields {
+			ct.Content = append(ct.Content, &bitxid.FieldTyp{Field: f.Field, Typ: f.Typ})
+		}
+		cts = append(cts, ct)
+	}
+	return cts, nil
+}
+
+// seedGenesis applies the vc genesis to the registry,
+// claim types in config are created with caller as owner.
+func (mm *VCManager) seedGenesis(vcr *VCRegistry, g *VCGenesis, caller bitxid.DID) error {
+	admins, err := genesisAdmins(caller, g.Admins)
+	if err != nil {
+		return err
+	}
+	cts, err := g.claimTyps()
+	if err != nil {
+		return err
+	}
+	for _, ct := range cts {
+		if old, _ := vcr.Registry.GetClaimTyp(ct.ID); old != nil {
+			return fmt.Errorf("genesis claim type %s already existed", ct.ID)
+		}
+		ctid, err := vcr.Registry.CreateClaimTyp(ct)
+		if err != nil {
+			return fmt.Errorf("genesis claim type %s: %w", ct.ID, err)
+		}
+		mm.SetObject(claimTypPolicyKey(ctid), &ClaimTypPolicy{ID: ctid, Owner: caller})
+	}
+	vcr.Admins = admins
+	return nil
+}
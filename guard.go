@@ -0,0 +1,146 @@
+package contracts
+
+import (
+	"github.com/meshplus/bitxhub-core/boltvm"
+	"github.com/meshplus/bitxhub-model/constant"
+	"github.com/meshplus/bitxhub-model/pb"
+	"github.com/meshplus/bitxid"
+	"github.com/meshplus/did-registry/didpb"
+)
+
+// guard is a check run before a manager method touches the registry,
+// it returns a failed response to reject the call, or nil to pass.
+type guard func() *boltvm.Response
+
+// admins answers admin checks of guards,
+// implemented by ChainDIDRegistry, AccountDIDRegistry and VCRegistry.
+type admins interface {
+	hasAdmin(did bitxid.DID) bool
+	isSuperAdmin(did bitxid.DID) bool
+}
+
+// roles answers role checks of guards,
+// implemented by ChainDIDRegistry and AccountDIDRegistry.
+type roles interface {
+	hasRole(did bitxid.DID, role Role) bool
+}
+
+// statuses answers status checks of guards,
+// implemented by ChainDIDRegistry and AccountDIDRegistry.
+type statuses interface {
+	statusOf(did bitxid.DID) bitxid.StatusType
+}
+
+// authorize runs the checks shared by manager methods in order:
+// the registry is initialized, tx.From controls caller did, then guards,
+// returns the first rejection, or nil if all of them passed.
+// Guards are only run on an initialized registry, so they are free to
+// use its underlying bitxid registry.
+func authorize(stub boltvm.Stub, initialized bool, caller string, guards ...guard) *boltvm.Response {
+	if res := checkInitialized(initialized); res != nil {
+		return res
+	}
+	return authorizeAs(stub, initialized, caller, accountController(stub, bitxid.DID(caller)), guards...)
+}
+
+// accountController gets address controlling the account did from the
+// account registry, which rebinds it on key rotation and recovery.
+// It falls back to the address in did if the account registry can't tell,
+// e.g. the did is not registered there, as nothing rebound it then.
+func accountController(stub boltvm.Stub, did bitxid.DID) string {
+	if did.GetType() != int(bitxid.AccountDIDType) {
+		return did.GetAddress()
+	}
+	res := stub.CrossInvoke(constant.DIDRegistryContractAddr.String(), "Resolve", pb.String(string(did)))
+	if !res.Ok {
+		return did.GetAddress()
+	}
+	info := &didpb.DIDInfo{}
+	if err := info.Unmarshal(res.Result); err != nil || info.Controller == "" {
+		return did.GetAddress()
+	}
+	return info.Controller
+}
+
+// authorizeAs is authorize for a caller did controlled by addr
+// instead of the address in it, e.g. an account did rebound by recovery.
+func authorizeAs(stub boltvm.Stub, initialized bool, caller, addr string, guards ...guard) *boltvm.Response {
+	if res := checkInitialized(initialized); res != nil {
+		return res
+	}
+	if stub.Caller() != addr {
+		return errorResponse(ErrCallerMismatch, callerNotMatchError(stub.Caller(), caller))
+	}
+	for _, g := range guards {
+		if res := g(); res != nil {
+			return res
+		}
+	}
+	return nil
+}
+
+// checkInitialized is for methods without a caller, e.g. queries.
+func checkInitialized(initialized bool) *boltvm.Response {
+	if !initialized {
+		return notInitializedResponse()
+	}
+	return nil
+}
+
+// requireAdmin passes if caller is an admin of the registry.
+func requireAdmin(r admins, caller bitxid.DID) guard {
+	return func() *boltvm.Response {
+		if !r.hasAdmin(caller) {
+			return errorResponse(ErrNotAdmin, "caller("+string(caller)+") has no permission")
+		}
+		return nil
+	}
+}
+
+// requireSuperAdmin passes if caller is the super admin of the registry.
+func requireSuperAdmin(r admins, caller bitxid.DID) guard {
+	return func() *boltvm.Response {
+		if !r.isSuperAdmin(caller) {
+			return errorResponse(ErrNotSuperAdmin, "caller("+string(caller)+") doesn't have enough permission")
+		}
+		return nil
+	}
+}
+
+// requireRole passes if caller is an admin or has been granted the role.
+func requireRole(r roles, caller bitxid.DID, role Role) guard {
+	return func() *boltvm.Response {
+		if !r.hasRole(caller, role) {
+			return errorResponse(ErrNotAdmin, "caller("+string(caller)+") has no permission")
+		}
+		return nil
+	}
+}
+
+// requireNormal passes if did is under Normal status, so that a did frozen
+// by admin or by its owner after a key leak can't be managed with the key.
+func requireNormal(r statuses, did bitxid.DID) guard {
+	return func() *boltvm.Response {
+		if status := r.statusOf(did); status != bitxid.Normal {
+			return errorResponse(ErrInvalidStatus, string(did)+" is under status: "+string(status))
+		}
+		return nil
+	}
+}
+
+// requireSig passes if sig over msg was made by the key controlling caller.
+func requireSig(stub boltvm.Stub, caller bitxid.DID, msg, sig []byte) guard {
+	return func() *boltvm.Response {
+		return requireAddrSig(accountController(stub, caller), msg, sig)()
+	}
+}
+
+// requireAddrSig passes if sig over msg was made by the key behind addr.
+func requireAddrSig(addr string, msg, sig []byte) guard {
+	return func() *boltvm.Response {
+		if err := verifyAddrSig(addr, msg, sig); err != nil {
+			return wrapErrorResponse(ErrSignatureInvalid, "verify signature err, ", err)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,107 @@
+package contracts
+
+import (
+	"testing"
+
+	"github.com/meshplus/bitxhub-core/boltvm"
+	"github.com/meshplus/bitxid"
+	"github.com/meshplus/did-registry/stubtest"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAdmins has admin as its only admin and super admin.
+type fakeAdmins struct {
+	admin bitxid.DID
+}
+
+func (f *fakeAdmins) hasAdmin(did bitxid.DID) bool     { return did == f.admin }
+func (f *fakeAdmins) isSuperAdmin(did bitxid.DID) bool { return did == f.admin }
+
+func TestAuthorize(t *testing.T) {
+	acc := newTestAccount(t)
+	other := newTestAccount(t)
+	r := &fakeAdmins{admin: bitxid.DID(other.did)}
+	msg := []byte("msg")
+	// ran records guards run by a case
+	var ran []string
+	record := func(name string, res *boltvm.Response) guard {
+		return func() *boltvm.Response {
+			ran = append(ran, name)
+			return res
+		}
+	}
+	tests := []struct {
+		name        string
+		initialized bool
+		caller      string
+		guards      []guard
+		code        ErrorCode
+		ran         []string
+	}{
+		{
+			name:   "not initialized first",
+			caller: other.did,
+			guards: []guard{record("a", nil)},
+			code:   ErrNotInitialized,
+		},
+		{
+			name:        "caller mismatch before guards",
+			initialized: true,
+			caller:      other.did,
+			guards:      []guard{record("a", nil)},
+			code:        ErrCallerMismatch,
+		},
+		{
+			name:        "guards in order",
+			initialized: true,
+			caller:      acc.did,
+			guards:      []guard{record("a", nil), record("b", errorResponse(ErrNotOwner, "b")), record("c", nil)},
+			code:        ErrNotOwner,
+			ran:         []string{"a", "b"},
+		},
+		{
+			name:        "not admin",
+			initialized: true,
+			caller:      acc.did,
+			guards:      []guard{requireAdmin(r, bitxid.DID(acc.did))},
+			code:        ErrNotAdmin,
+		},
+		{
+			name:        "not super admin",
+			initialized: true,
+			caller:      acc.did,
+			guards:      []guard{requireSuperAdmin(r, bitxid.DID(acc.did))},
+			code:        ErrNotSuperAdmin,
+		},
+		{
+			name:        "signed by others",
+			initialized: true,
+			caller:      acc.did,
+			guards:      []guard{requireAddrSig(acc.addr, msg, other.sign(t, msg))},
+			code:        ErrSignatureInvalid,
+		},
+		{
+			name:        "passed",
+			initialized: true,
+			caller:      acc.did,
+			guards:      []guard{record("a", nil), requireAddrSig(acc.addr, msg, acc.sign(t, msg))},
+			ran:         []string{"a"},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ran = nil
+			stub := stubtest.New("contract")
+			stub.SetCaller(acc.addr)
+			res := authorize(stub, tt.initialized, tt.caller, tt.guards...)
+			if tt.code == "" {
+				require.Nil(t, res)
+			} else {
+				require.NotNil(t, res)
+				requireCode(t, res, tt.code)
+			}
+			require.Equal(t, tt.ran, ran)
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package contracts
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/meshplus/bitxhub-core/boltvm"
+	"github.com/meshplus/bitxid"
+	"github.com/meshplus/did-registry/didpb"
+)
+
+// KeyRecord is a key which has been authoritative for a did.
+// @KeyID: id of the key in the did doc
+// @Address: address of the key, identifies the key regardless of its encoding in docs
+// @Since: height of the block the key became authoritative, 0 if before the first rotation
+// @Until: height of the block the key was retired
+// @Retired: the key was retired by a rotation or recovery
+type KeyRecord struct {
+	KeyID   string
+	Address string
+	Since   uint64
+	Until   uint64
+	Retired bool
+}
+
+// KeyHistories maps a did to its authoritative keys in order,
+// the first one is the original key of the did, behind the address in it,
+// the last one is current unless it was retired by a recovery.
+// Signatures for a did are only accepted from the key behind its controlling
+// address, keys added to the doc by Update never sign for it.
+type KeyHistories map[bitxid.DID][]KeyRecord
+
+func (kh KeyHistories) current(did bitxid.DID) (KeyRecord, bool) {
+	records := kh[did]
+	if len(records) == 0 || records[len(records)-1].Retired {
+		return KeyRecord{}, false
+	}
+	return records[len(records)-1], true
+}
+
+// retired returns addresses of retired keys of did.
+func (kh KeyHistories) retired(did bitxid.DID) []string {
+	var res []string
+	for _, r := range kh[did] {
+		if r.Retired {
+			res = append(res, r.Address)
+		}
+	}
+	return res
+}
+
+// begin starts history of did with its original key, retired at height
+// unless it's the key, which is about to be retired by the caller.
+func (kh *KeyHistories) begin(did bitxid.DID, key KeyRecord, height uint64) {
+	if *kh == nil {
+		*kh = make(KeyHistories)
+	}
+	if len((*kh)[did]) != 0 || key.Address == did.GetAddress() {
+		return
+	}
+	(*kh)[did] = []KeyRecord{{Address: did.GetAddress(), Until: height, Retired: true}}
+}
+
+// rotate retires old key of did at height and makes next key authoritative.
+func (kh *KeyHistories) rotate(did bitxid.DID, old, next KeyRecord, height uint64) {
+	kh.begin(did, old, height)
+	records := (*kh)[did]
+	if _, ok := kh.current(did); !ok {
+		records = append(records, old)
+	}
+	records[len(records)-1].Until = height
+	records[len(records)-1].Retired = true
+	next.Since = height
+	(*kh)[did] = append(records, next)
+}
+
+// retire retires the key behind addr controlling did at height without
+// a successor, e.g. the key was lost and the did recovered.
+// The key is recorded if it was never authoritative by rotation,
+// e.g. the original key or the address of a previous recovery.
+func (kh *KeyHistories) retire(did bitxid.DID, addr string, height uint64) {
+	kh.begin(did, KeyRecord{Address: addr}, height)
+	if _, ok := kh.current(did); !ok {
+		for _, a := range kh.retired(did) {
+			if a == addr {
+				return
+			}
+		}
+		(*kh)[did] = append((*kh)[did], KeyRecord{Address: addr})
+	}
+	records := (*kh)[did]
+	records[len(records)-1].Until = height
+	records[len(records)-1].Retired = true
+}
+
+// rotateKeyMsg is signed by both the current and the new key to rotate key of did,
+// newDocHash is sha256 of the new doc.
+func rotateKeyMsg(did bitxid.DID, newDocHash []byte) []byte {
+	return []byte("RotateKey:" + string(did) + ":" + hex.EncodeToString(newDocHash))
+}
+
+// signingKey verifies sig over msg against doc, returns the record of the key made it.
+func signingKey(doc *bitxid.BasicDoc, msg, sig []byte) (KeyRecord, error) {
+	id, err := verifyDocSig(doc, msg, sig)
+	if err != nil {
+		return KeyRecord{}, err
+	}
+	for _, pk := range doc.PublicKey {
+		if pk.ID == id {
+			addr, err := keyAddress(pk)
+			if err != nil {
+				return KeyRecord{}, err
+			}
+			return KeyRecord{KeyID: id, Address: addr}, nil
+		}
+	}
+	return KeyRecord{}, newError(ErrInternal, "key %s not in doc of %s", id, doc.ID)
+}
+
+// RotateKey replaces the authoritative key of caller with a key of the new doc,
+// and anchors the new doc, caller is controlled by the new key from then on,
+// the retired key is rejected by signature checks and as tx.From.
+// The key behind the controlling address of caller is authoritative.
+// @docb: current doc of caller, its hash should match the anchored one
+// @newDocAddr: addr where the new doc stored
+// @newDocb: new doc, which should not list the retired keys
+// @oldSig: signature over "RotateKey:<caller>:<hex sha256 of newDocb>" by the authoritative key
+// @newSig: signature over the same content by the new key listed in newDocb
+func (dm *AccountDIDManager) RotateKey(caller string, docb []byte, newDocAddr string, newDocb []byte, oldSig, newSig []byte) *boltvm.Response {
+	dr := dm.getAccountDIDRegistry()
+
+	callerDID := bitxid.DID(caller)
+	if res := dr.authorize(dm.Stub, caller); res != nil {
+		return res
+	}
+
+	item, _, exist, err := dr.Registry.Resolve(callerDID)
+	if err != nil || !exist {
+		return errorResponse(ErrNotFound, "rotate key err, did "+caller+" not existed")
+	}
+	if item.Status != bitxid.Normal {
+		return errorResponse(ErrInvalidStatus, "rotate key err, did "+caller+" is under status: "+string(item.Status))
+	}
+	doc, err := loadDoc(callerDID, docb, item.DocHash)
+	if err != nil {
+		return wrapErrorResponse(ErrInvalidArgument, "rotate key err, ", err)
+	}
+	newDocHash := sha256.Sum256(newDocb)
+	newDoc, err := loadDoc(callerDID, newDocb, newDocHash[:])
+	if err != nil {
+		return wrapErrorResponse(ErrInvalidFormat, "rotate key err, ", err)
+	}
+
+	msg := rotateKeyMsg(callerDID, newDocHash[:])
+	doc = keepKeys(doc, []string{dr.controllerOf(callerDID)})
+	oldKey, err := signingKey(doc, msg, oldSig)
+	if err != nil {
+		return wrapErrorResponse(ErrSignatureInvalid, "rotate key err, current key: ", err)
+	}
+	retired := append(dr.KeyHistories.retired(callerDID), oldKey.Address)
+	if len(keepKeys(newDoc, retired).PublicKey) != 0 {
+		return errorResponse(ErrInvalidArgument, "rotate key err, new doc still lists retired key")
+	}
+	newKey, err := signingKey(newDoc, msg, newSig)
+	if err != nil {
+		return wrapErrorResponse(ErrSignatureInvalid, "rotate key err, new key: ", err)
+	}
+
+	height := recordHeight(dm.Stub)
+	if _, _, err := dr.Registry.Update(callerDID, newDocAddr, newDocHash[:]); err != nil {
+		return wrapErrorResponse(ErrRegistryRejected, "rotate key err, ", err)
+	}
+	dr.KeyHistories.rotate(callerDID, oldKey, newKey, height)
+	dr.rebind(callerDID, newKey.Address)
+
+	dm.SetObject(AccountDIDRegistryKey, dr)
+	postDIDEvent(dm.Stub, accountDIDRegistryName, EventRotateKey, callerDID, item.Status, dr.statusOf(callerDID), callerDID)
+	return boltvm.Success(nil)
+}
+
+// GetKeyHistory gets authoritative keys of the did in order, from the original key
+// to the current one, empty if the did never rotated keys or was recovered.
+func (dm *AccountDIDManager) GetKeyHistory(did string) *boltvm.Response {
+	dr := dm.getAccountDIDRegistry()
+
+	if res := checkInitialized(dr.Initalized); res != nil {
+		return res
+	}
+
+	res := &didpb.KeyHistory{}
+	for _, r := range dr.KeyHistories[bitxid.DID(did)] {
+		res.Records = append(res.Records, &didpb.KeyRecord{
+			KeyId:   r.KeyID,
+			Address: r.Address,
+			Since:   r.Since,
+			Until:   r.Until,
+			Retired: r.Retired,
+		})
+	}
+	return success(res)
+}
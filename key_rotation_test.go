@@ -0,0 +1,253 @@
+package contracts
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/meshplus/bitxhub-core/boltvm"
+	"github.com/meshplus/bitxid"
+	"github.com/meshplus/did-registry/didpb"
+	"github.com/stretchr/testify/require"
+)
+
+const rotateHeight = 20
+
+// rotateUserKey rotates key of user with current doc docb and new doc newDocb,
+// signed by oldSigner and newSigner, sent by the address controlling user.
+func rotateUserKey(e *testEnv, docb, newDocb []byte, oldSigner, newSigner *testAccount) *boltvm.Response {
+	hash := sha256.Sum256(newDocb)
+	msg := rotateKeyMsg(bitxid.DID(e.user.did), hash[:])
+	return e.asUser().account.RotateKey(e.user.did, docb, testDocAddr, newDocb, oldSigner.sign(e.t, msg), newSigner.sign(e.t, msg))
+}
+
+// rotated rotates key of user to next, returns doc listing the key of next.
+func rotated(e *testEnv, next *testAccount) []byte {
+	e.setBlock(rotateHeight, testTime)
+	newDocb, _ := docOf(e.t, e.user.did, next, nil)
+	requireOK(e.t, rotateUserKey(e, e.userDoc, newDocb, e.user, next))
+	return newDocb
+}
+
+func keyHistory(t *testing.T, e *testEnv, did string) []*didpb.KeyRecord {
+	history := &didpb.KeyHistory{}
+	decode(t, e.account.GetKeyHistory(did), history)
+	return history.Records
+}
+
+func TestAccountDIDManager_RotateKey(t *testing.T) {
+	runCalls(t, []call{
+		{
+			name: "success",
+			run: func(e *testEnv) *boltvm.Response {
+				rotated(e, newTestAccount(e.t))
+				return e.account.Resolve(e.user.did)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				info := &didpb.DIDInfo{}
+				decode(t, res, info)
+				require.Equal(t, []string{e.user.addr}, info.RetiredKeys)
+
+				records := keyHistory(t, e, e.user.did)
+				require.Len(t, records, 2)
+				require.Equal(t, e.user.did+"#key-1", records[0].KeyId)
+				require.Equal(t, e.user.addr, records[0].Address)
+				require.Equal(t, uint64(rotateHeight), records[0].Until)
+				require.Equal(t, uint64(rotateHeight), records[1].Since)
+				require.Zero(t, records[1].Until)
+				require.Equal(t, records[1].Address, info.Controller)
+
+				ev := lastEvent(t, e.accountStub)
+				require.Equal(t, EventRotateKey, ev.Type)
+			},
+		},
+		{
+			name: "rotate twice",
+			run: func(e *testEnv) *boltvm.Response {
+				next, last := newTestAccount(e.t), newTestAccount(e.t)
+				docb := rotated(e, next)
+				lastDocb, _ := docOf(e.t, e.user.did, last, nil)
+				return rotateUserKey(e, docb, lastDocb, next, last)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Len(t, keyHistory(t, e, e.user.did), 3)
+				require.Len(t, resolveAccountDID(t, e, e.user.did).RetiredKeys, 2)
+			},
+		},
+		{
+			name: "signed by retired key",
+			run: func(e *testEnv) *boltvm.Response {
+				next, last := newTestAccount(e.t), newTestAccount(e.t)
+				// the doc lists both the retired and the current key
+				e.userDoc = rotated(e, next)
+				docb, hash := docOf(e.t, e.user.did, next, e.user)
+				requireOK(e.t, e.asUser().account.Update(e.user.did, testDocAddr, hash, nil))
+				lastDocb, _ := docOf(e.t, e.user.did, last, nil)
+				return rotateUserKey(e, docb, lastDocb, e.user, last)
+			},
+			code: ErrSignatureInvalid,
+		},
+		{
+			name: "not signed by current key",
+			run: func(e *testEnv) *boltvm.Response {
+				next := newTestAccount(e.t)
+				newDocb, _ := docOf(e.t, e.user.did, next, nil)
+				return rotateUserKey(e, e.userDoc, newDocb, next, next)
+			},
+			code: ErrSignatureInvalid,
+		},
+		{
+			name: "not signed by new key",
+			run: func(e *testEnv) *boltvm.Response {
+				next := newTestAccount(e.t)
+				newDocb, _ := docOf(e.t, e.user.did, next, nil)
+				return rotateUserKey(e, e.userDoc, newDocb, e.user, e.user)
+			},
+			code: ErrSignatureInvalid,
+		},
+		{
+			name: "new doc lists retired key",
+			run: func(e *testEnv) *boltvm.Response {
+				next := newTestAccount(e.t)
+				newDocb, _ := docOf(e.t, e.user.did, next, e.user)
+				return rotateUserKey(e, e.userDoc, newDocb, e.user, next)
+			},
+			code: ErrInvalidArgument,
+		},
+		{
+			name: "new doc of others",
+			run: func(e *testEnv) *boltvm.Response {
+				next := newTestAccount(e.t)
+				newDocb, _ := docOf(e.t, next.did, next, nil)
+				return rotateUserKey(e, e.userDoc, newDocb, e.user, next)
+			},
+			code: ErrInvalidArgument,
+		},
+		{
+			name: "current doc not match",
+			run: func(e *testEnv) *boltvm.Response {
+				next := newTestAccount(e.t)
+				newDocb, _ := docOf(e.t, e.user.did, next, nil)
+				return rotateUserKey(e, newDocb, newDocb, e.user, next)
+			},
+			code: ErrInvalidArgument,
+		},
+		{
+			name:  "frozen",
+			setup: userFrozen,
+			run: func(e *testEnv) *boltvm.Response {
+				next := newTestAccount(e.t)
+				newDocb, _ := docOf(e.t, e.user.did, next, nil)
+				return rotateUserKey(e, e.userDoc, newDocb, e.user, next)
+			},
+			code: ErrInvalidStatus,
+		},
+		{
+			name: "no history",
+			run:  func(e *testEnv) *boltvm.Response { return e.account.GetKeyHistory(e.user.did) },
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Empty(t, keyHistory(t, e, e.user.did))
+			},
+		},
+	})
+}
+
+func TestAccountDIDManager_RetiredKey(t *testing.T) {
+	// readded rotates key of user, then anchors a doc listing the retired key again.
+	readded := func(e *testEnv) {
+		rotated(e, newTestAccount(e.t))
+		docb, hash := e.user.doc(e.t)
+		requireOK(e.t, e.asUser().account.Update(e.user.did, testDocAddr, hash, nil))
+		e.userDoc = docb
+	}
+	runCalls(t, []call{
+		{
+			name: "credential stored before rotation",
+			setup: func(e *testEnv) {
+				issued(e)
+				rotated(e, newTestAccount(e.t))
+			},
+			run: func(e *testEnv) *boltvm.Response { return e.vc.VerifyVC(testCID) },
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.True(t, verifyVC(t, e, testCID).Valid)
+			},
+		},
+		{
+			name: "store credential",
+			setup: func(e *testEnv) {
+				e.newClaimTyp(e.user, testCTID, "name")
+				readded(e)
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				c := e.credential(e.user, testCID, testCTID, claimOf(e.t, e.admin.did, map[string]string{"name": "alice"}))
+				cb, err := c.Marshal()
+				require.Nil(e.t, err)
+				return e.asUser().vc.StoreVC(e.user.did, cb, e.userDoc)
+			},
+			code: ErrSignatureInvalid,
+		},
+		{
+			name:  "presentation",
+			setup: readded,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.vc.VerifyPresentation(presentation(e.t, e.user, e.user, e.userDoc))
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				verdict := &didpb.PresentationVerdict{}
+				decode(t, res, verdict)
+				require.False(t, verdict.HolderValid)
+			},
+		},
+		{
+			name:  "send tx",
+			setup: func(e *testEnv) { rotated(e, newTestAccount(e.t)) },
+			run: func(e *testEnv) *boltvm.Response {
+				_, hash := e.user.doc(e.t)
+				return e.as(e.user).account.Update(e.user.did, testDocAddr, hash, nil)
+			},
+			code: ErrCallerMismatch,
+		},
+		{
+			name:  "send tx to vc registry",
+			setup: func(e *testEnv) { rotated(e, newTestAccount(e.t)) },
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).vc.AddTrustedIssuer(e.user.did, testCTID, e.admin.did)
+			},
+			code: ErrCallerMismatch,
+		},
+		{
+			name:  "send tx to chain registry",
+			setup: func(e *testEnv) { rotated(e, newTestAccount(e.t)) },
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).chain.Apply(e.user.did, testAppChainDID, nil)
+			},
+			code: ErrCallerMismatch,
+		},
+		{
+			name: "send tx by new key",
+			setup: func(e *testEnv) {
+				e.newClaimTyp(e.user, testCTID, "name")
+				rotated(e, newTestAccount(e.t))
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.asUser().vc.AddTrustedIssuer(e.user.did, testCTID, e.admin.did)
+			},
+		},
+		{
+			name: "sign status change",
+			setup: func(e *testEnv) {
+				issued(e)
+				rotated(e, newTestAccount(e.t))
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.asUser().vc.RevokeVC(e.user.did, testCID, "leaked", e.user.sign(e.t, []byte(testCID+"leaked")))
+			},
+			code: ErrSignatureInvalid,
+		},
+		{
+			name:  "self freeze",
+			setup: readded,
+			run:   func(e *testEnv) *boltvm.Response { return selfFreezeUser(e, e.user) },
+			code:  ErrSignatureInvalid,
+		},
+	})
+}
@@ -0,0 +1,768 @@
+package contracts
+
+import (
+	"strconv"
+
+	"github.com/meshplus/bitxhub-core/boltvm"
+	"github.com/meshplus/bitxid"
+	"github.com/meshplus/did-registry/didpb"
+)
+
+// ProposalAction is a sensitive registry action which needs admin quorum.
+type ProposalAction string
+
+// sensitive registry actions
+const (
+	ProposalAuditApply     ProposalAction = "AuditApply"     // Target: did, Arg: 1 to approve, 0 to reject
+	ProposalFreeze         ProposalAction = "Freeze"         // Target: did
+	ProposalUnFreeze       ProposalAction = "UnFreeze"       // Target: did
+	ProposalAddAdmin       ProposalAction = "AddAdmin"       // Target: admin did
+	ProposalRemoveAdmin    ProposalAction = "RemoveAdmin"    // Target: admin did
+	ProposalSetThreshold   ProposalAction = "SetThreshold"   // Arg: threshold
+	ProposalSetProposalTTL ProposalAction = "SetProposalTTL" // Arg: ttl in seconds
+
+	ProposalRecoverSuperAdmin ProposalAction = "RecoverSuperAdmin" // Target: new super admin, voted by regular admins, time-locked
+)
+
+// ProposalStatus is status of a proposal.
+type ProposalStatus string
+
+// statuses of proposal
+const (
+	ProposalPending  ProposalStatus = "pending"
+	ProposalApproved ProposalStatus = "approved" // approved recovery waiting for the time-lock
+	ProposalExecuted ProposalStatus = "executed"
+	ProposalRejected ProposalStatus = "rejected"
+	ProposalExpired  ProposalStatus = "expired"
+	ProposalFailed   ProposalStatus = "failed"
+)
+
+// defaultProposalTTL is lifetime of a proposal in seconds if not configured.
+const defaultProposalTTL = 7 * 24 * 60 * 60
+
+// superAdminRecoveryDelay is time-lock of an approved super admin recovery in seconds,
+// during which the current super admin can reject it.
+const superAdminRecoveryDelay = 3 * 24 * 60 * 60
+
+// Quorum represents approval rule of sensitive registry actions.
+// @Threshold: approvals needed to run an action (M of N), 0 or 1 means a single admin
+// is enough and actions can be called directly; capped by number of admins
+// @TTL: lifetime of a proposal in seconds, 0 means defaultProposalTTL
+// @RecoveryThreshold: approvals of regular admins needed to recover a lost super admin,
+// 0 means recovery is disabled; capped by number of regular admins, set by super admin only
+type Quorum struct {
+	Threshold         uint64
+	TTL               uint64
+	RecoveryThreshold uint64
+}
+
+// enabled returns whether sensitive actions must go through proposals.
+func (q *Quorum) enabled() bool {
+	return q.Threshold > 1
+}
+
+// required returns number of approvals needed among the admins.
+func (q *Quorum) required(admins int) uint64 {
+	required := q.Threshold
+	if required == 0 {
+		required = 1
+	}
+	if admins > 0 && required > uint64(admins) {
+		required = uint64(admins)
+	}
+	return required
+}
+
+// recoveryRequired returns number of approvals needed among the regular admins.
+func (q *Quorum) recoveryRequired(admins int) uint64 {
+	required := q.RecoveryThreshold
+	if admins > 0 && required > uint64(admins) {
+		required = uint64(admins)
+	}
+	return required
+}
+
+// recoveryEnabled returns whether regular admins can recover a lost super admin,
+// it needs quorum and at least two approvals so no single admin can take over.
+func (q *Quorum) recoveryEnabled(admins int) bool {
+	return q.enabled() && q.recoveryRequired(admins) >= 2
+}
+
+func (q *Quorum) ttl() int64 {
+	if q.TTL == 0 {
+		return defaultProposalTTL
+	}
+	return int64(q.TTL)
+}
+
+// apply runs quorum actions of the proposal.
+func (q *Quorum) apply(p *Proposal) error {
+	switch p.Action {
+	case ProposalSetThreshold:
+		q.Threshold = p.Arg
+	case ProposalSetProposalTTL:
+		q.TTL = p.Arg
+	default:
+		return newError(ErrInvalidArgument, "unsupported proposal action %s", p.Action)
+	}
+	return nil
+}
+
+// Proposal represents a sensitive action waiting for approvals of admins.
+// @Required: approvals needed when the proposal was last tallied
+// @Expire: timestamp in seconds after which votes are not accepted
+// @Unlock: timestamp in seconds after which an approved recovery can be executed
+// @Error: reason if the action failed to run
+type Proposal struct {
+	ID         uint64
+	Action     ProposalAction
+	Target     bitxid.DID
+	Arg        uint64
+	Proposer   bitxid.DID
+	Approvals  []bitxid.DID
+	Rejections []bitxid.DID
+	Required   uint64
+	Created    int64
+	Expire     int64
+	Unlock     int64
+	Status     ProposalStatus
+	Error      string
+}
+
+// ProposalPage represents a page of proposals.
+// @Total: number of proposals ever opened
+type ProposalPage struct {
+	Total uint64
+	Items []*Proposal
+}
+
+// quorumRegistry is a did registry whose sensitive actions are approved by admin quorum.
+type quorumRegistry interface {
+	name() string
+	admins() []bitxid.DID
+	superAdmin() bitxid.DID
+	statusOf(did bitxid.DID) bitxid.StatusType
+	quorum() *Quorum
+	execute(p *Proposal) error
+}
+
+func checkProposalAction(action ProposalAction) error {
+	switch action {
+	case ProposalAuditApply, ProposalFreeze, ProposalUnFreeze, ProposalAddAdmin, ProposalRemoveAdmin:
+	case ProposalSetThreshold, ProposalSetProposalTTL:
+	case ProposalRecoverSuperAdmin:
+	default:
+		return newError(ErrInvalidArgument, "unsupported proposal action %s", action)
+	}
+	return nil
+}
+
+// superAdminAction returns whether the action changes the admin set or the quorum,
+// which only the super admin may call directly and so only the super admin may propose.
+func superAdminAction(action ProposalAction) bool {
+	switch action {
+	case ProposalAddAdmin, ProposalRemoveAdmin:
+	case ProposalSetThreshold, ProposalSetProposalTTL:
+	default:
+		return false
+	}
+	return true
+}
+
+// openProposal opens a proposal approved by the proposer,
+// the action runs at once if the proposer alone reaches the threshold.
+func openProposal(stub boltvm.Stub, r quorumRegistry, proposer bitxid.DID, action ProposalAction, target bitxid.DID, arg uint64) (*Proposal, error) {
+	if !containsDID(r.admins(), proposer) {
+		return nil, newError(ErrNotAdmin, "caller(%s) has no permission", proposer)
+	}
+	if err := checkProposalAction(action); err != nil {
+		return nil, err
+	}
+	if superAdminAction(action) && proposer != r.superAdmin() {
+		return nil, newError(ErrNotSuperAdmin, "caller(%s) doesn't have enough permission to propose %s", proposer, action)
+	}
+	if action == ProposalRecoverSuperAdmin {
+		if !r.quorum().recoveryEnabled(len(removeDID(r.admins(), r.superAdmin()))) {
+			return nil, newError(ErrInvalidStatus, "super admin recovery is not enabled")
+		}
+		if err := checkSuperAdminCandidate(r, target); err != nil {
+			return nil, err
+		}
+	}
+
+	now, err := blockTime(stub)
+	if err != nil {
+		return nil, err
+	}
+	var count uint64
+	stub.GetObject(proposalCountKey, &count)
+	p := &Proposal{
+		ID:        count,
+		Action:    action,
+		Target:    target,
+		Arg:       arg,
+		Proposer:  proposer,
+		Approvals: []bitxid.DID{proposer},
+		Created:   now,
+		Expire:    now + r.quorum().ttl(),
+		Status:    ProposalPending,
+	}
+	oldStatus := r.statusOf(p.Target)
+	tallyProposal(r, p, now)
+
+	stub.SetObject(proposalCountKey, count+1)
+	stub.SetObject(proposalKey(p.ID), p)
+	postProposalAdminEvent(stub, r, p)
+	postProposalDIDEvent(stub, r, p, oldStatus)
+	return p, nil
+}
+
+// voteProposal records vote of an admin and runs the action once approved,
+// a proposal past its expiry can not be voted.
+func voteProposal(stub boltvm.Stub, r quorumRegistry, voter bitxid.DID, id uint64, approve bool) (*Proposal, error) {
+	if !containsDID(r.admins(), voter) {
+		return nil, newError(ErrNotAdmin, "caller(%s) has no permission", voter)
+	}
+	now, err := blockTime(stub)
+	if err != nil {
+		return nil, err
+	}
+	p, ok := getProposal(stub, id, clock{now: now, known: true})
+	if !ok {
+		return nil, newError(ErrNotFound, "proposal %d not existed", id)
+	}
+	if p.Status == ProposalExpired {
+		return nil, newError(ErrExpired, "proposal %d is already %s", id, p.Status)
+	}
+	if p.Status != ProposalPending {
+		return nil, newError(ErrInvalidStatus, "proposal %d is already %s", id, p.Status)
+	}
+	if containsDID(p.Approvals, voter) || containsDID(p.Rejections, voter) {
+		return nil, newError(ErrAlreadyExists, "caller(%s) already voted on proposal %d", voter, id)
+	}
+
+	if approve {
+		p.Approvals = append(p.Approvals, voter)
+	} else {
+		p.Rejections = append(p.Rejections, voter)
+	}
+	oldStatus := r.statusOf(p.Target)
+	tallyProposal(r, p, now)
+
+	stub.SetObject(proposalKey(p.ID), p)
+	postProposalAdminEvent(stub, r, p)
+	postProposalDIDEvent(stub, r, p, oldStatus)
+	return p, nil
+}
+
+// tallyProposal counts votes of current admins, runs the action if approved,
+// or rejects the proposal once approval is no longer reachable,
+// votes of the super admin are not counted for recovering super admin,
+// an approved recovery is time-locked instead of run, see executeProposal.
+func tallyProposal(r quorumRegistry, p *Proposal, now int64) {
+	admins := r.admins()
+	p.Required = r.quorum().required(len(admins))
+	if p.Action == ProposalRecoverSuperAdmin {
+		admins = removeDID(admins, r.superAdmin())
+		p.Required = r.quorum().recoveryRequired(len(admins))
+		if !r.quorum().recoveryEnabled(len(admins)) {
+			p.Status = ProposalRejected
+			p.Error = "super admin recovery is not enabled"
+			return
+		}
+	}
+	approvals := countDIDs(admins, p.Approvals)
+	rejections := countDIDs(admins, p.Rejections)
+
+	switch {
+	case approvals >= p.Required && p.Action == ProposalRecoverSuperAdmin:
+		p.Status = ProposalApproved
+		p.Unlock = now + superAdminRecoveryDelay
+	case approvals >= p.Required:
+		if err := r.execute(p); err != nil {
+			p.Status = ProposalFailed
+			p.Error = err.Error()
+			return
+		}
+		p.Status = ProposalExecuted
+	case rejections > uint64(len(admins))-p.Required:
+		p.Status = ProposalRejected
+	}
+}
+
+// executeProposal runs an approved recovery once its time-lock passed,
+// approvals are counted again among current regular admins.
+func executeProposal(stub boltvm.Stub, r quorumRegistry, caller bitxid.DID, id uint64) (*Proposal, error) {
+	if !containsDID(r.admins(), caller) {
+		return nil, newError(ErrNotAdmin, "caller(%s) has no permission", caller)
+	}
+	now, err := blockTime(stub)
+	if err != nil {
+		return nil, err
+	}
+	p, ok := getProposal(stub, id, clock{now: now, known: true})
+	if !ok {
+		return nil, newError(ErrNotFound, "proposal %d not existed", id)
+	}
+	if p.Status != ProposalApproved {
+		return nil, newError(ErrInvalidStatus, "proposal %d is %s, not approved", id, p.Status)
+	}
+	if now < p.Unlock {
+		return nil, newError(ErrInvalidStatus, "proposal %d is locked until %d", id, p.Unlock)
+	}
+
+	admins := removeDID(r.admins(), r.superAdmin())
+	p.Required = r.quorum().recoveryRequired(len(admins))
+	switch {
+	case !r.quorum().recoveryEnabled(len(admins)):
+		p.Status = ProposalRejected
+		p.Error = "super admin recovery is not enabled"
+	case countDIDs(admins, p.Approvals) < p.Required:
+		p.Status = ProposalRejected
+		p.Error = "approvals of current admins are not enough"
+	default:
+		if err := r.execute(p); err != nil {
+			p.Status = ProposalFailed
+			p.Error = err.Error()
+		} else {
+			p.Status = ProposalExecuted
+		}
+	}
+
+	stub.SetObject(proposalKey(p.ID), p)
+	postProposalAdminEvent(stub, r, p)
+	return p, nil
+}
+
+// rejectRecovery rejects a pending or approved recovery of the super admin,
+// caller should be the current super admin.
+func rejectRecovery(stub boltvm.Stub, r quorumRegistry, caller bitxid.DID, id uint64) (*Proposal, error) {
+	p, ok := getProposal(stub, id, blockClock(stub))
+	if !ok {
+		return nil, newError(ErrNotFound, "proposal %d not existed", id)
+	}
+	if p.Action != ProposalRecoverSuperAdmin {
+		return nil, newError(ErrInvalidArgument, "proposal %d is %s, not %s", id, p.Action, ProposalRecoverSuperAdmin)
+	}
+	if p.Status != ProposalPending && p.Status != ProposalApproved {
+		return nil, newError(ErrInvalidStatus, "proposal %d is already %s", id, p.Status)
+	}
+	p.Rejections = append(p.Rejections, caller)
+	p.Status = ProposalRejected
+	p.Error = "rejected by super admin"
+
+	stub.SetObject(proposalKey(p.ID), p)
+	postAdminEvent(stub, r.name(), RecoveryRejected, p.Target, caller)
+	return p, nil
+}
+
+// getProposal gets the proposal, a pending proposal past its expiry at clk is shown
+// as expired, it's shown as pending if clk is unknown.
+func getProposal(stub boltvm.Stub, id uint64, clk clock) (*Proposal, bool) {
+	p := &Proposal{}
+	if !stub.GetObject(proposalKey(id), p) {
+		return nil, false
+	}
+	if p.Status == ProposalPending && clk.known && clk.now > p.Expire {
+		p.Status = ProposalExpired
+	}
+	return p, true
+}
+
+// listProposals lists proposals in opening order, filtered by status if given,
+// limit is capped by maxPageLimit, 0 means maxPageLimit.
+func listProposals(stub boltvm.Stub, status ProposalStatus, offset, limit uint64, clk clock) *ProposalPage {
+	if limit == 0 || limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+	page := &ProposalPage{}
+	stub.GetObject(proposalCountKey, &page.Total)
+	var matched uint64
+	for id := uint64(0); id < page.Total && uint64(len(page.Items)) < limit; id++ {
+		p, ok := getProposal(stub, id, clk)
+		if !ok || (status != "" && p.Status != status) {
+			continue
+		}
+		if matched >= offset {
+			page.Items = append(page.Items, p)
+		}
+		matched++
+	}
+	return page
+}
+
+func containsDID(dids []bitxid.DID, did bitxid.DID) bool {
+	for _, d := range dids {
+		if d == did {
+			return true
+		}
+	}
+	return false
+}
+
+func removeDID(dids []bitxid.DID, did bitxid.DID) []bitxid.DID {
+	var res []bitxid.DID
+	for _, d := range dids {
+		if d != did {
+			res = append(res, d)
+		}
+	}
+	return res
+}
+
+// countDIDs counts votes cast by dids in the set.
+func countDIDs(set []bitxid.DID, votes []bitxid.DID) uint64 {
+	var count uint64
+	for _, v := range votes {
+		if containsDID(set, v) {
+			count++
+		}
+	}
+	return count
+}
+
+func quorumRequiredError(action ProposalAction, q *Quorum) string {
+	return string(action) + " requires approvals of " + strconv.FormatUint(q.Threshold, 10) + " admins, open a proposal instead"
+}
+
+const proposalCountKey = "proposal-count"
+
+func proposalKey(id uint64) string {
+	return "proposal-" + strconv.FormatUint(id, 10)
+}
+
+func (mr *ChainDIDRegistry) admins() []bitxid.DID {
+	return mr.Registry.GetAdmins()
+}
+
+func (mr *ChainDIDRegistry) quorum() *Quorum {
+	return &mr.Quorum
+}
+
+// execute runs the approved action on the chain did registry.
+func (mr *ChainDIDRegistry) execute(p *Proposal) error {
+	switch p.Action {
+	case ProposalAuditApply:
+		return mr.Registry.AuditApply(p.Target, p.Arg >= 1)
+	case ProposalFreeze:
+		return mr.freeze(p.Target)
+	case ProposalUnFreeze:
+		return mr.unfreeze(p.Target)
+	case ProposalAddAdmin:
+		return mr.Registry.AddAdmin(p.Target)
+	case ProposalRemoveAdmin:
+		return mr.removeAdmin(p.Target)
+	case ProposalRecoverSuperAdmin:
+		if err := checkSuperAdminCandidate(mr, p.Target); err != nil {
+			return err
+		}
+		mr.SuperAdmin = p.Target
+		mr.PendingSuperAdmin = ""
+		return nil
+	default:
+		return mr.Quorum.apply(p)
+	}
+}
+
+func (dr *AccountDIDRegistry) admins() []bitxid.DID {
+	return dr.Registry.GetAdmins()
+}
+
+func (dr *AccountDIDRegistry) quorum() *Quorum {
+	return &dr.Quorum
+}
+
+// execute runs the approved action on the account did registry,
+// AuditApply is not supported since account dids need no audit.
+func (dr *AccountDIDRegistry) execute(p *Proposal) error {
+	switch p.Action {
+	case ProposalFreeze:
+		return dr.freeze(p.Target)
+	case ProposalUnFreeze:
+		return dr.unfreeze(p.Target)
+	case ProposalAddAdmin:
+		return dr.Registry.AddAdmin(p.Target)
+	case ProposalRemoveAdmin:
+		return dr.removeAdmin(p.Target)
+	case ProposalRecoverSuperAdmin:
+		if err := checkSuperAdminCandidate(dr, p.Target); err != nil {
+			return err
+		}
+		dr.SuperAdmin = p.Target
+		dr.PendingSuperAdmin = ""
+		return nil
+	default:
+		return dr.Quorum.apply(p)
+	}
+}
+
+// Propose opens a proposal of a sensitive action, the proposer approves it
+// by proposing, returns id of the proposal,
+// caller should be admin, and super admin to change admins or quorum.
+func (mm *ChainDIDManager) Propose(caller, action, target string, arg uint64) *boltvm.Response {
+	mr := mm.getChainDIDRegistry()
+
+	callerDID := bitxid.DID(caller)
+	if res := authorize(mm.Stub, mr.Initalized, caller); res != nil {
+		return res
+	}
+
+	p, err := openProposal(mm.Stub, mr, callerDID, ProposalAction(action), bitxid.DID(target), arg)
+	if err != nil {
+		return wrapErrorResponse(ErrInvalidArgument, "propose err, ", err)
+	}
+
+	mm.SetObject(ChainDIDRegistryKey, mr)
+	return success(&didpb.Uint64{Value: p.ID})
+}
+
+// Vote votes for the proposal, returns the proposal after voting,
+// caller should be admin.
+func (mm *ChainDIDManager) Vote(caller string, id uint64, approve bool) *boltvm.Response {
+	mr := mm.getChainDIDRegistry()
+
+	callerDID := bitxid.DID(caller)
+	if res := authorize(mm.Stub, mr.Initalized, caller); res != nil {
+		return res
+	}
+
+	p, err := voteProposal(mm.Stub, mr, callerDID, id, approve)
+	if err != nil {
+		return wrapErrorResponse(ErrInvalidArgument, "vote err, ", err)
+	}
+
+	mm.SetObject(ChainDIDRegistryKey, mr)
+	return success(p.toPB())
+}
+
+// GetProposal gets the proposal.
+func (mm *ChainDIDManager) GetProposal(id uint64) *boltvm.Response {
+	mr := mm.getChainDIDRegistry()
+
+	if res := checkInitialized(mr.Initalized); res != nil {
+		return res
+	}
+
+	p, ok := getProposal(mm.Stub, id, blockClock(mm.Stub))
+	if !ok {
+		return errorResponse(ErrNotFound, "proposal "+strconv.FormatUint(id, 10)+" not existed")
+	}
+	return success(p.toPB())
+}
+
+// ListProposals lists proposals under the status, empty status lists all.
+func (mm *ChainDIDManager) ListProposals(status string, offset, limit uint64) *boltvm.Response {
+	mr := mm.getChainDIDRegistry()
+
+	if res := checkInitialized(mr.Initalized); res != nil {
+		return res
+	}
+
+	return success(listProposals(mm.Stub, ProposalStatus(status), offset, limit, blockClock(mm.Stub)).toPB())
+}
+
+// SetQuorum sets approval rule of sensitive actions,
+// caller should be super admin and quorum should not be enabled yet,
+// otherwise propose SetThreshold or SetProposalTTL.
+func (mm *ChainDIDManager) SetQuorum(caller string, threshold, ttl uint64) *boltvm.Response {
+	mr := mm.getChainDIDRegistry()
+
+	callerDID := bitxid.DID(caller)
+	if res := authorize(mm.Stub, mr.Initalized, caller, requireSuperAdmin(mr, callerDID)); res != nil {
+		return res
+	}
+	if mr.Quorum.enabled() {
+		return errorResponse(ErrQuorumRequired, quorumRequiredError(ProposalSetThreshold, &mr.Quorum))
+	}
+
+	mr.Quorum.Threshold, mr.Quorum.TTL = threshold, ttl
+
+	mm.SetObject(ChainDIDRegistryKey, mr)
+	postDIDEvent(mm.Stub, chainDIDRegistryName, EventSetQuorum, callerDID, "", "", callerDID)
+	return boltvm.Success(nil)
+}
+
+// GetQuorum gets approval rule of sensitive actions.
+func (mm *ChainDIDManager) GetQuorum() *boltvm.Response {
+	mr := mm.getChainDIDRegistry()
+
+	if res := checkInitialized(mr.Initalized); res != nil {
+		return res
+	}
+
+	return success(mr.Quorum.toPB())
+}
+
+// Propose opens a proposal of a sensitive action, the proposer approves it
+// by proposing, returns id of the proposal,
+// caller should be admin, and super admin to change admins or quorum.
+func (dm *AccountDIDManager) Propose(caller, action, target string, arg uint64) *boltvm.Response {
+	dr := dm.getAccountDIDRegistry()
+
+	callerDID := bitxid.DID(caller)
+	if res := dr.authorize(dm.Stub, caller); res != nil {
+		return res
+	}
+	if ProposalAction(action) == ProposalAuditApply {
+		return errorResponse(ErrInvalidArgument, "propose err, account did registry has no apply to audit")
+	}
+
+	p, err := openProposal(dm.Stub, dr, callerDID, ProposalAction(action), bitxid.DID(target), arg)
+	if err != nil {
+		return wrapErrorResponse(ErrInvalidArgument, "propose err, ", err)
+	}
+
+	dm.SetObject(AccountDIDRegistryKey, dr)
+	return success(&didpb.Uint64{Value: p.ID})
+}
+
+// Vote votes for the proposal, returns the proposal after voting,
+// caller should be admin.
+func (dm *AccountDIDManager) Vote(caller string, id uint64, approve bool) *boltvm.Response {
+	dr := dm.getAccountDIDRegistry()
+
+	callerDID := bitxid.DID(caller)
+	if res := dr.authorize(dm.Stub, caller); res != nil {
+		return res
+	}
+
+	p, err := voteProposal(dm.Stub, dr, callerDID, id, approve)
+	if err != nil {
+		return wrapErrorResponse(ErrInvalidArgument, "vote err, ", err)
+	}
+
+	dm.SetObject(AccountDIDRegistryKey, dr)
+	return success(p.toPB())
+}
+
+// GetProposal gets the proposal.
+func (dm *AccountDIDManager) GetProposal(id uint64) *boltvm.Response {
+	dr := dm.getAccountDIDRegistry()
+
+	if res := checkInitialized(dr.Initalized); res != nil {
+		return res
+	}
+
+	p, ok := getProposal(dm.Stub, id, blockClock(dm.Stub))
+	if !ok {
+		return errorResponse(ErrNotFound, "proposal "+strconv.FormatUint(id, 10)+" not existed")
+	}
+	return success(p.toPB())
+}
+
+// ListProposals lists proposals under the status, empty status lists all.
+func (dm *AccountDIDManager) ListProposals(status string, offset, limit uint64) *boltvm.Response {
+	dr := dm.getAccountDIDRegistry()
+
+	if res := checkInitialized(dr.Initalized); res != nil {
+		return res
+	}
+
+	return success(listProposals(dm.Stub, ProposalStatus(status), offset, limit, blockClock(dm.Stub)).toPB())
+}
+
+// SetQuorum sets approval rule of sensitive actions,
+// caller should be super admin and quorum should not be enabled yet,
+// otherwise propose SetThreshold or SetProposalTTL.
+func (dm *AccountDIDManager) SetQuorum(caller string, threshold, ttl uint64) *boltvm.Response {
+	dr := dm.getAccountDIDRegistry()
+
+	callerDID := bitxid.DID(caller)
+	if res := dr.authorize(dm.Stub, caller, requireSuperAdmin(dr, callerDID)); res != nil {
+		return res
+	}
+	if dr.Quorum.enabled() {
+		return errorResponse(ErrQuorumRequired, quorumRequiredError(ProposalSetThreshold, &dr.Quorum))
+	}
+
+	dr.Quorum.Threshold, dr.Quorum.TTL = threshold, ttl
+
+	dm.SetObject(AccountDIDRegistryKey, dr)
+	postDIDEvent(dm.Stub, accountDIDRegistryName, EventSetQuorum, callerDID, "", "", callerDID)
+	return boltvm.Success(nil)
+}
+
+// GetQuorum gets approval rule of sensitive actions.
+func (dm *AccountDIDManager) GetQuorum() *boltvm.Response {
+	dr := dm.getAccountDIDRegistry()
+
+	if res := checkInitialized(dr.Initalized); res != nil {
+		return res
+	}
+
+	return success(dr.Quorum.toPB())
+}
+
+// ExecuteProposal executes an approved super admin recovery after its time-lock,
+// returns the proposal after executing, caller should be admin.
+func (mm *ChainDIDManager) ExecuteProposal(caller string, id uint64) *boltvm.Response {
+	mr := mm.getChainDIDRegistry()
+
+	callerDID := bitxid.DID(caller)
+	if res := authorize(mm.Stub, mr.Initalized, caller); res != nil {
+		return res
+	}
+
+	p, err := executeProposal(mm.Stub, mr, callerDID, id)
+	if err != nil {
+		return wrapErrorResponse(ErrInvalidArgument, "execute proposal err, ", err)
+	}
+
+	mm.SetObject(ChainDIDRegistryKey, mr)
+	return success(p.toPB())
+}
+
+// RejectRecovery rejects a super admin recovery before it's executed,
+// returns the proposal after rejecting, caller should be super admin.
+func (mm *ChainDIDManager) RejectRecovery(caller string, id uint64) *boltvm.Response {
+	mr := mm.getChainDIDRegistry()
+
+	callerDID := bitxid.DID(caller)
+	if res := authorize(mm.Stub, mr.Initalized, caller, requireSuperAdmin(mr, callerDID)); res != nil {
+		return res
+	}
+
+	p, err := rejectRecovery(mm.Stub, mr, callerDID, id)
+	if err != nil {
+		return wrapErrorResponse(ErrInvalidArgument, "reject recovery err, ", err)
+	}
+
+	return success(p.toPB())
+}
+
+// ExecuteProposal executes an approved super admin recovery after its time-lock,
+// returns the proposal after executing, caller should be admin.
+func (dm *AccountDIDManager) ExecuteProposal(caller string, id uint64) *boltvm.Response {
+	dr := dm.getAccountDIDRegistry()
+
+	callerDID := bitxid.DID(caller)
+	if res := dr.authorize(dm.Stub, caller); res != nil {
+		return res
+	}
+
+	p, err := executeProposal(dm.Stub, dr, callerDID, id)
+	if err != nil {
+		return wrapErrorResponse(ErrInvalidArgument, "execute proposal err, ", err)
+	}
+
+	dm.SetObject(AccountDIDRegistryKey, dr)
+	return success(p.toPB())
+}
+
+// RejectRecovery rejects a super admin recovery before it's executed,
+// returns the proposal after rejecting, caller should be super admin.
+func (dm *AccountDIDManager) RejectRecovery(caller string, id uint64) *boltvm.Response {
+	dr := dm.getAccountDIDRegistry()
+
+	callerDID := bitxid.DID(caller)
+	if res := dr.authorize(dm.Stub, caller, requireSuperAdmin(dr, callerDID)); res != nil {
+		return res
+	}
+
+	p, err := rejectRecovery(dm.Stub, dr, callerDID, id)
+	if err != nil {
+		return wrapErrorResponse(ErrInvalidArgument, "reject recovery err, ", err)
+	}
+
+	return success(p.toPB())
+}
@@ -0,0 +1,471 @@
+package contracts
+
+import (
+	"testing"
+
+	"github.com/meshplus/bitxhub-core/boltvm"
+	"github.com/meshplus/bitxid"
+	"github.com/meshplus/did-registry/didpb"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeProposal(t *testing.T, res *boltvm.Response) *didpb.Proposal {
+	p := &didpb.Proposal{}
+	decode(t, res, p)
+	return p
+}
+
+func proposalID(t *testing.T, res *boltvm.Response) uint64 {
+	id := &didpb.Uint64{}
+	decode(t, res, id)
+	return id.Value
+}
+
+func accountQuorum(t *testing.T, e *testEnv) *didpb.Quorum {
+	q := &didpb.Quorum{}
+	decode(t, e.account.GetQuorum(), q)
+	return q
+}
+
+func TestAccountDIDManager_SetQuorum(t *testing.T) {
+	runCalls(t, []call{
+		{
+			name: "success",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.SetQuorum(e.admin.did, 2, 3600)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				q := accountQuorum(t, e)
+				require.Equal(t, uint64(2), q.Threshold)
+				require.Equal(t, uint64(3600), q.Ttl)
+				require.Equal(t, EventSetQuorum, lastEvent(t, e.accountStub).Type)
+			},
+		},
+		{
+			name: "by regular admin",
+			run: func(e *testEnv) *boltvm.Response {
+				other := e.newAdmin()
+				return e.as(other).account.SetQuorum(other.did, 2, 0)
+			},
+			code: ErrNotSuperAdmin,
+		},
+		{
+			name: "caller mismatch",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).account.SetQuorum(e.admin.did, 2, 0)
+			},
+			code: ErrCallerMismatch,
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Zero(t, accountQuorum(t, e).Threshold)
+			},
+		},
+		{
+			name:  "already enabled",
+			setup: quorumEnabled,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.SetQuorum(e.admin.did, 3, 0)
+			},
+			code: ErrQuorumRequired,
+		},
+	})
+}
+
+func TestAccountDIDManager_Propose(t *testing.T) {
+	var other *testAccount
+	withQuorum := func(e *testEnv) {
+		other = enableQuorum(e)
+	}
+	runCalls(t, []call{
+		{
+			name:  "pending",
+			setup: withQuorum,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.Propose(e.admin.did, string(ProposalFreeze), e.user.did, 0)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				p := decodeProposal(t, e.account.GetProposal(proposalID(t, res)))
+				require.Equal(t, string(ProposalPending), p.Status)
+				require.Equal(t, []string{e.admin.did}, p.Approvals)
+				require.Equal(t, uint64(2), p.Required)
+				require.Equal(t, testTime+defaultProposalTTL, p.Expire)
+				require.Equal(t, string(bitxid.Normal), resolveAccountDID(t, e, e.user.did).Status)
+			},
+		},
+		{
+			name: "executed at once without quorum",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.Propose(e.admin.did, string(ProposalFreeze), e.user.did, 0)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				p := decodeProposal(t, e.account.GetProposal(proposalID(t, res)))
+				require.Equal(t, string(ProposalExecuted), p.Status)
+				require.Equal(t, string(bitxid.Frozen), resolveAccountDID(t, e, e.user.did).Status)
+			},
+		},
+		{
+			name: "by others",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).account.Propose(e.user.did, string(ProposalFreeze), e.user.did, 0)
+			},
+			code: ErrNotAdmin,
+		},
+		{
+			name: "caller mismatch",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).account.Propose(e.admin.did, string(ProposalFreeze), e.user.did, 0)
+			},
+			code: ErrCallerMismatch,
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				requireCode(t, e.account.GetProposal(0), ErrNotFound)
+			},
+		},
+		{
+			name: "audit apply",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.Propose(e.admin.did, string(ProposalAuditApply), e.user.did, 1)
+			},
+			code: ErrInvalidArgument,
+		},
+		{
+			name: "unknown action",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.Propose(e.admin.did, "Unknown", e.user.did, 0)
+			},
+			code: ErrInvalidArgument,
+		},
+		{
+			name: "failed action",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.Propose(e.admin.did, string(ProposalUnFreeze), e.user.did, 0)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				p := decodeProposal(t, e.account.GetProposal(proposalID(t, res)))
+				require.Equal(t, string(ProposalFailed), p.Status)
+				require.NotEmpty(t, p.Error)
+			},
+		},
+		{
+			name: "add admin by regular admin without quorum",
+			setup: func(e *testEnv) {
+				other = e.newAdmin()
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(other).account.Propose(other.did, string(ProposalAddAdmin), e.user.did, 0)
+			},
+			code: ErrNotSuperAdmin,
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				admins := &didpb.StringSlice{}
+				decode(t, e.account.GetAdmins(), admins)
+				require.NotContains(t, admins.Slice, e.user.did)
+			},
+		},
+		{
+			name: "remove admin by regular admin without quorum",
+			setup: func(e *testEnv) {
+				other = e.newAdmin()
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(other).account.Propose(other.did, string(ProposalRemoveAdmin), e.admin.did, 0)
+			},
+			code: ErrNotSuperAdmin,
+		},
+		{
+			name:  "set threshold by regular admin under quorum",
+			setup: withQuorum,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(other).account.Propose(other.did, string(ProposalSetThreshold), "", 1)
+			},
+			code: ErrNotSuperAdmin,
+		},
+		{
+			name: "recovery not enabled",
+			setup: func(e *testEnv) {
+				other = e.newAdmin()
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(other).account.Propose(other.did, string(ProposalRecoverSuperAdmin), other.did, 0)
+			},
+			code: ErrInvalidStatus,
+		},
+	})
+}
+
+func TestAccountDIDManager_Vote(t *testing.T) {
+	var other *testAccount
+	proposed := func(e *testEnv) {
+		other = enableQuorum(e)
+		requireOK(e.t, e.as(e.admin).account.Propose(e.admin.did, string(ProposalFreeze), e.user.did, 0))
+	}
+	runCalls(t, []call{
+		{
+			name:  "approve",
+			setup: proposed,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(other).account.Vote(other.did, 0, true)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				p := decodeProposal(t, res)
+				require.Equal(t, string(ProposalExecuted), p.Status)
+				require.Equal(t, []string{e.admin.did, other.did}, p.Approvals)
+				require.Equal(t, string(bitxid.Frozen), resolveAccountDID(t, e, e.user.did).Status)
+				ev := lastEvent(t, e.accountStub)
+				require.Equal(t, EventFreeze, ev.Type)
+				require.Equal(t, bitxid.DID(e.admin.did), ev.Actor)
+			},
+		},
+		{
+			name:  "reject",
+			setup: proposed,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(other).account.Vote(other.did, 0, false)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				p := decodeProposal(t, res)
+				require.Equal(t, string(ProposalRejected), p.Status)
+				require.Equal(t, []string{other.did}, p.Rejections)
+				require.Equal(t, string(bitxid.Normal), resolveAccountDID(t, e, e.user.did).Status)
+			},
+		},
+		{
+			name:  "by others",
+			setup: proposed,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).account.Vote(e.user.did, 0, true)
+			},
+			code: ErrNotAdmin,
+		},
+		{
+			name:  "caller mismatch",
+			setup: proposed,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).account.Vote(other.did, 0, true)
+			},
+			code: ErrCallerMismatch,
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, string(ProposalPending), decodeProposal(t, e.account.GetProposal(0)).Status)
+			},
+		},
+		{
+			name:  "twice",
+			setup: proposed,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.Vote(e.admin.did, 0, true)
+			},
+			code: ErrAlreadyExists,
+		},
+		{
+			name:  "not existed",
+			setup: proposed,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(other).account.Vote(other.did, 1, true)
+			},
+			code: ErrNotFound,
+		},
+		{
+			name: "expired",
+			setup: func(e *testEnv) {
+				proposed(e)
+				e.setBlock(testHeight+1, testTime+defaultProposalTTL+1)
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(other).account.Vote(other.did, 0, true)
+			},
+			code: ErrExpired,
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				p := decodeProposal(t, e.account.GetProposal(0))
+				require.Equal(t, string(ProposalExpired), p.Status)
+			},
+		},
+		{
+			name: "closed",
+			setup: func(e *testEnv) {
+				proposed(e)
+				requireOK(e.t, e.as(other).account.Vote(other.did, 0, true))
+				requireOK(e.t, e.as(e.admin).account.Propose(e.admin.did, string(ProposalAddAdmin), e.user.did, 0))
+				requireOK(e.t, e.as(other).account.Vote(other.did, 1, true))
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).account.Vote(e.user.did, 0, false)
+			},
+			code: ErrInvalidStatus,
+		},
+		{
+			name: "set threshold",
+			setup: func(e *testEnv) {
+				other = enableQuorum(e)
+				requireOK(e.t, e.as(e.admin).account.Propose(e.admin.did, string(ProposalSetThreshold), "", 1))
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(other).account.Vote(other.did, 0, true)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, uint64(1), accountQuorum(t, e).Threshold)
+				requireOK(t, e.as(e.admin).account.Freeze(e.admin.did, e.user.did, nil))
+			},
+		},
+	})
+}
+
+func TestAccountDIDManager_ListProposals(t *testing.T) {
+	e := newInitedEnv(t)
+	other := enableQuorum(e)
+	e.as(e.admin)
+	for i := 0; i < 3; i++ {
+		acc, _ := e.newAccount()
+		requireOK(t, e.as(e.admin).account.Propose(e.admin.did, string(ProposalFreeze), acc.did, 0))
+	}
+	requireOK(t, e.as(other).account.Vote(other.did, 1, true))
+
+	page := &didpb.ProposalPage{}
+	decode(t, e.account.ListProposals("", 0, 0), page)
+	require.Equal(t, uint64(3), page.Total)
+	require.Len(t, page.Items, 3)
+
+	page = &didpb.ProposalPage{}
+	decode(t, e.account.ListProposals(string(ProposalPending), 1, 1), page)
+	require.Len(t, page.Items, 1)
+	require.Equal(t, uint64(2), page.Items[0].Id)
+
+	page = &didpb.ProposalPage{}
+	decode(t, e.account.ListProposals(string(ProposalExecuted), 0, 0), page)
+	require.Len(t, page.Items, 1)
+	require.Equal(t, uint64(1), page.Items[0].Id)
+
+	requireCode(t, e.account.GetProposal(3), ErrNotFound)
+}
+
+func TestChainDIDManager_Propose(t *testing.T) {
+	var other *testAccount
+	runCalls(t, []call{
+		{
+			name: "audit apply",
+			setup: func(e *testEnv) {
+				other = enableQuorum(e)
+				applied(e)
+				requireCode(e.t, e.as(e.admin).chain.AuditApply(e.admin.did, testAppChainDID, 1, nil), ErrQuorumRequired)
+				requireOK(e.t, e.chain.Propose(e.admin.did, string(ProposalAuditApply), testAppChainDID, 1))
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(other).chain.Vote(other.did, 0, true)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, string(ProposalExecuted), decodeProposal(t, res).Status)
+				require.Equal(t, bitxid.ApplySuccess, chainDIDStatus(t, e, testAppChainDID))
+			},
+		},
+		{
+			name: "add admin",
+			setup: func(e *testEnv) {
+				other = enableQuorum(e)
+				requireOK(e.t, e.as(e.admin).chain.Propose(e.admin.did, string(ProposalAddAdmin), e.user.did, 0))
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(other).chain.Vote(other.did, 0, true)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				ev := lastAdminEvent(t, e.chainStub)
+				require.Equal(t, AdminAdded, ev.Action)
+				require.Equal(t, bitxid.DID(e.user.did), ev.Admin)
+				require.True(t, ev.Quorum)
+				admins := &didpb.StringSlice{}
+				decode(t, e.chain.GetAdmins(), admins)
+				require.Contains(t, admins.Slice, e.user.did)
+			},
+		},
+		{
+			name: "by others",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).chain.Propose(e.user.did, string(ProposalAuditApply), testAppChainDID, 1)
+			},
+			code: ErrNotAdmin,
+		},
+		{
+			name: "caller mismatch",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).chain.Propose(e.admin.did, string(ProposalFreeze), testChainDID, 0)
+			},
+			code: ErrCallerMismatch,
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				requireCode(t, e.chain.GetProposal(0), ErrNotFound)
+			},
+		},
+		{
+			name: "vote by others",
+			setup: func(e *testEnv) {
+				enableQuorum(e)
+				requireOK(e.t, e.as(e.admin).chain.Propose(e.admin.did, string(ProposalFreeze), testChainDID, 0))
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).chain.Vote(e.user.did, 0, true)
+			},
+			code: ErrNotAdmin,
+		},
+		{
+			name: "vote caller mismatch",
+			setup: func(e *testEnv) {
+				other = enableQuorum(e)
+				requireOK(e.t, e.as(e.admin).chain.Propose(e.admin.did, string(ProposalFreeze), testChainDID, 0))
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).chain.Vote(other.did, 0, true)
+			},
+			code: ErrCallerMismatch,
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, string(ProposalPending), decodeProposal(t, e.chain.GetProposal(0)).Status)
+			},
+		},
+		{
+			name:  "vote not existed",
+			setup: func(e *testEnv) { other = enableQuorum(e) },
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(other).chain.Vote(other.did, 0, true)
+			},
+			code: ErrNotFound,
+		},
+		{
+			name: "get not existed",
+			run:  func(e *testEnv) *boltvm.Response { return e.chain.GetProposal(0) },
+			code: ErrNotFound,
+		},
+		{
+			name: "set quorum by others",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).chain.SetQuorum(e.user.did, 2, 0)
+			},
+			code: ErrNotSuperAdmin,
+		},
+		{
+			name: "set quorum caller mismatch",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).chain.SetQuorum(e.admin.did, 2, 0)
+			},
+			code: ErrCallerMismatch,
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Empty(t, e.chainStub.Events())
+			},
+		},
+		{
+			name: "set ttl by regular admin without quorum",
+			setup: func(e *testEnv) {
+				other = e.newAdmin()
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(other).chain.Propose(other.did, string(ProposalSetProposalTTL), "", 1)
+			},
+			code: ErrNotSuperAdmin,
+		},
+		{
+			name: "get quorum",
+			setup: func(e *testEnv) {
+				requireOK(e.t, e.as(e.admin).chain.SetQuorum(e.admin.did, 2, 60))
+			},
+			run: func(e *testEnv) *boltvm.Response { return e.chain.GetQuorum() },
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				q := &didpb.Quorum{}
+				decode(t, res, q)
+				require.Equal(t, uint64(2), q.Threshold)
+				require.Equal(t, uint64(60), q.Ttl)
+			},
+		},
+	})
+}
@@ -0,0 +1,103 @@
+package register
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"github.com/meshplus/bitxid"
+)
+
+// DefaultMethod is the chain did of the relay chain if not configured.
+const DefaultMethod = "did:bitxhub:relayroot:."
+
+// Config is the did registry section of the node's repo config.
+// @Admins: genesis admin dids, the first one is the super admin
+// @Method: chain did method name of the relay chain, e.g. did:bitxhub:relayroot:.
+// @GenesisChainDoc: genesis doc info of the chain did registry, defaults to Method
+// @GenesisAccountDoc: genesis doc info of the account did registry, defaults to the first admin
+type Config struct {
+	Admins            []string   `mapstructure:"admins" toml:"admins" json:"admins"`
+	Method            string     `mapstructure:"method" toml:"method" json:"method"`
+	GenesisChainDoc   GenesisDoc `mapstructure:"genesis_chain_doc" toml:"genesis_chain_doc" json:"genesis_chain_doc"`
+	GenesisAccountDoc GenesisDoc `mapstructure:"genesis_account_doc" toml:"genesis_account_doc" json:"genesis_account_doc"`
+}
+
+// GenesisDoc is the genesis doc info in config,
+// Hash is hex encoded hash of the doc content.
+type GenesisDoc struct {
+	ID   string `mapstructure:"id" toml:"id" json:"id"`
+	Addr string `mapstructure:"addr" toml:"addr" json:"addr"`
+	Hash string `mapstructure:"hash" toml:"hash" json:"hash"`
+}
+
+var (
+	config     = &Config{}
+	configLock sync.RWMutex
+)
+
+// SetConfig sets config used by registry constructors,
+// should be called by the node before registries are constructed.
+func SetConfig(c *Config) {
+	configLock.Lock()
+	defer configLock.Unlock()
+	if c == nil {
+		c = &Config{}
+	}
+	config = c
+}
+
+// GetConfig gets config used by registry constructors.
+func GetConfig() *Config {
+	configLock.RLock()
+	defer configLock.RUnlock()
+	return config
+}
+
+// admins gets valid admin dids in config.
+func (c *Config) admins() ([]bitxid.DID, error) {
+	var admins []bitxid.DID
+	for _, admin := range c.Admins {
+		did := bitxid.DID(admin)
+		if !did.IsValidFormat() || did.GetType() != int(bitxid.AccountDIDType) {
+			return nil, fmt.Errorf("admin %s is not a valid account did", admin)
+		}
+		admins = append(admins, did)
+	}
+	return admins, nil
+}
+
+// method gets chain did method name of the relay chain.
+func (c *Config) method() (bitxid.DID, error) {
+	if c.Method == "" {
+		return DefaultMethod, nil
+	}
+	method := bitxid.DID(c.Method)
+	if !method.IsValidFormat() || method.GetType() != int(bitxid.ChainDIDType) {
+		return "", fmt.Errorf("method %s is not a valid chain did", c.Method)
+	}
+	return method, nil
+}
+
+// docInfo converts the genesis doc in config to doc info,
+// id defaults to did and addr defaults to ".".
+func (d GenesisDoc) docInfo(did bitxid.DID) (bitxid.DocInfo, error) {
+	info := bitxid.DocInfo{ID: did, Addr: ".", Hash: []byte{}}
+	if d.ID != "" {
+		info.ID = bitxid.DID(d.ID)
+		if !info.ID.IsValidFormat() {
+			return bitxid.DocInfo{}, fmt.Errorf("genesis doc id %s is not a valid did", d.ID)
+		}
+	}
+	if d.Addr != "" {
+		info.Addr = d.Addr
+	}
+	if d.Hash != "" {
+		hash, err := hex.DecodeString(d.Hash)
+		if err != nil {
+			return bitxid.DocInfo{}, fmt.Errorf("genesis doc hash of %s: %w", info.ID, err)
+		}
+		info.Hash = hash
+	}
+	return info, nil
+}
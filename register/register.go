@@ -7,33 +7,112 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// NewMethodRegistryRegister constructs the chain did registry with admins,
+// method name and genesis doc info in config, config errors are logged
+// and the registry is constructed without them, it panics if the registry
+// can't be constructed even without them since the node can't serve dids.
 func NewMethodRegistryRegister(ts storage.Storage, l logrus.FieldLogger) agency.Registry {
-	r, err := bitxid.NewChainDIDRegistry(ts, l)
-	// TODO WithAdmin
+	options, err := methodRegistryOptions(GetConfig())
 	if err != nil {
-		return nil
+		l.WithField("registry", "chain-did").Errorf("load config err: %s", err)
+		options = nil
+	}
+	r, err := bitxid.NewChainDIDRegistry(ts, l, options...)
+	if err != nil {
+		l.WithField("registry", "chain-did").Errorf("construct registry err: %s", err)
+		if r, err = bitxid.NewChainDIDRegistry(ts, l); err != nil {
+			l.WithField("registry", "chain-did").Errorf("construct default registry err: %s", err)
+			panic(err)
+		}
 	}
 	return r
 }
 
+// NewDIDRegistryRegister constructs the account did registry with admins
+// and genesis doc info in config, config errors are logged
+// and the registry is constructed without them, it panics if the registry
+// can't be constructed even without them since the node can't serve dids.
 func NewDIDRegistryRegister(ts storage.Storage, l logrus.FieldLogger) agency.Registry {
-	r, err := bitxid.NewAccountDIDRegistry(ts, l)
+	options, err := didRegistryOptions(GetConfig())
+	if err != nil {
+		l.WithField("registry", "account-did").Errorf("load config err: %s", err)
+		options = nil
+	}
+	r, err := bitxid.NewAccountDIDRegistry(ts, l, options...)
 	if err != nil {
-		return nil
+		l.WithField("registry", "account-did").Errorf("construct registry err: %s", err)
+		if r, err = bitxid.NewAccountDIDRegistry(ts, l); err != nil {
+			l.WithField("registry", "account-did").Errorf("construct default registry err: %s", err)
+			panic(err)
+		}
 	}
 	return r
 }
 
-// func NewVCRegistryRegister(ts storage.Storage) agency.Registry {
-// 	r, err := bitxid.NewAccountDIDRegistry(ts, l)
-// 	if err != nil {
-// 		return nil
-// 	}
-// 	return r
-// }
+// NewVCRegistryRegister constructs the read-only vc registry, construction
+// errors are logged and it's retried once, it panics if the retry fails too
+// since the node can't serve credentials.
+func NewVCRegistryRegister(ts storage.Storage, l logrus.FieldLogger) agency.Registry {
+	r, err := bitxid.NewVCRegistry(ts)
+	if err != nil {
+		l.WithField("registry", "vc").Errorf("construct registry err: %s", err)
+		if r, err = bitxid.NewVCRegistry(ts); err != nil {
+			l.WithField("registry", "vc").Errorf("construct default registry err: %s", err)
+			panic(err)
+		}
+	}
+	return &VCRegistry{
+		registry: r,
+		store:    ts,
+	}
+}
+
+func methodRegistryOptions(c *Config) ([]func(*bitxid.ChainDIDRegistry), error) {
+	admins, err := c.admins()
+	if err != nil {
+		return nil, err
+	}
+	method, err := c.method()
+	if err != nil {
+		return nil, err
+	}
+	info, err := c.GenesisChainDoc.docInfo(method)
+	if err != nil {
+		return nil, err
+	}
+	options := []func(*bitxid.ChainDIDRegistry){
+		bitxid.WithGenesisChainDocInfo(info),
+	}
+	if len(admins) != 0 {
+		options = append(options, func(cr *bitxid.ChainDIDRegistry) {
+			cr.Admins = admins
+		})
+	}
+	return options, nil
+}
+
+func didRegistryOptions(c *Config) ([]func(*bitxid.AccountDIDRegistry), error) {
+	admins, err := c.admins()
+	if err != nil {
+		return nil, err
+	}
+	if len(admins) == 0 {
+		return nil, nil
+	}
+	info, err := c.GenesisAccountDoc.docInfo(admins[0])
+	if err != nil {
+		return nil, err
+	}
+	return []func(*bitxid.AccountDIDRegistry){
+		bitxid.WithGenesisAccountDocInfo(info),
+		func(ar *bitxid.AccountDIDRegistry) {
+			ar.Admins = admins
+		},
+	}, nil
+}
 
 func init() {
 	agency.RegisterRegistryConstructor("chain-did", NewMethodRegistryRegister)
 	agency.RegisterRegistryConstructor("account-did", NewDIDRegistryRegister)
-	// agency.RegisterRegistryConstructor("vc", NewDIDRegistryRegister)
+	agency.RegisterRegistryConstructor("vc", NewVCRegistryRegister)
 }
@@ -0,0 +1,65 @@
+package register
+
+import (
+	"testing"
+
+	"github.com/meshplus/bitxhub-core/agency"
+	"github.com/meshplus/bitxhub-model/constant"
+	"github.com/meshplus/bitxid"
+	"github.com/meshplus/did-registry/converter"
+	"github.com/meshplus/did-registry/stubtest"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVCRegistryRegister(t *testing.T) {
+	constructor, err := agency.GetRegistryConstructor("vc")
+	require.Nil(t, err)
+	ts := converter.StubToStorage(stubtest.New(constant.VCRegistryContractAddr.String()))
+	r, ok := constructor(ts, logrus.New()).(*VCRegistry)
+	require.True(t, ok)
+
+	vc, err := r.GetVC("vc-1")
+	require.Nil(t, err)
+	require.Nil(t, vc)
+	ct, err := r.GetClaimTyp("ct-1")
+	require.Nil(t, err)
+	require.Nil(t, ct)
+	cts, err := r.GetAllClaimTyps()
+	require.Nil(t, err)
+	require.Empty(t, cts)
+}
+
+func TestConfigInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *Config
+	}{
+		{name: "admin not account did", config: &Config{Admins: []string{DefaultMethod}}},
+		{name: "method not chain did", config: &Config{Method: "method"}},
+		{name: "doc id not did", config: &Config{GenesisChainDoc: GenesisDoc{ID: "doc"}}},
+		{name: "doc hash not hex", config: &Config{GenesisChainDoc: GenesisDoc{Hash: "hash"}}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := methodRegistryOptions(tt.config)
+			require.NotNil(t, err)
+
+			// the registry is constructed without config
+			SetConfig(tt.config)
+			defer SetConfig(nil)
+			ts := converter.StubToStorage(stubtest.New(constant.MethodRegistryContractAddr.String()))
+			r, ok := NewMethodRegistryRegister(ts, logrus.New()).(*bitxid.ChainDIDRegistry)
+			require.True(t, ok)
+			require.Empty(t, r.Admins)
+		})
+	}
+
+	admin := "did:bitxhub:relayroot:0x1234"
+	_, err := didRegistryOptions(&Config{Admins: []string{admin}, GenesisAccountDoc: GenesisDoc{Hash: "hash"}})
+	require.NotNil(t, err)
+	options, err := didRegistryOptions(&Config{Admins: []string{admin}})
+	require.Nil(t, err)
+	require.Len(t, options, 2)
+}
@@ -0,0 +1,61 @@
+package register
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/meshplus/bitxhub-core/agency"
+	"github.com/meshplus/bitxhub-kit/storage"
+	"github.com/meshplus/bitxid"
+	contracts "github.com/meshplus/did-registry"
+)
+
+// VCRegistry is a read-only agency.Registry adapter of the vc registry,
+// it reads state written by the vc registry contract.
+type VCRegistry struct {
+	registry *bitxid.VCRegistry
+	store    storage.Storage
+}
+
+var _ agency.Registry = (*VCRegistry)(nil)
+
+// GetVC gets a vc, returns nil if not existed.
+func (r *VCRegistry) GetVC(cid string) (*bitxid.Credential, error) {
+	return r.registry.GetVC(cid)
+}
+
+// GetClaimTyp gets a claim type, returns nil if not existed.
+func (r *VCRegistry) GetClaimTyp(ctid string) (*bitxid.ClaimTyp, error) {
+	return r.registry.GetClaimTyp(ctid)
+}
+
+// GetAllClaimTyps gets all claim types created through the contract.
+func (r *VCRegistry) GetAllClaimTyps() ([]*bitxid.ClaimTyp, error) {
+	if err := r.loadCTlist(); err != nil {
+		return nil, err
+	}
+	return r.registry.GetAllClaimTyps()
+}
+
+// loadCTlist loads claim type list kept in the contract object,
+// since it is not stored under the registry store.
+func (r *VCRegistry) loadCTlist() error {
+	if !r.store.Has([]byte(contracts.VCRegistryKey)) {
+		r.registry.CTlist = nil
+		return nil
+	}
+	vr := struct {
+		Registry *struct {
+			CTlist []string `json:"ct_list"`
+		}
+	}{}
+	if err := json.Unmarshal(r.store.Get([]byte(contracts.VCRegistryKey)), &vr); err != nil {
+		return fmt.Errorf("vc registry unmarshal: %w", err)
+	}
+	if vr.Registry == nil {
+		r.registry.CTlist = nil
+		return nil
+	}
+	r.registry.CTlist = vr.Registry.CTlist
+	return nil
+}
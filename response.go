@@ -0,0 +1,183 @@
+package contracts
+
+import (
+	"github.com/meshplus/bitxhub-core/boltvm"
+	"github.com/meshplus/bitxid"
+	"github.com/meshplus/did-registry/didpb"
+)
+
+// message is a generated message of didpb.
+type message interface {
+	Marshal() ([]byte, error)
+}
+
+// success marshals msg as the result of a successful response,
+// msg should be one of the response messages declared in didpb.
+func success(msg message) *boltvm.Response {
+	b, err := msg.Marshal()
+	if err != nil {
+		return wrapErrorResponse(ErrInternal, "response marshal err, ", err)
+	}
+	return boltvm.Success(b)
+}
+
+func boolResponse(value bool) *boltvm.Response {
+	return success(&didpb.Bool{Value: value})
+}
+
+func stringResponse(value string) *boltvm.Response {
+	return success(&didpb.String{Value: value})
+}
+
+func didsResponse(dids []bitxid.DID) *boltvm.Response {
+	return success(&didpb.StringSlice{Slice: didStrings(dids)})
+}
+
+func rolesResponse(roles []Role) *boltvm.Response {
+	slice := make([]string, 0, len(roles))
+	for _, role := range roles {
+		slice = append(slice, string(role))
+	}
+	return success(&didpb.StringSlice{Slice: slice})
+}
+
+func didStrings(dids []bitxid.DID) []string {
+	res := make([]string, 0, len(dids))
+	for _, did := range dids {
+		res = append(res, string(did))
+	}
+	return res
+}
+
+func (q *Quorum) toPB() *didpb.Quorum {
+	return &didpb.Quorum{
+		Threshold:         q.Threshold,
+		Ttl:               q.TTL,
+		RecoveryThreshold: q.RecoveryThreshold,
+	}
+}
+
+func (p *Proposal) toPB() *didpb.Proposal {
+	return &didpb.Proposal{
+		Id:         p.ID,
+		Action:     string(p.Action),
+		Target:     string(p.Target),
+		Arg:        p.Arg,
+		Proposer:   string(p.Proposer),
+		Approvals:  didStrings(p.Approvals),
+		Rejections: didStrings(p.Rejections),
+		Required:   p.Required,
+		Created:    p.Created,
+		Expire:     p.Expire,
+		Unlock:     p.Unlock,
+		Status:     string(p.Status),
+		Error:      p.Error,
+	}
+}
+
+func (page *ProposalPage) toPB() *didpb.ProposalPage {
+	res := &didpb.ProposalPage{Total: page.Total}
+	for _, p := range page.Items {
+		res.Items = append(res.Items, p.toPB())
+	}
+	return res
+}
+
+func claimTypToPB(ct *bitxid.ClaimTyp) *didpb.ClaimTyp {
+	res := &didpb.ClaimTyp{Id: ct.ID}
+	for _, f := range ct.Content {
+		res.Content = append(res.Content, &didpb.FieldTyp{Field: f.Field, Typ: f.Typ})
+	}
+	return res
+}
+
+func credentialToPB(c *bitxid.Credential) *didpb.Credential {
+	return &didpb.Credential{
+		Id:         c.ID,
+		Typ:        c.Typ,
+		Issuer:     string(c.Issuer),
+		Issued:     c.Issued,
+		Expiration: c.Expiration,
+		Claim:      c.Claim,
+		Signature:  &didpb.Sig{Typ: c.Signature.Typ, Content: c.Signature.Content},
+	}
+}
+
+func (s *VCStatus) toPB() *didpb.VCStatus {
+	return &didpb.VCStatus{
+		Id:        s.ID,
+		Status:    string(s.Status),
+		Reason:    s.Reason,
+		Timestamp: s.Timestamp,
+	}
+}
+
+func (v *VCVerdict) toPB() *didpb.VCVerdict {
+	return &didpb.VCVerdict{
+		Id:     v.ID,
+		Valid:  v.Valid,
+		Status: string(v.Status),
+		Errors: v.Errors,
+	}
+}
+
+func (v *PresentationVerdict) toPB() *didpb.PresentationVerdict {
+	res := &didpb.PresentationVerdict{
+		Holder:      string(v.Holder),
+		Challenge:   v.Challenge,
+		Domain:      v.Domain,
+		Valid:       v.Valid,
+		HolderValid: v.HolderValid,
+		Errors:      v.Errors,
+	}
+	for _, c := range v.Credentials {
+		res.Credentials = append(res.Credentials, c.toPB())
+	}
+	return res
+}
+
+func (v *DisclosureVerdict) toPB() *didpb.DisclosureVerdict {
+	return &didpb.DisclosureVerdict{
+		Id:     v.ID,
+		Field:  v.Field,
+		Valid:  v.Valid,
+		Status: string(v.Status),
+		Error:  v.Error,
+	}
+}
+
+func (esl *EncodedStatusList) toPB() *didpb.StatusList {
+	return &didpb.StatusList{
+		Id:          esl.ID,
+		Issuer:      string(esl.Issuer),
+		Purpose:     esl.Purpose,
+		Length:      esl.Length,
+		EncodedList: esl.EncodedList,
+		Updated:     esl.Updated,
+	}
+}
+
+func (page *VCPage) toPB() *didpb.VCPage {
+	res := &didpb.VCPage{Total: page.Total}
+	for _, s := range page.Items {
+		res.Items = append(res.Items, &didpb.VCSummary{
+			Id:         s.ID,
+			Typ:        s.Typ,
+			Issuer:     string(s.Issuer),
+			Holder:     string(s.Holder),
+			Issued:     s.Issued,
+			Expiration: s.Expiration,
+			Status:     string(s.Status),
+		})
+	}
+	return res
+}
+
+func (p *ClaimTypPolicy) toPB() *didpb.ClaimTypPolicy {
+	return &didpb.ClaimTypPolicy{
+		Id:             p.ID,
+		Owner:          string(p.Owner),
+		Restricted:     p.Restricted,
+		TrustedIssuers: didStrings(p.TrustedIssuers),
+	}
+}
@@ -0,0 +1,59 @@
+package contracts
+
+import (
+	"testing"
+
+	"github.com/meshplus/bitxid"
+	"github.com/meshplus/did-registry/didpb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuccess(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  message
+		got  unmarshaler
+	}{
+		{
+			name: "proposal",
+			msg: (&Proposal{
+				ID:         1,
+				Action:     ProposalRecoverSuperAdmin,
+				Target:     bitxid.DID(testChainDID),
+				Arg:        2,
+				Proposer:   "did:bitxhub:appchain001:0x1",
+				Approvals:  []bitxid.DID{"did:bitxhub:appchain001:0x1"},
+				Rejections: []bitxid.DID{"did:bitxhub:appchain001:0x2"},
+				Required:   2,
+				Created:    testTime,
+				Expire:     testTime + 1,
+				Unlock:     testTime + 2,
+				Status:     ProposalApproved,
+				Error:      "err",
+			}).toPB(),
+			got: &didpb.Proposal{},
+		},
+		{
+			name: "vc status",
+			msg:  (&VCStatus{ID: testCID, Status: VCSuspended, Reason: "lost", Timestamp: testTime}).toPB(),
+			got:  &didpb.VCStatus{},
+		},
+		{
+			name: "claim type policy",
+			msg:  (&ClaimTypPolicy{ID: testCTID, Owner: "did:bitxhub:appchain001:0x1", Restricted: true, TrustedIssuers: []bitxid.DID{bitxid.DID(testChainDID)}}).toPB(),
+			got:  &didpb.ClaimTypPolicy{},
+		},
+		{
+			name: "dids",
+			msg:  &didpb.StringSlice{Slice: didStrings([]bitxid.DID{bitxid.DID(testChainDID), bitxid.DID(testAppChainDID)})},
+			got:  &didpb.StringSlice{},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			decode(t, success(tt.msg), tt.got)
+			require.Equal(t, tt.msg, tt.got)
+		})
+	}
+}
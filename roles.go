@@ -0,0 +1,260 @@
+package contracts
+
+import (
+	"encoding/hex"
+
+	"github.com/meshplus/bitxhub-core/boltvm"
+	"github.com/meshplus/bitxid"
+)
+
+// Role is a named permission granted to a did,
+// admins implicitly have every role.
+type Role string
+
+// roles of did registries
+const (
+	RoleAuditor          Role = "auditor"           // may AuditApply and Audit
+	RoleFreezer          Role = "freezer"           // may Freeze and UnFreeze
+	RoleRegistrar        Role = "registrar"         // may Register on behalf of others
+	RoleConverterManager Role = "converter-manager" // may SetConvertMap
+)
+
+// role actions recorded by AdminEvent
+const (
+	RoleGranted = "GrantRole"
+	RoleRevoked = "RevokeRole"
+)
+
+func checkRole(role Role) error {
+	switch role {
+	case RoleAuditor, RoleFreezer, RoleRegistrar, RoleConverterManager:
+		return nil
+	}
+	return newError(ErrInvalidArgument, "unknown role %s", role)
+}
+
+// RoleSet maps a did to roles granted to it.
+type RoleSet map[bitxid.DID][]Role
+
+func (rs RoleSet) has(did bitxid.DID, role Role) bool {
+	for _, r := range rs[did] {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+func (rs *RoleSet) grant(did bitxid.DID, role Role) error {
+	if err := checkRole(role); err != nil {
+		return err
+	}
+	if rs.has(did, role) {
+		return newError(ErrAlreadyExists, "%s already has role %s", did, role)
+	}
+	if *rs == nil {
+		*rs = make(RoleSet)
+	}
+	(*rs)[did] = append((*rs)[did], role)
+	return nil
+}
+
+func (rs RoleSet) revoke(did bitxid.DID, role Role) error {
+	for i, r := range rs[did] {
+		if r == role {
+			rs[did] = append(rs[did][:i], rs[did][i+1:]...)
+			if len(rs[did]) == 0 {
+				delete(rs, did)
+			}
+			return nil
+		}
+	}
+	return newError(ErrNotFound, "%s has no role %s", did, role)
+}
+
+// hasRole checks whether did is admin or has been granted the role.
+func (mr *ChainDIDRegistry) hasRole(did bitxid.DID, role Role) bool {
+	return mr.hasAdmin(did) || mr.Roles.has(did, role)
+}
+
+// hasRole checks whether did is admin or has been granted the role.
+func (dr *AccountDIDRegistry) hasRole(did bitxid.DID, role Role) bool {
+	return dr.hasAdmin(did) || dr.Roles.has(did, role)
+}
+
+func postRoleEvent(stub boltvm.Stub, registry, action string, did bitxid.DID, role Role, operator bitxid.DID) {
+	stub.PostEvent(&AdminEvent{
+		Registry: registry,
+		Action:   action,
+		Admin:    did,
+		Role:     role,
+		Operator: operator,
+		Height:   recordHeight(stub),
+	})
+}
+
+// GrantRole grants the role to did,
+// caller should be super admin.
+func (mm *ChainDIDManager) GrantRole(caller, did, role string) *boltvm.Response {
+	mr := mm.getChainDIDRegistry()
+
+	callerDID := bitxid.DID(caller)
+	if res := authorize(mm.Stub, mr.Initalized, caller, requireSuperAdmin(mr, callerDID)); res != nil {
+		return res
+	}
+	if !bitxid.DID(did).IsValidFormat() {
+		return errorResponse(ErrInvalidFormat, "grant role err, not valid did format")
+	}
+
+	if err := mr.Roles.grant(bitxid.DID(did), Role(role)); err != nil {
+		return wrapErrorResponse(ErrInvalidArgument, "grant role err, ", err)
+	}
+
+	mm.SetObject(ChainDIDRegistryKey, mr)
+	postRoleEvent(mm.Stub, chainDIDRegistryName, RoleGranted, bitxid.DID(did), Role(role), callerDID)
+	return boltvm.Success(nil)
+}
+
+// RevokeRole revokes the role from did,
+// caller should be super admin.
+func (mm *ChainDIDManager) RevokeRole(caller, did, role string) *boltvm.Response {
+	mr := mm.getChainDIDRegistry()
+
+	callerDID := bitxid.DID(caller)
+	if res := authorize(mm.Stub, mr.Initalized, caller, requireSuperAdmin(mr, callerDID)); res != nil {
+		return res
+	}
+
+	if err := mr.Roles.revoke(bitxid.DID(did), Role(role)); err != nil {
+		return wrapErrorResponse(ErrNotFound, "revoke role err, ", err)
+	}
+
+	mm.SetObject(ChainDIDRegistryKey, mr)
+	postRoleEvent(mm.Stub, chainDIDRegistryName, RoleRevoked, bitxid.DID(did), Role(role), callerDID)
+	return boltvm.Success(nil)
+}
+
+// GetRoles gets roles granted to did, roles implied by admin are not listed.
+func (mm *ChainDIDManager) GetRoles(did string) *boltvm.Response {
+	mr := mm.getChainDIDRegistry()
+
+	if res := checkInitialized(mr.Initalized); res != nil {
+		return res
+	}
+
+	return rolesResponse(mr.Roles[bitxid.DID(did)])
+}
+
+// HasRole querys whether did is admin or has been granted the role.
+func (mm *ChainDIDManager) HasRole(did, role string) *boltvm.Response {
+	mr := mm.getChainDIDRegistry()
+
+	if res := checkInitialized(mr.Initalized); res != nil {
+		return res
+	}
+
+	return boolResponse(mr.hasRole(bitxid.DID(did), Role(role)))
+}
+
+// GrantRole grants the role to did,
+// caller should be super admin.
+func (dm *AccountDIDManager) GrantRole(caller, did, role string) *boltvm.Response {
+	dr := dm.getAccountDIDRegistry()
+
+	callerDID := bitxid.DID(caller)
+	if res := dr.authorize(dm.Stub, caller, requireSuperAdmin(dr, callerDID)); res != nil {
+		return res
+	}
+	if !bitxid.DID(did).IsValidFormat() {
+		return errorResponse(ErrInvalidFormat, "grant role err, not valid did format")
+	}
+
+	if err := dr.Roles.grant(bitxid.DID(did), Role(role)); err != nil {
+		return wrapErrorResponse(ErrInvalidArgument, "grant role err, ", err)
+	}
+
+	dm.SetObject(AccountDIDRegistryKey, dr)
+	postRoleEvent(dm.Stub, accountDIDRegistryName, RoleGranted, bitxid.DID(did), Role(role), callerDID)
+	return boltvm.Success(nil)
+}
+
+// RevokeRole revokes the role from did,
+// caller should be super admin.
+func (dm *AccountDIDManager) RevokeRole(caller, did, role string) *boltvm.Response {
+	dr := dm.getAccountDIDRegistry()
+
+	callerDID := bitxid.DID(caller)
+	if res := dr.authorize(dm.Stub, caller, requireSuperAdmin(dr, callerDID)); res != nil {
+		return res
+	}
+
+	if err := dr.Roles.revoke(bitxid.DID(did), Role(role)); err != nil {
+		return wrapErrorResponse(ErrNotFound, "revoke role err, ", err)
+	}
+
+	dm.SetObject(AccountDIDRegistryKey, dr)
+	postRoleEvent(dm.Stub, accountDIDRegistryName, RoleRevoked, bitxid.DID(did), Role(role), callerDID)
+	return boltvm.Success(nil)
+}
+
+// GetRoles gets roles granted to did, roles implied by admin are not listed.
+func (dm *AccountDIDManager) GetRoles(did string) *boltvm.Response {
+	dr := dm.getAccountDIDRegistry()
+
+	if res := checkInitialized(dr.Initalized); res != nil {
+		return res
+	}
+
+	return rolesResponse(dr.Roles[bitxid.DID(did)])
+}
+
+// HasRole querys whether did is admin or has been granted the role.
+func (dm *AccountDIDManager) HasRole(did, role string) *boltvm.Response {
+	dr := dm.getAccountDIDRegistry()
+
+	if res := checkInitialized(dr.Initalized); res != nil {
+		return res
+	}
+
+	return boolResponse(dr.hasRole(bitxid.DID(did), Role(role)))
+}
+
+// registerMsg is signed by the owner of did to let a registrar register
+// the doc on its behalf, registry is name of the registry registering it.
+func registerMsg(registry string, did bitxid.DID, docAddr string, docHash []byte) []byte {
+	return []byte("Register:" + registry + ":" + string(did) + ":" + docAddr + ":" + hex.EncodeToString(docHash))
+}
+
+// RegisterFor anchors infomation for an account did on behalf of its owner,
+// caller should be registrar, sig should be made by the owner over registerMsg.
+func (dm *AccountDIDManager) RegisterFor(caller, did string, docAddr string, docHash []byte, sig []byte) *boltvm.Response {
+	dr := dm.getAccountDIDRegistry()
+
+	callerDID := bitxid.DID(caller)
+	if res := dr.authorize(dm.Stub, caller, requireRole(dr, callerDID, RoleRegistrar)); res != nil {
+		return res
+	}
+	didToRegister := bitxid.DID(did)
+	if !didToRegister.IsValidFormat() {
+		return errorResponse(ErrInvalidFormat, "register err, not valid did format")
+	}
+	if dr.SelfID != didToRegister.GetChainDID() {
+		return errorResponse(ErrNotOnThisChain, didNotOnThisChainError(did, string(dr.SelfID)))
+	}
+	if dr.deactivated(didToRegister) {
+		return errorResponse(ErrInvalidStatus, deactivatedError(did))
+	}
+	msg := registerMsg(accountDIDRegistryName, didToRegister, docAddr, docHash)
+	if res := requireAddrSig(dr.controllerOf(didToRegister), msg, sig)(); res != nil {
+		return res
+	}
+
+	_, _, err := dr.Registry.Register(didToRegister, docAddr, docHash)
+	if err != nil {
+		return wrapErrorResponse(ErrRegistryRejected, "register err, ", err)
+	}
+
+	dm.SetObject(AccountDIDRegistryKey, dr)
+	postDIDEvent(dm.Stub, accountDIDRegistryName, EventRegister, didToRegister, "", dr.statusOf(didToRegister), callerDID)
+	return boltvm.Success(nil)
+}
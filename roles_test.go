@@ -0,0 +1,306 @@
+package contracts
+
+import (
+	"testing"
+
+	"github.com/meshplus/bitxhub-core/boltvm"
+	"github.com/meshplus/bitxid"
+	"github.com/meshplus/did-registry/didpb"
+	"github.com/stretchr/testify/require"
+)
+
+func hasRole(t *testing.T, res *boltvm.Response) bool {
+	has := &didpb.Bool{}
+	decode(t, res, has)
+	return has.Value
+}
+
+func TestAccountDIDManager_GrantRole(t *testing.T) {
+	runCalls(t, []call{
+		{
+			name: "grant",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.GrantRole(e.admin.did, e.user.did, string(RoleFreezer))
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				roles := &didpb.StringSlice{}
+				decode(t, e.account.GetRoles(e.user.did), roles)
+				require.Equal(t, []string{string(RoleFreezer)}, roles.Slice)
+				require.True(t, hasRole(t, e.account.HasRole(e.user.did, string(RoleFreezer))))
+				require.False(t, hasRole(t, e.account.HasRole(e.user.did, string(RoleRegistrar))))
+				ev := lastAdminEvent(t, e.accountStub)
+				require.Equal(t, RoleGranted, ev.Action)
+				require.Equal(t, RoleFreezer, ev.Role)
+				require.Equal(t, bitxid.DID(e.user.did), ev.Admin)
+			},
+		},
+		{
+			name: "grant by regular admin",
+			run: func(e *testEnv) *boltvm.Response {
+				other := e.newAdmin()
+				return e.as(other).account.GrantRole(other.did, e.user.did, string(RoleFreezer))
+			},
+			code: ErrNotSuperAdmin,
+		},
+		{
+			name: "grant unknown role",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.GrantRole(e.admin.did, e.user.did, "unknown")
+			},
+			code: ErrInvalidArgument,
+		},
+		{
+			name: "grant to invalid did",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.GrantRole(e.admin.did, "did:bitxhub", string(RoleFreezer))
+			},
+			code: ErrInvalidFormat,
+		},
+		{
+			name:  "grant twice",
+			setup: func(e *testEnv) { grantAccountRole(e, RoleFreezer) },
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.GrantRole(e.admin.did, e.user.did, string(RoleFreezer))
+			},
+			code: ErrAlreadyExists,
+		},
+		{
+			name:  "revoke",
+			setup: func(e *testEnv) { grantAccountRole(e, RoleFreezer) },
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.RevokeRole(e.admin.did, e.user.did, string(RoleFreezer))
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.False(t, hasRole(t, e.account.HasRole(e.user.did, string(RoleFreezer))))
+				require.Equal(t, RoleRevoked, lastAdminEvent(t, e.accountStub).Action)
+				requireCode(t, e.as(e.user).account.Freeze(e.user.did, e.admin.did, nil), ErrNotAdmin)
+			},
+		},
+		{
+			name: "revoke not granted",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.RevokeRole(e.admin.did, e.user.did, string(RoleFreezer))
+			},
+			code: ErrNotFound,
+		},
+		{
+			name:  "revoke by regular admin",
+			setup: func(e *testEnv) { grantAccountRole(e, RoleFreezer) },
+			run: func(e *testEnv) *boltvm.Response {
+				other := e.newAdmin()
+				return e.as(other).account.RevokeRole(other.did, e.user.did, string(RoleFreezer))
+			},
+			code: ErrNotSuperAdmin,
+		},
+		{
+			name:  "revoke caller mismatch",
+			setup: func(e *testEnv) { grantAccountRole(e, RoleFreezer) },
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).account.RevokeRole(e.admin.did, e.user.did, string(RoleFreezer))
+			},
+			code: ErrCallerMismatch,
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.True(t, hasRole(t, e.account.HasRole(e.user.did, string(RoleFreezer))))
+			},
+		},
+		{
+			name: "grant caller mismatch",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).account.GrantRole(e.admin.did, e.user.did, string(RoleFreezer))
+			},
+			code: ErrCallerMismatch,
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.False(t, hasRole(t, e.account.HasRole(e.user.did, string(RoleFreezer))))
+			},
+		},
+		{
+			name: "admin has every role",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.account.HasRole(e.admin.did, string(RoleRegistrar))
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.True(t, hasRole(t, res))
+				roles := &didpb.StringSlice{}
+				decode(t, e.account.GetRoles(e.admin.did), roles)
+				require.Empty(t, roles.Slice)
+			},
+		},
+	})
+}
+
+func TestAccountDIDManager_RegisterFor(t *testing.T) {
+	var acc *testAccount
+	runCalls(t, []call{
+		{
+			name:  "by registrar",
+			setup: func(e *testEnv) { grantAccountRole(e, RoleRegistrar) },
+			run: func(e *testEnv) *boltvm.Response {
+				acc = newTestAccount(e.t)
+				return e.as(e.user).account.RegisterFor(e.user.did, acc.did, testDocAddr, []byte("hash"), acc.sign(e.t, registerMsg(accountDIDRegistryName, bitxid.DID(acc.did), testDocAddr, []byte("hash"))))
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, string(bitxid.Normal), resolveAccountDID(t, e, acc.did).Status)
+				ev := lastEvent(t, e.accountStub)
+				require.Equal(t, EventRegister, ev.Type)
+				require.Equal(t, bitxid.DID(acc.did), ev.DID)
+				require.Equal(t, bitxid.DID(e.user.did), ev.Actor)
+			},
+		},
+		{
+			name:  "without owner sig",
+			setup: func(e *testEnv) { grantAccountRole(e, RoleRegistrar) },
+			run: func(e *testEnv) *boltvm.Response {
+				acc = newTestAccount(e.t)
+				return e.as(e.user).account.RegisterFor(e.user.did, acc.did, testDocAddr, []byte("hash"), e.user.sign(e.t, registerMsg(accountDIDRegistryName, bitxid.DID(acc.did), testDocAddr, []byte("hash"))))
+			},
+			code: ErrSignatureInvalid,
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				requireCode(t, e.account.Resolve(acc.did), ErrNotFound)
+			},
+		},
+		{
+			name:  "with sig over other doc",
+			setup: func(e *testEnv) { grantAccountRole(e, RoleRegistrar) },
+			run: func(e *testEnv) *boltvm.Response {
+				acc = newTestAccount(e.t)
+				sig := acc.sign(e.t, registerMsg(accountDIDRegistryName, bitxid.DID(acc.did), "other", []byte("hash")))
+				return e.as(e.user).account.RegisterFor(e.user.did, acc.did, testDocAddr, []byte("hash"), sig)
+			},
+			code: ErrSignatureInvalid,
+		},
+		{
+			name:  "with sig for chain did registry",
+			setup: func(e *testEnv) { grantAccountRole(e, RoleRegistrar) },
+			run: func(e *testEnv) *boltvm.Response {
+				acc = newTestAccount(e.t)
+				sig := acc.sign(e.t, registerMsg(chainDIDRegistryName, bitxid.DID(acc.did), testDocAddr, []byte("hash")))
+				return e.as(e.user).account.RegisterFor(e.user.did, acc.did, testDocAddr, []byte("hash"), sig)
+			},
+			code: ErrSignatureInvalid,
+		},
+		{
+			name: "by others",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).account.RegisterFor(e.user.did, newTestAccount(e.t).did, testDocAddr, []byte("hash"), nil)
+			},
+			code: ErrNotAdmin,
+		},
+		{
+			name:  "caller mismatch",
+			setup: func(e *testEnv) { grantAccountRole(e, RoleRegistrar) },
+			run: func(e *testEnv) *boltvm.Response {
+				acc = newTestAccount(e.t)
+				return e.as(e.admin).account.RegisterFor(e.user.did, acc.did, testDocAddr, []byte("hash"), acc.sign(e.t, registerMsg(accountDIDRegistryName, bitxid.DID(acc.did), testDocAddr, []byte("hash"))))
+			},
+			code: ErrCallerMismatch,
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				requireCode(t, e.account.Resolve(acc.did), ErrNotFound)
+			},
+		},
+		{
+			name: "invalid did",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.RegisterFor(e.admin.did, "did:bitxhub", testDocAddr, []byte("hash"), nil)
+			},
+			code: ErrInvalidFormat,
+		},
+		{
+			name: "not on this chain",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.RegisterFor(e.admin.did, "did:bitxhub:appchain002:"+newTestAccount(e.t).addr, testDocAddr, []byte("hash"), nil)
+			},
+			code: ErrNotOnThisChain,
+		},
+		{
+			name: "registered",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.RegisterFor(e.admin.did, e.user.did, testDocAddr, []byte("hash"), e.user.sign(e.t, registerMsg(accountDIDRegistryName, bitxid.DID(e.user.did), testDocAddr, []byte("hash"))))
+			},
+			code: ErrRegistryRejected,
+		},
+	})
+}
+
+func TestChainDIDManager_Roles(t *testing.T) {
+	runCalls(t, []call{
+		{
+			name:  "auditor audits",
+			setup: func(e *testEnv) { applied(e); grantChainRole(e, RoleAuditor) },
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).chain.AuditApply(e.user.did, testAppChainDID, 1, nil)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, bitxid.ApplySuccess, chainDIDStatus(t, e, testAppChainDID))
+			},
+		},
+		{
+			name: "revoked auditor",
+			setup: func(e *testEnv) {
+				applied(e)
+				grantChainRole(e, RoleAuditor)
+				requireOK(e.t, e.as(e.admin).chain.RevokeRole(e.admin.did, e.user.did, string(RoleAuditor)))
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).chain.AuditApply(e.user.did, testAppChainDID, 1, nil)
+			},
+			code: ErrNotAdmin,
+		},
+		{
+			name: "grant by regular admin",
+			run: func(e *testEnv) *boltvm.Response {
+				other := e.newAdmin()
+				return e.as(other).chain.GrantRole(other.did, e.user.did, string(RoleAuditor))
+			},
+			code: ErrNotSuperAdmin,
+		},
+		{
+			name: "grant caller mismatch",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).chain.GrantRole(e.admin.did, e.user.did, string(RoleAuditor))
+			},
+			code: ErrCallerMismatch,
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.False(t, hasRole(t, e.chain.HasRole(e.user.did, string(RoleAuditor))))
+			},
+		},
+		{
+			name:  "revoke by regular admin",
+			setup: func(e *testEnv) { grantChainRole(e, RoleAuditor) },
+			run: func(e *testEnv) *boltvm.Response {
+				other := e.newAdmin()
+				return e.as(other).chain.RevokeRole(other.did, e.user.did, string(RoleAuditor))
+			},
+			code: ErrNotSuperAdmin,
+		},
+		{
+			name:  "revoke caller mismatch",
+			setup: func(e *testEnv) { grantChainRole(e, RoleAuditor) },
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).chain.RevokeRole(e.admin.did, e.user.did, string(RoleAuditor))
+			},
+			code: ErrCallerMismatch,
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.True(t, hasRole(t, e.chain.HasRole(e.user.did, string(RoleAuditor))))
+			},
+		},
+		{
+			name: "revoke not granted",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).chain.RevokeRole(e.admin.did, e.user.did, string(RoleAuditor))
+			},
+			code: ErrNotFound,
+		},
+		{
+			name:  "get roles",
+			setup: func(e *testEnv) { grantChainRole(e, RoleAuditor); grantChainRole(e, RoleConverterManager) },
+			run:   func(e *testEnv) *boltvm.Response { return e.chain.GetRoles(e.user.did) },
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				roles := &didpb.StringSlice{}
+				decode(t, res, roles)
+				require.Equal(t, []string{string(RoleAuditor), string(RoleConverterManager)}, roles.Slice)
+				require.True(t, hasRole(t, e.chain.HasRole(e.user.did, string(RoleConverterManager))))
+				require.False(t, hasRole(t, e.chain.HasRole(e.user.did, string(RoleFreezer))))
+			},
+		},
+	})
+}
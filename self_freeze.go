@@ -0,0 +1,252 @@
+package contracts
+
+import (
+	"strconv"
+
+	"github.com/meshplus/bitxhub-core/boltvm"
+	"github.com/meshplus/bitxid"
+)
+
+// recoveryStrategy marks an authentication of did doc whose keys are
+// recovery keys, which are kept offline and only used to recover the did.
+const recoveryStrategy = "recovery"
+
+// SelfFreeze is the self freeze state of a did.
+// @Frozen: the did is currently frozen by its owner
+// @Nonce: number of self freezes so far, signed by the recovery key to
+// unfreeze, so that an old unfreeze proof can not be replayed
+type SelfFreeze struct {
+	Frozen bool
+	Nonce  uint64
+}
+
+// SelfFreezes maps a did to its self freeze state.
+type SelfFreezes map[bitxid.DID]SelfFreeze
+
+func (sf *SelfFreezes) freeze(did bitxid.DID) {
+	if *sf == nil {
+		*sf = make(SelfFreezes)
+	}
+	s := (*sf)[did]
+	s.Frozen = true
+	s.Nonce++
+	(*sf)[did] = s
+}
+
+// unfreeze clears the frozen flag, called on any unfreeze of the did.
+func (sf SelfFreezes) unfreeze(did bitxid.DID) {
+	if s, ok := sf[did]; ok {
+		s.Frozen = false
+		sf[did] = s
+	}
+}
+
+// selfFreezeMsg is signed by a key of the did doc allowed to freeze did.
+func selfFreezeMsg(did bitxid.DID) []byte {
+	return []byte("SelfFreeze:" + string(did))
+}
+
+// selfUnFreezeMsg is signed by a recovery key of the did doc to unfreeze did.
+func selfUnFreezeMsg(did bitxid.DID, nonce uint64) []byte {
+	return []byte("SelfUnFreeze:" + string(did) + ":" + strconv.FormatUint(nonce, 10))
+}
+
+// recoveryKeys returns doc with only its recovery keys.
+func recoveryKeys(doc *bitxid.BasicDoc) *bitxid.BasicDoc {
+	ids := make(map[string]bool)
+	for _, auth := range doc.Authentication {
+		if auth.Strategy != recoveryStrategy {
+			continue
+		}
+		for _, id := range auth.PublicKey {
+			ids[id] = true
+		}
+	}
+	res := &bitxid.BasicDoc{ID: doc.ID, Type: doc.Type}
+	for _, pk := range doc.PublicKey {
+		if ids[pk.ID] {
+			res.PublicKey = append(res.PublicKey, pk)
+		}
+	}
+	return res
+}
+
+// verifyFreezeSig checks sig over msg against the doc anchored for did,
+// retired keys are rejected, only recovery keys of the doc are accepted if recovery,
+// otherwise only recovery keys and the key behind controller if it's not empty.
+func verifyFreezeSig(did bitxid.DID, docHash, docb, msg, sig []byte, recovery bool, controller string, retired []string) error {
+	doc, err := loadDoc(did, docb, docHash)
+	if err != nil {
+		return err
+	}
+	if len(retired) != 0 {
+		doc = dropKeys(doc, retired)
+	}
+	if !recovery && controller != "" {
+		keys := recoveryKeys(doc)
+		keys.PublicKey = append(keys.PublicKey, keepKeys(doc, []string{controller}).PublicKey...)
+		doc = keys
+	}
+	if recovery {
+		doc = recoveryKeys(doc)
+		if len(doc.PublicKey) == 0 {
+			return newError(ErrInvalidArgument, "doc of %s has no recovery key, ask admin to unfreeze", did)
+		}
+	}
+	_, err = verifyDocSig(doc, msg, sig)
+	return err
+}
+
+// checkSelfFrozen checks the did is frozen by its owner, not by admin.
+func checkSelfFrozen(sf SelfFreezes, did bitxid.DID, status bitxid.StatusType) error {
+	if status != bitxid.Frozen {
+		return newError(ErrNotFrozen, "%s was not frozen", did)
+	}
+	if !sf[did].Frozen {
+		return newError(ErrInvalidStatus, "%s was frozen by admin, ask admin to unfreeze", did)
+	}
+	return nil
+}
+
+// SelfFreeze freezes the chainDID in an emergency, e.g. a key leaked,
+// caller should be owner of the chainDID,
+// only a recovery key or admin can unfreeze it then.
+// @docb: doc of the chainDID, its hash should match the anchored one
+// @sig: signature over "SelfFreeze:<chainDID>" by any key of the doc
+func (mm *ChainDIDManager) SelfFreeze(caller, chainDID string, docb, sig []byte) *boltvm.Response {
+	mr := mm.getChainDIDRegistry()
+
+	callerDID := bitxid.DID(caller)
+	did := bitxid.DID(chainDID)
+	if res := authorize(mm.Stub, mr.Initalized, caller); res != nil {
+		return res
+	}
+
+	item, err := mr.resolveItem(did)
+	if err != nil {
+		return wrapErrorResponse(ErrRegistryRejected, "self freeze err, ", err)
+	}
+	if item.Owner != callerDID {
+		return errorResponse(ErrNotOwner, "caller("+caller+") is not the owner of "+chainDID)
+	}
+	if err := verifyFreezeSig(did, item.DocHash, docb, selfFreezeMsg(did), sig, false, "", nil); err != nil {
+		return wrapErrorResponse(ErrSignatureInvalid, "self freeze err, ", err)
+	}
+
+	oldStatus := item.Status
+	if err := mr.freeze(did); err != nil {
+		return wrapErrorResponse(ErrRegistryRejected, "self freeze err, ", err)
+	}
+	mr.SelfFreezes.freeze(did)
+
+	mm.SetObject(ChainDIDRegistryKey, mr)
+	postDIDEvent(mm.Stub, chainDIDRegistryName, EventSelfFreeze, did, oldStatus, mr.statusOf(did), callerDID)
+	return boltvm.Success(nil)
+}
+
+// SelfUnFreeze unfreezes the chainDID frozen by SelfFreeze,
+// caller should be owner of the chainDID, a chainDID frozen by admin
+// can only be unfrozen by admin.
+// @docb: doc of the chainDID, its hash should match the anchored one
+// @sig: signature over "SelfUnFreeze:<chainDID>:<nonce>" by a recovery key of the doc,
+// nonce is the number of self freezes of the chainDID
+func (mm *ChainDIDManager) SelfUnFreeze(caller, chainDID string, docb, sig []byte) *boltvm.Response {
+	mr := mm.getChainDIDRegistry()
+
+	callerDID := bitxid.DID(caller)
+	did := bitxid.DID(chainDID)
+	if res := authorize(mm.Stub, mr.Initalized, caller); res != nil {
+		return res
+	}
+
+	item, err := mr.resolveItem(did)
+	if err != nil {
+		return wrapErrorResponse(ErrRegistryRejected, "self unfreeze err, ", err)
+	}
+	if item.Owner != callerDID {
+		return errorResponse(ErrNotOwner, "caller("+caller+") is not the owner of "+chainDID)
+	}
+	if err := checkSelfFrozen(mr.SelfFreezes, did, item.Status); err != nil {
+		return wrapErrorResponse(ErrInvalidStatus, "self unfreeze err, ", err)
+	}
+	msg := selfUnFreezeMsg(did, mr.SelfFreezes[did].Nonce)
+	if err := verifyFreezeSig(did, item.DocHash, docb, msg, sig, true, "", nil); err != nil {
+		return wrapErrorResponse(ErrSignatureInvalid, "self unfreeze err, ", err)
+	}
+
+	oldStatus := item.Status
+	if err := mr.unfreeze(did); err != nil {
+		return wrapErrorResponse(ErrRegistryRejected, "self unfreeze err, ", err)
+	}
+
+	mm.SetObject(ChainDIDRegistryKey, mr)
+	postDIDEvent(mm.Stub, chainDIDRegistryName, EventSelfUnFreeze, did, oldStatus, mr.statusOf(did), callerDID)
+	return boltvm.Success(nil)
+}
+
+// SelfFreeze freezes the did of caller in an emergency, e.g. a key leaked,
+// only a recovery key or admin can unfreeze it then.
+// @docb: doc of the did, its hash should match the anchored one
+// @sig: signature over "SelfFreeze:<caller>" by the key of the doc controlling the did
+// or a recovery key of the doc
+func (dm *AccountDIDManager) SelfFreeze(caller string, docb, sig []byte) *boltvm.Response {
+	dr := dm.getAccountDIDRegistry()
+
+	callerDID := bitxid.DID(caller)
+	if res := dr.authorize(dm.Stub, caller); res != nil {
+		return res
+	}
+
+	item, _, exist, err := dr.Registry.Resolve(callerDID)
+	if err != nil || !exist {
+		return errorResponse(ErrNotFound, "self freeze err, did "+caller+" not existed")
+	}
+	if err := verifyFreezeSig(callerDID, item.DocHash, docb, selfFreezeMsg(callerDID), sig, false, dr.controllerOf(callerDID), dr.KeyHistories.retired(callerDID)); err != nil {
+		return wrapErrorResponse(ErrSignatureInvalid, "self freeze err, ", err)
+	}
+
+	oldStatus := item.Status
+	if err := dr.freeze(callerDID); err != nil {
+		return wrapErrorResponse(ErrRegistryRejected, "self freeze err, ", err)
+	}
+	dr.SelfFreezes.freeze(callerDID)
+
+	dm.SetObject(AccountDIDRegistryKey, dr)
+	postDIDEvent(dm.Stub, accountDIDRegistryName, EventSelfFreeze, callerDID, oldStatus, dr.statusOf(callerDID), callerDID)
+	return boltvm.Success(nil)
+}
+
+// SelfUnFreeze unfreezes the did of caller frozen by SelfFreeze,
+// a did frozen by admin can only be unfrozen by admin.
+// @docb: doc of the did, its hash should match the anchored one
+// @sig: signature over "SelfUnFreeze:<caller>:<nonce>" by a recovery key of the doc,
+// nonce is the number of self freezes of the did
+func (dm *AccountDIDManager) SelfUnFreeze(caller string, docb, sig []byte) *boltvm.Response {
+	dr := dm.getAccountDIDRegistry()
+
+	callerDID := bitxid.DID(caller)
+	if res := dr.authorize(dm.Stub, caller); res != nil {
+		return res
+	}
+
+	item, _, exist, err := dr.Registry.Resolve(callerDID)
+	if err != nil || !exist {
+		return errorResponse(ErrNotFound, "self unfreeze err, did "+caller+" not existed")
+	}
+	if err := checkSelfFrozen(dr.SelfFreezes, callerDID, item.Status); err != nil {
+		return wrapErrorResponse(ErrInvalidStatus, "self unfreeze err, ", err)
+	}
+	msg := selfUnFreezeMsg(callerDID, dr.SelfFreezes[callerDID].Nonce)
+	if err := verifyFreezeSig(callerDID, item.DocHash, docb, msg, sig, true, "", dr.KeyHistories.retired(callerDID)); err != nil {
+		return wrapErrorResponse(ErrSignatureInvalid, "self unfreeze err, ", err)
+	}
+
+	oldStatus := item.Status
+	if err := dr.unfreeze(callerDID); err != nil {
+		return wrapErrorResponse(ErrRegistryRejected, "self unfreeze err, ", err)
+	}
+
+	dm.SetObject(AccountDIDRegistryKey, dr)
+	postDIDEvent(dm.Stub, accountDIDRegistryName, EventSelfUnFreeze, callerDID, oldStatus, dr.statusOf(callerDID), callerDID)
+	return boltvm.Success(nil)
+}
@@ -0,0 +1,277 @@
+package contracts
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/meshplus/bitxhub-core/boltvm"
+	"github.com/meshplus/bitxid"
+	"github.com/stretchr/testify/require"
+)
+
+// docOf returns doc of did listing key of signer as key-1,
+// and key of recovery as recovery key key-2 if not nil, with its hash.
+func docOf(t *testing.T, did string, signer, recovery *testAccount) ([]byte, []byte) {
+	pubKey := func(acc *testAccount, id string) bitxid.PubKey {
+		pub, err := acc.key.PublicKey().Bytes()
+		require.Nil(t, err)
+		return bitxid.PubKey{ID: did + id, Type: "Secp256k1", PublicKeyPem: hex.EncodeToString(pub)}
+	}
+	basic := bitxid.BasicDoc{
+		ID:        bitxid.DID(did),
+		Type:      bitxid.DID(did).GetType(),
+		PublicKey: []bitxid.PubKey{pubKey(signer, "#key-1")},
+	}
+	if recovery != nil {
+		basic.PublicKey = append(basic.PublicKey, pubKey(recovery, "#key-2"))
+		basic.Authentication = []bitxid.Auth{{PublicKey: []string{did + "#key-2"}, Strategy: recoveryStrategy}}
+	}
+
+	var docb []byte
+	var err error
+	if basic.Type == int(bitxid.ChainDIDType) {
+		docb, err = bitxid.MarshalChainDoc(bitxid.ChainDoc{BasicDoc: basic})
+	} else {
+		docb, err = bitxid.MarshalAccountDoc(bitxid.AccountDoc{BasicDoc: basic})
+	}
+	require.Nil(t, err)
+	hash := sha256.Sum256(docb)
+	return docb, hash[:]
+}
+
+// withRecoveryKey anchors a user doc with a recovery key, returns the recovery key.
+func withRecoveryKey(e *testEnv) *testAccount {
+	recovery := newTestAccount(e.t)
+	docb, hash := docOf(e.t, e.user.did, e.user, recovery)
+	requireOK(e.t, e.as(e.user).account.Update(e.user.did, testDocAddr, hash, nil))
+	e.userDoc = docb
+	return recovery
+}
+
+// selfFreezeUser freezes user by itself, signed by signer.
+func selfFreezeUser(e *testEnv, signer *testAccount) *boltvm.Response {
+	sig := signer.sign(e.t, selfFreezeMsg(bitxid.DID(e.user.did)))
+	return e.asUser().account.SelfFreeze(e.user.did, e.userDoc, sig)
+}
+
+// selfUnFreezeUser unfreezes user by itself, signed by signer for nonce.
+func selfUnFreezeUser(e *testEnv, signer *testAccount, nonce uint64) *boltvm.Response {
+	sig := signer.sign(e.t, selfUnFreezeMsg(bitxid.DID(e.user.did), nonce))
+	return e.as(e.user).account.SelfUnFreeze(e.user.did, e.userDoc, sig)
+}
+
+func TestAccountDIDManager_SelfFreeze(t *testing.T) {
+	userStatus := func(e *testEnv) string { return resolveAccountDID(e.t, e, e.user.did).Status }
+	runCalls(t, []call{
+		{
+			name: "success",
+			run:  func(e *testEnv) *boltvm.Response { return selfFreezeUser(e, e.user) },
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, string(bitxid.Frozen), userStatus(e))
+				ev := lastEvent(t, e.accountStub)
+				require.Equal(t, EventSelfFreeze, ev.Type)
+				require.Equal(t, bitxid.DID(e.user.did), ev.Actor)
+			},
+		},
+		{
+			name: "by recovery key",
+			run: func(e *testEnv) *boltvm.Response {
+				return selfFreezeUser(e, withRecoveryKey(e))
+			},
+		},
+		{
+			name: "key not in doc",
+			run:  func(e *testEnv) *boltvm.Response { return selfFreezeUser(e, e.admin) },
+			code: ErrSignatureInvalid,
+		},
+		{
+			name: "key added without rotation",
+			run: func(e *testEnv) *boltvm.Response {
+				other := newTestAccount(e.t)
+				docb, hash := docOf(e.t, e.user.did, other, nil)
+				requireOK(e.t, e.asUser().account.Update(e.user.did, testDocAddr, hash, nil))
+				e.userDoc = docb
+				return selfFreezeUser(e, other)
+			},
+			code: ErrSignatureInvalid,
+		},
+		{
+			name: "doc not match",
+			run: func(e *testEnv) *boltvm.Response {
+				e.userDoc, _ = docOf(e.t, e.user.did, e.user, e.admin)
+				return selfFreezeUser(e, e.user)
+			},
+			code: ErrInvalidArgument,
+		},
+		{
+			name:  "frozen by admin",
+			setup: userFrozen,
+			run:   func(e *testEnv) *boltvm.Response { return selfFreezeUser(e, e.user) },
+			code:  ErrAlreadyFrozen,
+		},
+		{
+			name: "unfreeze",
+			run: func(e *testEnv) *boltvm.Response {
+				recovery := withRecoveryKey(e)
+				requireOK(e.t, selfFreezeUser(e, e.user))
+				return selfUnFreezeUser(e, recovery, 1)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, string(bitxid.Normal), userStatus(e))
+				require.Equal(t, EventSelfUnFreeze, lastEvent(t, e.accountStub).Type)
+			},
+		},
+		{
+			name: "unfreeze by leaked key",
+			run: func(e *testEnv) *boltvm.Response {
+				withRecoveryKey(e)
+				requireOK(e.t, selfFreezeUser(e, e.user))
+				return selfUnFreezeUser(e, e.user, 1)
+			},
+			code: ErrSignatureInvalid,
+		},
+		{
+			name: "unfreeze without recovery key",
+			run: func(e *testEnv) *boltvm.Response {
+				requireOK(e.t, selfFreezeUser(e, e.user))
+				return selfUnFreezeUser(e, e.user, 1)
+			},
+			code: ErrInvalidArgument,
+		},
+		{
+			name: "unfreeze replayed",
+			run: func(e *testEnv) *boltvm.Response {
+				recovery := withRecoveryKey(e)
+				requireOK(e.t, selfFreezeUser(e, e.user))
+				old := recovery.sign(e.t, selfUnFreezeMsg(bitxid.DID(e.user.did), 1))
+				requireOK(e.t, e.as(e.user).account.SelfUnFreeze(e.user.did, e.userDoc, old))
+				requireOK(e.t, selfFreezeUser(e, e.user))
+				return e.as(e.user).account.SelfUnFreeze(e.user.did, e.userDoc, old)
+			},
+			code: ErrSignatureInvalid,
+		},
+		{
+			name: "unfreeze frozen by admin",
+			run: func(e *testEnv) *boltvm.Response {
+				recovery := withRecoveryKey(e)
+				userFrozen(e)
+				return selfUnFreezeUser(e, recovery, 0)
+			},
+			code: ErrInvalidStatus,
+		},
+		{
+			name: "unfreeze not frozen",
+			run: func(e *testEnv) *boltvm.Response {
+				return selfUnFreezeUser(e, withRecoveryKey(e), 0)
+			},
+			code: ErrNotFrozen,
+		},
+		{
+			name: "unfrozen by admin",
+			run: func(e *testEnv) *boltvm.Response {
+				recovery := withRecoveryKey(e)
+				requireOK(e.t, selfFreezeUser(e, e.user))
+				requireOK(e.t, e.as(e.admin).account.UnFreeze(e.admin.did, e.user.did, nil))
+				// frozen by admin afterwards, the owner can't unfreeze it
+				userFrozen(e)
+				return selfUnFreezeUser(e, recovery, 1)
+			},
+			code: ErrInvalidStatus,
+		},
+		{
+			name: "not existed",
+			run: func(e *testEnv) *boltvm.Response {
+				acc := newTestAccount(e.t)
+				return e.as(acc).account.SelfFreeze(acc.did, nil, nil)
+			},
+			code: ErrNotFound,
+		},
+	})
+}
+
+func TestChainDIDManager_SelfFreeze(t *testing.T) {
+	// anchored registers testAppChainDID owned by user with a doc
+	// listing user key and a recovery key, returns the doc and recovery key.
+	anchored := func(e *testEnv) ([]byte, *testAccount) {
+		registered(e)
+		recovery := newTestAccount(e.t)
+		docb, hash := docOf(e.t, testAppChainDID, e.user, recovery)
+		requireOK(e.t, e.as(e.user).chain.Update(e.user.did, testAppChainDID, testDocAddr, hash, nil))
+		return docb, recovery
+	}
+	selfFreeze := func(e *testEnv, caller, signer *testAccount, docb []byte) *boltvm.Response {
+		sig := signer.sign(e.t, selfFreezeMsg(testAppChainDID))
+		return e.as(caller).chain.SelfFreeze(caller.did, testAppChainDID, docb, sig)
+	}
+	selfUnFreeze := func(e *testEnv, signer *testAccount, docb []byte, nonce uint64) *boltvm.Response {
+		sig := signer.sign(e.t, selfUnFreezeMsg(testAppChainDID, nonce))
+		return e.as(e.user).chain.SelfUnFreeze(e.user.did, testAppChainDID, docb, sig)
+	}
+	runCalls(t, []call{
+		{
+			name: "success",
+			run: func(e *testEnv) *boltvm.Response {
+				docb, _ := anchored(e)
+				return selfFreeze(e, e.user, e.user, docb)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, bitxid.Frozen, chainDIDStatus(t, e, testAppChainDID))
+				require.Equal(t, EventSelfFreeze, lastEvent(t, e.chainStub).Type)
+			},
+		},
+		{
+			name: "by others",
+			run: func(e *testEnv) *boltvm.Response {
+				docb, _ := anchored(e)
+				return selfFreeze(e, e.admin, e.user, docb)
+			},
+			code: ErrNotOwner,
+		},
+		{
+			name: "key not in doc",
+			run: func(e *testEnv) *boltvm.Response {
+				docb, _ := anchored(e)
+				return selfFreeze(e, e.user, e.admin, docb)
+			},
+			code: ErrSignatureInvalid,
+		},
+		{
+			name: "unfreeze",
+			run: func(e *testEnv) *boltvm.Response {
+				docb, recovery := anchored(e)
+				requireOK(e.t, selfFreeze(e, e.user, e.user, docb))
+				return selfUnFreeze(e, recovery, docb, 1)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, bitxid.Normal, chainDIDStatus(t, e, testAppChainDID))
+				require.Equal(t, EventSelfUnFreeze, lastEvent(t, e.chainStub).Type)
+			},
+		},
+		{
+			name: "unfreeze by leaked key",
+			run: func(e *testEnv) *boltvm.Response {
+				docb, _ := anchored(e)
+				requireOK(e.t, selfFreeze(e, e.user, e.user, docb))
+				return selfUnFreeze(e, e.user, docb, 1)
+			},
+			code: ErrSignatureInvalid,
+		},
+		{
+			name: "unfreeze frozen by admin",
+			run: func(e *testEnv) *boltvm.Response {
+				docb, recovery := anchored(e)
+				requireOK(e.t, e.as(e.admin).chain.Freeze(e.admin.did, testAppChainDID, nil))
+				return selfUnFreeze(e, recovery, docb, 0)
+			},
+			code: ErrInvalidStatus,
+		},
+		{
+			name: "not existed",
+			run: func(e *testEnv) *boltvm.Response {
+				return selfFreeze(e, e.user, e.user, nil)
+			},
+			code: ErrNotFound,
+		},
+	})
+}
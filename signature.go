@@ -0,0 +1,174 @@
+package contracts
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"github.com/meshplus/bitxhub-kit/crypto"
+	"github.com/meshplus/bitxhub-kit/crypto/asym"
+	"github.com/meshplus/bitxhub-kit/crypto/asym/ecdsa"
+	"github.com/meshplus/bitxhub-kit/types"
+	"github.com/meshplus/bitxid"
+)
+
+// keyTypes maps public key type names used in did docs to key types.
+var keyTypes = map[string]crypto.KeyType{
+	"Secp256k1": crypto.Secp256k1,
+	"ECDSAP256": crypto.ECDSA_P256,
+	"ECDSAP384": crypto.ECDSA_P384,
+	"ECDSAP521": crypto.ECDSA_P521,
+}
+
+// parsePubKey decodes a doc public key, PublicKeyPem is either
+// a pem block or hex encoded key bytes.
+func parsePubKey(pk bitxid.PubKey) (crypto.PublicKey, crypto.KeyType, error) {
+	typ, ok := keyTypes[pk.Type]
+	if !ok {
+		return nil, 0, fmt.Errorf("unsupported key type %s", pk.Type)
+	}
+	var raw []byte
+	if block, _ := pem.Decode([]byte(pk.PublicKeyPem)); block != nil {
+		raw = block.Bytes
+	} else {
+		b, err := hex.DecodeString(pk.PublicKeyPem)
+		if err != nil {
+			return nil, 0, fmt.Errorf("decode key %s: %w", pk.ID, err)
+		}
+		raw = b
+	}
+	pub, err := ecdsa.UnmarshalPublicKey(raw, typ)
+	if err != nil {
+		return nil, 0, fmt.Errorf("unmarshal key %s: %w", pk.ID, err)
+	}
+	return pub, typ, nil
+}
+
+// keyAddress returns address of the doc key.
+func keyAddress(pk bitxid.PubKey) (string, error) {
+	pub, _, err := parsePubKey(pk)
+	if err != nil {
+		return "", newError(ErrInvalidFormat, "%v", err)
+	}
+	addr, err := pub.Address()
+	if err != nil {
+		return "", newError(ErrInvalidFormat, "address of key %s: %v", pk.ID, err)
+	}
+	return addr.String(), nil
+}
+
+// filterKeys returns a copy of doc with keys whose address is in addrs if keep,
+// or with the other keys if not, keys not parsed are dropped either way.
+func filterKeys(doc *bitxid.BasicDoc, addrs []string, keep bool) *bitxid.BasicDoc {
+	res := *doc
+	res.PublicKey = nil
+	for _, pk := range doc.PublicKey {
+		addr, err := keyAddress(pk)
+		if err != nil {
+			continue
+		}
+		listed := false
+		for _, a := range addrs {
+			if strings.EqualFold(a, addr) {
+				listed = true
+				break
+			}
+		}
+		if listed == keep {
+			res.PublicKey = append(res.PublicKey, pk)
+		}
+	}
+	return &res
+}
+
+// keepKeys returns a copy of doc with only keys whose address is in addrs.
+func keepKeys(doc *bitxid.BasicDoc, addrs []string) *bitxid.BasicDoc {
+	return filterKeys(doc, addrs, true)
+}
+
+// dropKeys returns a copy of doc without keys whose address is in addrs,
+// used to reject retired keys.
+func dropKeys(doc *bitxid.BasicDoc, addrs []string) *bitxid.BasicDoc {
+	return filterKeys(doc, addrs, false)
+}
+
+// verifyDocSig checks sig over msg against public keys listed in the doc,
+// returns id of the key which made the signature.
+func verifyDocSig(doc *bitxid.BasicDoc, msg, sig []byte) (string, error) {
+	digest := sha256.Sum256(msg)
+	for _, pk := range doc.PublicKey {
+		pub, typ, err := parsePubKey(pk)
+		if err != nil {
+			continue
+		}
+		addr, err := pub.Address()
+		if err != nil {
+			continue
+		}
+		if ok, _ := asym.Verify(typ, sig, digest[:], *addr); ok {
+			return pk.ID, nil
+		}
+	}
+	return "", newError(ErrSignatureInvalid, "signature not made by any key of %s", doc.ID)
+}
+
+// verifyAddrSig checks sig over msg was made by the key behind addr.
+func verifyAddrSig(addr string, msg, sig []byte) error {
+	digest := sha256.Sum256(msg)
+	var typ crypto.KeyType = crypto.ECDSA_P256
+	if len(sig) == 65 {
+		typ = crypto.Secp256k1
+	}
+	from := types.NewAddressByStr(addr)
+	if from == nil {
+		return newError(ErrInvalidFormat, "invalid address %s", addr)
+	}
+	ok, err := asym.Verify(typ, sig, digest[:], *from)
+	if err != nil {
+		return newError(ErrSignatureInvalid, "verify sig: %v", err)
+	}
+	if !ok {
+		return newError(ErrSignatureInvalid, "invalid signature")
+	}
+	return nil
+}
+
+// loadDoc checks docb against the anchored doc hash and unmarshals it
+// according to the did type.
+func loadDoc(did bitxid.DID, docb []byte, docHash []byte) (*bitxid.BasicDoc, error) {
+	hash := sha256.Sum256(docb)
+	if !bytes.Equal(hash[:], docHash) {
+		return nil, newError(ErrInvalidArgument, "doc hash of %s not match", did)
+	}
+	if did.GetType() == int(bitxid.ChainDIDType) {
+		doc, err := bitxid.UnmarshalChainDoc(docb)
+		if err != nil {
+			return nil, newError(ErrInvalidFormat, "%v", err)
+		}
+		if doc.ID != did {
+			return nil, newError(ErrInvalidArgument, "%s", docIDNotMatchDIDError(string(doc.ID), string(did)))
+		}
+		return &doc.BasicDoc, nil
+	}
+	doc, err := bitxid.UnmarshalAccountDoc(docb)
+	if err != nil {
+		return nil, newError(ErrInvalidFormat, "%v", err)
+	}
+	if doc.ID != did {
+		return nil, newError(ErrInvalidArgument, "%s", docIDNotMatchDidError(string(doc.ID), string(did)))
+	}
+	return &doc.BasicDoc, nil
+}
+
+// decodeSig decodes base64 content of a credential signature.
+func decodeSig(s bitxid.Sig) ([]byte, error) {
+	sig, err := base64.StdEncoding.DecodeString(s.Content)
+	if err != nil {
+		return nil, newError(ErrInvalidFormat, "decode signature: %v", err)
+	}
+	return sig, nil
+}
@@ -0,0 +1,293 @@
+// Package simulation runs chain did registries of several relay chains on
+// in-memory stubs and routes IBTPs recorded by them, so that parent/child
+// synchronization can be tested without deploying relay chains.
+//
+// A registry records IBTPs to its children through the inter-relay broker
+// when a chain did is registered, the network queues them and delivers
+// each one by calling Synchronize of the destination registry:
+//
+//	n := simulation.NewNetwork(1, simulation.Faults{Drop: 0.2})
+//	root, _ := n.AddNode("did:bitxhub:relayroot:.")
+//	child, _ := n.AddNode("did:bitxhub:relay1:.")
+//	n.Link(root.ChainDID, child.ChainDID)
+//	root.RegisterChainDID("did:bitxhub:appchain1:.")
+//	n.Run(100)
+//	err := n.Converged(root.ChainDID, "did:bitxhub:appchain1:.")
+//
+// Registries are initialized without genesis config.
+package simulation
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/meshplus/bitxhub-core/boltvm"
+	"github.com/meshplus/bitxhub-model/constant"
+	"github.com/meshplus/bitxhub-model/pb"
+	contracts "github.com/meshplus/did-registry"
+	"github.com/meshplus/did-registry/stubtest"
+)
+
+// adminMethodKey is where the node genesis puts address of the chain did registry admin.
+const adminMethodKey = "admin-method"
+
+// Faults configures faults injected into IBTP delivery, every rate is
+// a probability in [0, 1].
+// @Drop: a delivery attempt is lost, the IBTP is retried later as the relay
+// retransmits IBTPs not yet received
+// @Reorder: the next IBTP is picked at random instead of in recording order
+// @Duplicate: a delivered IBTP is queued again
+type Faults struct {
+	Drop      float64
+	Reorder   float64
+	Duplicate float64
+}
+
+// Delivery records a delivery attempt of an IBTP.
+// @Duplicate: the IBTP is a copy injected by the Duplicate fault
+// @Dropped: the attempt is lost by the Drop fault, Response is nil
+// @Response: response of the destination registry
+type Delivery struct {
+	IBTP      *pb.IBTP
+	Duplicate bool
+	Dropped   bool
+	Response  *boltvm.Response
+}
+
+// Node is a relay chain running a chain did registry.
+// @Admin: admin did of the registry, also owner of chain dids registered by RegisterChainDID
+type Node struct {
+	ChainDID string
+	Admin    string
+	Stub     *stubtest.Stub
+	Registry *contracts.ChainDIDManager
+}
+
+// envelope is an IBTP waiting for delivery.
+type envelope struct {
+	ibtp      *pb.IBTP
+	duplicate bool
+}
+
+// Network routes IBTPs between nodes, it's deterministic given the seed.
+type Network struct {
+	faults     Faults
+	rand       *rand.Rand
+	nodes      map[string]*Node
+	children   map[string][]string
+	pending    []*envelope
+	deliveries []*Delivery
+	height     uint64
+}
+
+// NewNetwork creates an empty network injecting faults with the seed.
+func NewNetwork(seed int64, faults Faults) *Network {
+	return &Network{
+		faults:   faults,
+		rand:     rand.New(rand.NewSource(seed)),
+		nodes:    make(map[string]*Node),
+		children: make(map[string][]string),
+		height:   1,
+	}
+}
+
+// AddNode deploys and initializes a chain did registry whose self chain did is chainDID.
+func (n *Network) AddNode(chainDID string) (*Node, error) {
+	if _, ok := n.nodes[chainDID]; ok {
+		return nil, fmt.Errorf("node %s already existed", chainDID)
+	}
+	if len(chainDID) < 2 || chainDID[len(chainDID)-2:] != ":." {
+		return nil, fmt.Errorf("%s is not a chain did", chainDID)
+	}
+
+	addr := fmt.Sprintf("0x%040x", len(n.nodes)+1)
+	node := &Node{
+		ChainDID: chainDID,
+		Admin:    chainDID[:len(chainDID)-1] + addr,
+		Stub:     stubtest.New(constant.MethodRegistryContractAddr.String()),
+	}
+	node.Registry = &contracts.ChainDIDManager{Stub: node.Stub}
+	node.Stub.SetObject(adminMethodKey, addr)
+	node.Stub.SetCaller(addr)
+	node.Stub.SetBlock(n.height, n.timestamp())
+	node.Stub.Handle(constant.InterRelayBrokerContractAddr.String(), n.broker(node))
+
+	if res := node.Registry.Init(node.Admin); !res.Ok {
+		return nil, fmt.Errorf("init %s: %s", chainDID, res.Result)
+	}
+	n.nodes[chainDID] = node
+	return node, nil
+}
+
+// Node gets the node of chainDID, returns nil if not existed.
+func (n *Network) Node(chainDID string) *Node {
+	return n.nodes[chainDID]
+}
+
+// Link makes child a child registry of parent.
+func (n *Network) Link(parent, child string) error {
+	p, c := n.nodes[parent], n.nodes[child]
+	if p == nil || c == nil {
+		return fmt.Errorf("link %s to %s: node not existed", child, parent)
+	}
+	if res := p.Registry.AddChild(p.Admin, child); !res.Ok {
+		return fmt.Errorf("add child %s: %s", child, res.Result)
+	}
+	if res := c.Registry.SetParent(c.Admin, parent); !res.Ok {
+		return fmt.Errorf("set parent %s: %s", parent, res.Result)
+	}
+	n.children[parent] = append(n.children[parent], child)
+	return nil
+}
+
+// broker serves the inter-relay broker of node, IBTPs recorded are queued.
+func (n *Network) broker(node *Node) stubtest.Handler {
+	return func(method string, args ...*pb.Arg) *boltvm.Response {
+		if method != "RecordIBTPs" || len(args) != 1 {
+			return boltvm.Error("unsupported broker method " + method)
+		}
+		ibtps := &pb.IBTPs{}
+		if err := ibtps.Unmarshal(args[0].Value); err != nil {
+			return boltvm.Error("ibtps unmarshal err: " + err.Error())
+		}
+		for _, ibtp := range ibtps.Ibtps {
+			n.pending = append(n.pending, &envelope{ibtp: ibtp})
+		}
+		return boltvm.Success(nil)
+	}
+}
+
+// Pending returns number of IBTPs waiting for delivery.
+func (n *Network) Pending() int {
+	return len(n.pending)
+}
+
+// Deliveries returns delivery attempts so far in order.
+func (n *Network) Deliveries() []*Delivery {
+	return n.deliveries
+}
+
+// Step makes a delivery attempt of one pending IBTP,
+// returns false if there is nothing to deliver.
+func (n *Network) Step() (*Delivery, bool) {
+	if len(n.pending) == 0 {
+		return nil, false
+	}
+	n.height++
+
+	i := 0
+	if n.hit(n.faults.Reorder) {
+		i = n.rand.Intn(len(n.pending))
+	}
+	env := n.pending[i]
+	n.pending = append(n.pending[:i], n.pending[i+1:]...)
+
+	d := &Delivery{IBTP: env.ibtp, Duplicate: env.duplicate}
+	n.deliveries = append(n.deliveries, d)
+	if n.hit(n.faults.Drop) {
+		d.Dropped = true
+		n.pending = append(n.pending, env)
+		return d, true
+	}
+	d.Response = n.deliver(env.ibtp)
+	if n.hit(n.faults.Duplicate) {
+		n.pending = append(n.pending, &envelope{ibtp: env.ibtp, duplicate: true})
+	}
+	return d, true
+}
+
+// Run steps until no IBTP is pending, returns err if there are
+// still pending IBTPs after maxSteps attempts.
+func (n *Network) Run(maxSteps int) error {
+	for i := 0; i < maxSteps; i++ {
+		if _, ok := n.Step(); !ok {
+			return nil
+		}
+	}
+	if len(n.pending) != 0 {
+		return fmt.Errorf("%d ibtps still pending after %d steps", len(n.pending), maxSteps)
+	}
+	return nil
+}
+
+// deliver calls the function in the IBTP payload on the destination registry.
+func (n *Network) deliver(ibtp *pb.IBTP) *boltvm.Response {
+	node := n.nodes[ibtp.To]
+	if node == nil {
+		return boltvm.Error("no node of " + ibtp.To)
+	}
+	payload := &pb.Payload{}
+	if err := json.Unmarshal(ibtp.Payload, payload); err != nil {
+		return boltvm.Error("payload unmarshal err: " + err.Error())
+	}
+	content := &pb.Content{}
+	if err := content.Unmarshal(payload.Content); err != nil {
+		return boltvm.Error("content unmarshal err: " + err.Error())
+	}
+	if content.DstContractId != constant.MethodRegistryContractAddr.String() {
+		return boltvm.Error("no contract at " + content.DstContractId)
+	}
+
+	// Synchronize takes the source chain did as string and the item as bytes
+	args := make([]*pb.Arg, 0, len(content.Args))
+	for i, arg := range content.Args {
+		if i == 0 {
+			args = append(args, pb.String(string(arg)))
+		} else {
+			args = append(args, pb.Bytes(arg))
+		}
+	}
+	node.Stub.SetCaller(constant.InterRelayBrokerContractAddr.String())
+	node.Stub.SetBlock(n.height, n.timestamp())
+	return stubtest.Route(node.Registry)(content.Func, args...)
+}
+
+// Converged checks every child linked to parent resolves dids the same as parent.
+func (n *Network) Converged(parent string, dids ...string) error {
+	p := n.nodes[parent]
+	if p == nil {
+		return fmt.Errorf("node %s not existed", parent)
+	}
+	for _, child := range n.children[parent] {
+		c := n.nodes[child]
+		for _, did := range dids {
+			want, got := p.Registry.Resolve(did), c.Registry.Resolve(did)
+			if !want.Ok || !got.Ok {
+				return fmt.Errorf("resolve %s: %s %s", did, want.Result, got.Result)
+			}
+			if !bytes.Equal(want.Result, got.Result) {
+				return fmt.Errorf("%s diverged on %s", child, did)
+			}
+		}
+	}
+	return nil
+}
+
+func (n *Network) hit(rate float64) bool {
+	return rate > 0 && n.rand.Float64() < rate
+}
+
+// timestamp returns block time in nanoseconds, a block per second.
+func (n *Network) timestamp() int64 {
+	return int64(n.height) * int64(time.Second)
+}
+
+// RegisterChainDID applies, approves and registers chainDID owned by
+// the node admin, IBTPs to children are queued on the network.
+func (nd *Node) RegisterChainDID(chainDID string) *boltvm.Response {
+	nd.Stub.SetCaller(nd.adminAddr())
+	if res := nd.Registry.Apply(nd.Admin, chainDID, nil); !res.Ok {
+		return res
+	}
+	if res := nd.Registry.AuditApply(nd.Admin, chainDID, 1, nil); !res.Ok {
+		return res
+	}
+	return nd.Registry.Register(nd.Admin, chainDID, "/ipfs/"+chainDID, []byte(chainDID), nil)
+}
+
+func (nd *Node) adminAddr() string {
+	return nd.Admin[len(nd.ChainDID)-1:]
+}
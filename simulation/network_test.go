@@ -0,0 +1,136 @@
+package simulation
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	rootDID = "did:bitxhub:relayroot:."
+	appDID  = "did:bitxhub:appchain%d:."
+)
+
+// hierarchy creates a root relay with children relays linked to it.
+func hierarchy(t *testing.T, seed int64, faults Faults, children int) (*Network, *Node) {
+	n := NewNetwork(seed, faults)
+	root, err := n.AddNode(rootDID)
+	require.Nil(t, err)
+	for i := 1; i <= children; i++ {
+		child := fmt.Sprintf("did:bitxhub:relay%d:.", i)
+		_, err := n.AddNode(child)
+		require.Nil(t, err)
+		require.Nil(t, n.Link(rootDID, child))
+	}
+	return n, root
+}
+
+// registerApps registers count appchain dids on root, returns the dids.
+func registerApps(t *testing.T, root *Node, count int) []string {
+	var dids []string
+	for i := 0; i < count; i++ {
+		did := fmt.Sprintf(appDID, i)
+		res := root.RegisterChainDID(did)
+		require.True(t, res.Ok, string(res.Result))
+		dids = append(dids, did)
+	}
+	return dids
+}
+
+func TestNetwork_Sync(t *testing.T) {
+	n, root := hierarchy(t, 1, Faults{}, 2)
+	dids := registerApps(t, root, 3)
+	require.Equal(t, 6, n.Pending())
+	require.NotNil(t, n.Converged(rootDID, dids...))
+
+	require.Nil(t, n.Run(10))
+	require.Equal(t, 0, n.Pending())
+	require.Nil(t, n.Converged(rootDID, dids...))
+	require.Len(t, n.Deliveries(), 6)
+	for _, d := range n.Deliveries() {
+		require.False(t, d.Dropped)
+		require.True(t, d.Response.Ok, string(d.Response.Result))
+	}
+
+	_, ok := n.Step()
+	require.False(t, ok)
+}
+
+func TestNetwork_Faults(t *testing.T) {
+	faults := map[string]Faults{
+		"drop":      {Drop: 0.3},
+		"reorder":   {Reorder: 0.5},
+		"duplicate": {Duplicate: 0.3},
+		"all":       {Drop: 0.3, Reorder: 0.5, Duplicate: 0.3},
+	}
+	for name, f := range faults {
+		for seed := int64(1); seed <= 5; seed++ {
+			t.Run(fmt.Sprintf("%s-%d", name, seed), func(t *testing.T) {
+				n, root := hierarchy(t, seed, f, 3)
+				dids := registerApps(t, root, 4)
+				require.Nil(t, n.Run(1000))
+				require.Nil(t, n.Converged(rootDID, dids...))
+
+				for _, d := range n.Deliveries() {
+					if d.Dropped {
+						require.Nil(t, d.Response)
+						continue
+					}
+					// an item is created once, only a duplicate may be rejected
+					require.True(t, d.Response.Ok || d.Duplicate, string(d.Response.Result))
+				}
+			})
+		}
+	}
+}
+
+func TestNetwork_FaultsInjected(t *testing.T) {
+	n, root := hierarchy(t, 7, Faults{Drop: 0.5, Duplicate: 0.5}, 2)
+	registerApps(t, root, 5)
+	require.Nil(t, n.Run(1000))
+
+	var dropped, duplicated int
+	for _, d := range n.Deliveries() {
+		if d.Dropped {
+			dropped++
+		}
+		if d.Duplicate {
+			duplicated++
+		}
+	}
+	require.NotZero(t, dropped)
+	require.NotZero(t, duplicated)
+}
+
+func TestNetwork_Run(t *testing.T) {
+	n, root := hierarchy(t, 1, Faults{Drop: 1}, 1)
+	dids := registerApps(t, root, 1)
+	require.NotNil(t, n.Run(10))
+	require.Equal(t, 1, n.Pending())
+	require.Len(t, n.Deliveries(), 10)
+	require.NotNil(t, n.Converged(rootDID, dids...))
+}
+
+func TestNetwork_UnknownDestination(t *testing.T) {
+	n, root := hierarchy(t, 1, Faults{}, 0)
+	res := root.Registry.AddChild(root.Admin, "did:bitxhub:relaymissing:.")
+	require.True(t, res.Ok, string(res.Result))
+	registerApps(t, root, 1)
+
+	require.Nil(t, n.Run(10))
+	require.Len(t, n.Deliveries(), 1)
+	require.False(t, n.Deliveries()[0].Response.Ok)
+}
+
+func TestNetwork_AddNode(t *testing.T) {
+	n, _ := hierarchy(t, 1, Faults{}, 1)
+	require.NotNil(t, n.Node("did:bitxhub:relay1:."))
+	require.Nil(t, n.Node("did:bitxhub:relay2:."))
+
+	_, err := n.AddNode(rootDID)
+	require.NotNil(t, err)
+	_, err = n.AddNode("did:bitxhub:relay2:0x01")
+	require.NotNil(t, err)
+	require.NotNil(t, n.Link(rootDID, "did:bitxhub:relay2:."))
+}
@@ -0,0 +1,362 @@
+package contracts
+
+import (
+	"strconv"
+
+	"github.com/meshplus/bitxhub-core/boltvm"
+	"github.com/meshplus/bitxhub-kit/types"
+	"github.com/meshplus/bitxid"
+	"github.com/meshplus/did-registry/didpb"
+)
+
+// defaultRecoveryDelay is time-lock of a recovery in seconds if not configured.
+const defaultRecoveryDelay = 2 * 24 * 60 * 60
+
+// Recovery represents social recovery setting of an account did and its pending recovery.
+// @Guardians: dids allowed to approve a recovery of the did
+// @Threshold: approvals needed to recover (M of N), 0 means recovery is disabled;
+// capped by number of guardians
+// @Delay: seconds from enough approvals to the recovery executable, 0 means defaultRecoveryDelay
+// @Nonce: number of recoveries closed so far, signed by guardians so that
+// approvals of a cancelled or executed recovery can not be replayed
+// @Address: new controlling address of the pending recovery, empty if none
+// @Approvals: guardians approved the pending recovery
+// @Unlock: timestamp in seconds from which the pending recovery can be executed,
+// 0 until enough guardians approved it
+type Recovery struct {
+	Guardians []bitxid.DID
+	Threshold uint64
+	Delay     uint64
+	Nonce     uint64
+	Address   string
+	Approvals []bitxid.DID
+	Unlock    int64
+}
+
+// Recoveries maps an account did to its social recovery setting.
+type Recoveries map[bitxid.DID]Recovery
+
+// Controllers maps an account did rebound by key rotation or recovery
+// to its controlling address,
+// dids not in it are controlled by the address in them.
+type Controllers map[bitxid.DID]string
+
+// required returns number of approvals needed among the guardians.
+func (r *Recovery) required() uint64 {
+	if r.Threshold > uint64(len(r.Guardians)) {
+		return uint64(len(r.Guardians))
+	}
+	return r.Threshold
+}
+
+func (r *Recovery) delay() int64 {
+	if r.Delay == 0 {
+		return defaultRecoveryDelay
+	}
+	return int64(r.Delay)
+}
+
+func (r *Recovery) pending() bool {
+	return r.Address != ""
+}
+
+// close drops the pending recovery, approvals of it can't be used any more.
+func (r *Recovery) close() {
+	r.Address = ""
+	r.Approvals = nil
+	r.Unlock = 0
+	r.Nonce++
+}
+
+func (r *Recovery) toPB(did bitxid.DID, controller string) *didpb.Recovery {
+	res := &didpb.Recovery{
+		Did:        string(did),
+		Controller: controller,
+		Threshold:  r.Threshold,
+		Delay:      r.Delay,
+		Nonce:      r.Nonce,
+		Address:    r.Address,
+		Unlock:     r.Unlock,
+	}
+	for _, g := range r.Guardians {
+		res.Guardians = append(res.Guardians, string(g))
+	}
+	for _, a := range r.Approvals {
+		res.Approvals = append(res.Approvals, string(a))
+	}
+	return res
+}
+
+// recoveryMsg is signed by a guardian to approve rebinding did to addr.
+func recoveryMsg(did bitxid.DID, addr string, nonce uint64) []byte {
+	return []byte("Recover:" + string(did) + ":" + addr + ":" + strconv.FormatUint(nonce, 10))
+}
+
+// controllerOf gets address controlling the account did.
+func (dr *AccountDIDRegistry) controllerOf(did bitxid.DID) string {
+	if addr, ok := dr.Controllers[did]; ok {
+		return addr
+	}
+	return did.GetAddress()
+}
+
+// rebind makes addr control the account did.
+func (dr *AccountDIDRegistry) rebind(did bitxid.DID, addr string) {
+	if addr == did.GetAddress() {
+		delete(dr.Controllers, did)
+		return
+	}
+	if dr.Controllers == nil {
+		dr.Controllers = make(Controllers)
+	}
+	dr.Controllers[did] = addr
+}
+
+// authorize is authorize of the account registry, tx.From should be
+// the controlling address of caller did.
+func (dr *AccountDIDRegistry) authorize(stub boltvm.Stub, caller string, guards ...guard) *boltvm.Response {
+	return authorizeAs(stub, dr.Initalized, caller, dr.controllerOf(bitxid.DID(caller)), guards...)
+}
+
+// checkRecoverable checks did exists and is not deactivated.
+func (dr *AccountDIDRegistry) checkRecoverable(did bitxid.DID) error {
+	switch dr.statusOf(did) {
+	case "":
+		return newError(ErrNotFound, "did %s not existed", did)
+	case DIDDeactivated:
+		return newError(ErrInvalidStatus, deactivatedError(string(did)))
+	}
+	return nil
+}
+
+// checkNotPending checks no recovery of did is pending, the setting is fixed until it's closed.
+func checkNotPending(r *Recovery, did bitxid.DID) error {
+	if r.pending() {
+		return newError(ErrInvalidStatus, "recovery of %s is pending, cancel it first", did)
+	}
+	return nil
+}
+
+// AddGuardian adds a guardian who can approve recovery of the did of caller,
+// the guardian should be another did registered in this registry,
+// guardians and recovery rule of a frozen did can't be changed.
+func (dm *AccountDIDManager) AddGuardian(caller, guardian string) *boltvm.Response {
+	dr := dm.getAccountDIDRegistry()
+
+	callerDID := bitxid.DID(caller)
+	guardianDID := bitxid.DID(guardian)
+	if res := dr.authorize(dm.Stub, caller, requireNormal(dr, callerDID)); res != nil {
+		return res
+	}
+	if guardianDID == callerDID {
+		return errorResponse(ErrInvalidArgument, "add guardian err, can not guard itself")
+	}
+	if err := dr.checkRecoverable(guardianDID); err != nil {
+		return wrapErrorResponse(ErrNotFound, "add guardian err, ", err)
+	}
+	r := dr.Recoveries[callerDID]
+	if err := checkNotPending(&r, callerDID); err != nil {
+		return wrapErrorResponse(ErrInvalidStatus, "add guardian err, ", err)
+	}
+	if containsDID(r.Guardians, guardianDID) {
+		return errorResponse(ErrAlreadyExists, "add guardian err, "+guardian+" is already guardian")
+	}
+	r.Guardians = append(r.Guardians, guardianDID)
+	dr.setRecovery(callerDID, r)
+
+	dm.SetObject(AccountDIDRegistryKey, dr)
+	postDIDEvent(dm.Stub, accountDIDRegistryName, EventAddGuardian, callerDID, "", "", callerDID)
+	return boltvm.Success(nil)
+}
+
+// RemoveGuardian removes a guardian of the did of caller.
+func (dm *AccountDIDManager) RemoveGuardian(caller, guardian string) *boltvm.Response {
+	dr := dm.getAccountDIDRegistry()
+
+	callerDID := bitxid.DID(caller)
+	guardianDID := bitxid.DID(guardian)
+	if res := dr.authorize(dm.Stub, caller, requireNormal(dr, callerDID)); res != nil {
+		return res
+	}
+	r := dr.Recoveries[callerDID]
+	if err := checkNotPending(&r, callerDID); err != nil {
+		return wrapErrorResponse(ErrInvalidStatus, "remove guardian err, ", err)
+	}
+	if !containsDID(r.Guardians, guardianDID) {
+		return errorResponse(ErrNotFound, "remove guardian err, "+guardian+" is not guardian")
+	}
+	r.Guardians = removeDID(r.Guardians, guardianDID)
+	dr.setRecovery(callerDID, r)
+
+	dm.SetObject(AccountDIDRegistryKey, dr)
+	postDIDEvent(dm.Stub, accountDIDRegistryName, EventRemoveGuardian, callerDID, "", "", callerDID)
+	return boltvm.Success(nil)
+}
+
+// SetRecoveryRule sets approvals of guardians needed to recover the did of caller,
+// 0 disables recovery, and the time-lock in seconds during which caller can
+// cancel an approved recovery, 0 means defaultRecoveryDelay.
+func (dm *AccountDIDManager) SetRecoveryRule(caller string, threshold, delay uint64) *boltvm.Response {
+	dr := dm.getAccountDIDRegistry()
+
+	callerDID := bitxid.DID(caller)
+	if res := dr.authorize(dm.Stub, caller, requireNormal(dr, callerDID)); res != nil {
+		return res
+	}
+	r := dr.Recoveries[callerDID]
+	if err := checkNotPending(&r, callerDID); err != nil {
+		return wrapErrorResponse(ErrInvalidStatus, "set recovery rule err, ", err)
+	}
+	r.Threshold = threshold
+	r.Delay = delay
+	dr.setRecovery(callerDID, r)
+
+	dm.SetObject(AccountDIDRegistryKey, dr)
+	postDIDEvent(dm.Stub, accountDIDRegistryName, EventSetRecoveryRule, callerDID, "", "", callerDID)
+	return boltvm.Success(nil)
+}
+
+// ApproveRecovery approves rebinding the did to a new controlling address,
+// caller should be a guardian of the did under Normal, the first approval opens the recovery,
+// it can be executed after the time-lock once enough guardians approved.
+// @addr: new controlling address of the did
+// @sig: signature of caller over "Recover:<did>:<addr>:<nonce>",
+// nonce is the number of recoveries of the did closed so far
+func (dm *AccountDIDManager) ApproveRecovery(caller, did, addr string, sig []byte) *boltvm.Response {
+	dr := dm.getAccountDIDRegistry()
+
+	callerDID := bitxid.DID(caller)
+	target := bitxid.DID(did)
+	r := dr.Recoveries[target]
+	if res := dr.authorize(dm.Stub, caller,
+		requireNormal(dr, callerDID),
+		requireAddrSig(dr.controllerOf(callerDID), recoveryMsg(target, addr, r.Nonce), sig),
+	); res != nil {
+		return res
+	}
+	if !containsDID(r.Guardians, callerDID) {
+		return errorResponse(ErrNotAdmin, "caller("+caller+") is not guardian of "+did)
+	}
+	if r.required() == 0 {
+		return errorResponse(ErrInvalidStatus, "approve recovery err, recovery of "+did+" is disabled")
+	}
+	if err := dr.checkRecoverable(target); err != nil {
+		return wrapErrorResponse(ErrNotFound, "approve recovery err, ", err)
+	}
+	address := types.NewAddressByStr(addr)
+	if address == nil {
+		return errorResponse(ErrInvalidFormat, "approve recovery err, invalid address "+addr)
+	}
+	addr = address.String()
+	if addr == dr.controllerOf(target) {
+		return errorResponse(ErrInvalidArgument, "approve recovery err, "+did+" is already controlled by "+addr)
+	}
+	for _, retired := range dr.KeyHistories.retired(target) {
+		if addr == retired {
+			return errorResponse(ErrInvalidArgument, "approve recovery err, "+addr+" is a retired key of "+did)
+		}
+	}
+	if r.pending() && r.Address != addr {
+		return errorResponse(ErrInvalidStatus, "approve recovery err, recovery of "+did+" to "+r.Address+" is pending")
+	}
+	if containsDID(r.Approvals, callerDID) {
+		return errorResponse(ErrAlreadyExists, "approve recovery err, "+caller+" already approved")
+	}
+
+	now, err := blockTime(dm.Stub)
+	if err != nil {
+		return wrapErrorResponse(ErrNoBlockContext, "approve recovery err, ", err)
+	}
+
+	r.Address = addr
+	r.Approvals = append(r.Approvals, callerDID)
+	if r.Unlock == 0 && uint64(len(r.Approvals)) >= r.required() {
+		r.Unlock = now + r.delay()
+	}
+	dr.setRecovery(target, r)
+
+	dm.SetObject(AccountDIDRegistryKey, dr)
+	postDIDEvent(dm.Stub, accountDIDRegistryName, EventApproveRecovery, target, "", "", callerDID)
+	return boltvm.Success(nil)
+}
+
+// CancelRecovery cancels the pending recovery of the did of caller,
+// approvals of it can't be used again, a frozen did can't cancel.
+func (dm *AccountDIDManager) CancelRecovery(caller string) *boltvm.Response {
+	dr := dm.getAccountDIDRegistry()
+
+	callerDID := bitxid.DID(caller)
+	if res := dr.authorize(dm.Stub, caller, requireNormal(dr, callerDID)); res != nil {
+		return res
+	}
+	r := dr.Recoveries[callerDID]
+	if !r.pending() {
+		return errorResponse(ErrInvalidStatus, "cancel recovery err, no recovery of "+caller+" is pending")
+	}
+	r.close()
+	dr.setRecovery(callerDID, r)
+
+	dm.SetObject(AccountDIDRegistryKey, dr)
+	postDIDEvent(dm.Stub, accountDIDRegistryName, EventCancelRecovery, callerDID, "", "", callerDID)
+	return boltvm.Success(nil)
+}
+
+// ExecuteRecovery rebinds the did to the address approved by its guardians
+// once the time-lock passed, the key controlling the did is retired,
+// whether it's the original key or a rotated one,
+// caller should be a guardian of the did.
+func (dm *AccountDIDManager) ExecuteRecovery(caller, did string) *boltvm.Response {
+	dr := dm.getAccountDIDRegistry()
+
+	callerDID := bitxid.DID(caller)
+	target := bitxid.DID(did)
+	if res := dr.authorize(dm.Stub, caller); res != nil {
+		return res
+	}
+	r := dr.Recoveries[target]
+	if !containsDID(r.Guardians, callerDID) {
+		return errorResponse(ErrNotAdmin, "caller("+caller+") is not guardian of "+did)
+	}
+	if r.Unlock == 0 {
+		return errorResponse(ErrInvalidStatus, "execute recovery err, recovery of "+did+" is not approved by enough guardians")
+	}
+	now, err := blockTime(dm.Stub)
+	if err != nil {
+		return wrapErrorResponse(ErrNoBlockContext, "execute recovery err, ", err)
+	}
+	if now < r.Unlock {
+		return errorResponse(ErrInvalidStatus, "execute recovery err, recovery of "+did+" is locked until "+strconv.FormatInt(r.Unlock, 10))
+	}
+	if err := dr.checkRecoverable(target); err != nil {
+		return wrapErrorResponse(ErrNotFound, "execute recovery err, ", err)
+	}
+
+	dr.KeyHistories.retire(target, dr.controllerOf(target), recordHeight(dm.Stub))
+	dr.rebind(target, r.Address)
+	r.close()
+	dr.setRecovery(target, r)
+
+	dm.SetObject(AccountDIDRegistryKey, dr)
+	postDIDEvent(dm.Stub, accountDIDRegistryName, EventRecover, target, "", "", callerDID)
+	return boltvm.Success(nil)
+}
+
+// GetRecovery gets social recovery setting of the did with its pending recovery
+// and controlling address.
+func (dm *AccountDIDManager) GetRecovery(did string) *boltvm.Response {
+	dr := dm.getAccountDIDRegistry()
+
+	if res := checkInitialized(dr.Initalized); res != nil {
+		return res
+	}
+
+	r := dr.Recoveries[bitxid.DID(did)]
+	return success(r.toPB(bitxid.DID(did), dr.controllerOf(bitxid.DID(did))))
+}
+
+func (dr *AccountDIDRegistry) setRecovery(did bitxid.DID, r Recovery) {
+	if dr.Recoveries == nil {
+		dr.Recoveries = make(Recoveries)
+	}
+	dr.Recoveries[did] = r
+}
@@ -0,0 +1,421 @@
+package contracts
+
+import (
+	"testing"
+
+	"github.com/meshplus/bitxhub-core/boltvm"
+	"github.com/meshplus/bitxid"
+	"github.com/meshplus/did-registry/didpb"
+	"github.com/stretchr/testify/require"
+)
+
+const testRecoveryDelay = 100
+
+// guarded adds 3 guardians to user, 2 of them are needed to recover it.
+func guarded(e *testEnv) []*testAccount {
+	var guardians []*testAccount
+	for i := 0; i < 3; i++ {
+		g, _ := e.newAccount()
+		requireOK(e.t, e.asUser().account.AddGuardian(e.user.did, g.did))
+		guardians = append(guardians, g)
+	}
+	requireOK(e.t, e.asUser().account.SetRecoveryRule(e.user.did, 2, testRecoveryDelay))
+	return guardians
+}
+
+// approveRecovery approves recovering user to addr by guardian g.
+func approveRecovery(e *testEnv, g *testAccount, addr string, nonce uint64) *boltvm.Response {
+	sig := g.sign(e.t, recoveryMsg(bitxid.DID(e.user.did), addr, nonce))
+	return e.as(g).account.ApproveRecovery(g.did, e.user.did, addr, sig)
+}
+
+// approved has 2 guardians approve recovering user to the returned account.
+func approved(e *testEnv) ([]*testAccount, *testAccount) {
+	guardians := guarded(e)
+	next := newTestAccount(e.t)
+	requireOK(e.t, approveRecovery(e, guardians[0], next.addr, 0))
+	requireOK(e.t, approveRecovery(e, guardians[1], next.addr, 0))
+	return guardians, next
+}
+
+// recovered rebinds user to the returned account after the time-lock.
+func recovered(e *testEnv) *testAccount {
+	guardians, next := approved(e)
+	e.setBlock(testHeight+1, testTime+testRecoveryDelay)
+	requireOK(e.t, e.as(guardians[0]).account.ExecuteRecovery(guardians[0].did, e.user.did))
+	return next
+}
+
+func userRecovery(t *testing.T, e *testEnv) *didpb.Recovery {
+	r := &didpb.Recovery{}
+	decode(t, e.account.GetRecovery(e.user.did), r)
+	return r
+}
+
+func TestAccountDIDManager_Guardians(t *testing.T) {
+	runCalls(t, []call{
+		{
+			name: "add",
+			run: func(e *testEnv) *boltvm.Response {
+				g, _ := e.newAccount()
+				return e.as(e.user).account.AddGuardian(e.user.did, g.did)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Len(t, userRecovery(t, e).Guardians, 1)
+				require.Equal(t, EventAddGuardian, lastEvent(t, e.accountStub).Type)
+			},
+		},
+		{
+			name: "set rule",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).account.SetRecoveryRule(e.user.did, 1, testRecoveryDelay)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				r := userRecovery(t, e)
+				require.Equal(t, uint64(1), r.Threshold)
+				require.Equal(t, uint64(testRecoveryDelay), r.Delay)
+				require.Equal(t, EventSetRecoveryRule, lastEvent(t, e.accountStub).Type)
+			},
+		},
+		{
+			name: "add itself",
+			run:  func(e *testEnv) *boltvm.Response { return e.as(e.user).account.AddGuardian(e.user.did, e.user.did) },
+			code: ErrInvalidArgument,
+		},
+		{
+			name: "add not existed",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).account.AddGuardian(e.user.did, newTestAccount(e.t).did)
+			},
+			code: ErrNotFound,
+		},
+		{
+			name:  "add twice",
+			setup: func(e *testEnv) { guarded(e) },
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).account.AddGuardian(e.user.did, userRecovery(e.t, e).Guardians[0])
+			},
+			code: ErrAlreadyExists,
+		},
+		{
+			name:  "remove",
+			setup: func(e *testEnv) { guarded(e) },
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).account.RemoveGuardian(e.user.did, userRecovery(e.t, e).Guardians[0])
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Len(t, userRecovery(t, e).Guardians, 2)
+				require.Equal(t, EventRemoveGuardian, lastEvent(t, e.accountStub).Type)
+			},
+		},
+		{
+			name: "remove not guardian",
+			run:  func(e *testEnv) *boltvm.Response { return e.as(e.user).account.RemoveGuardian(e.user.did, e.admin.did) },
+			code: ErrNotFound,
+		},
+		{
+			name:  "change while pending",
+			setup: func(e *testEnv) { approved(e) },
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).account.SetRecoveryRule(e.user.did, 1, 0)
+			},
+			code: ErrInvalidStatus,
+		},
+		{
+			name: "by others",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.AddGuardian(e.user.did, e.admin.did)
+			},
+			code: ErrCallerMismatch,
+		},
+		{
+			name:  "add while self frozen",
+			setup: func(e *testEnv) { requireOK(e.t, selfFreezeUser(e, e.user)) },
+			run: func(e *testEnv) *boltvm.Response {
+				g, _ := e.newAccount()
+				return e.as(e.user).account.AddGuardian(e.user.did, g.did)
+			},
+			code: ErrInvalidStatus,
+		},
+		{
+			name: "remove while self frozen",
+			setup: func(e *testEnv) {
+				guarded(e)
+				requireOK(e.t, selfFreezeUser(e, e.user))
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).account.RemoveGuardian(e.user.did, userRecovery(e.t, e).Guardians[0])
+			},
+			code: ErrInvalidStatus,
+		},
+	})
+}
+
+func TestAccountDIDManager_Recovery(t *testing.T) {
+	runCalls(t, []call{
+		{
+			name: "approve",
+			run: func(e *testEnv) *boltvm.Response {
+				guardians := guarded(e)
+				return approveRecovery(e, guardians[0], newTestAccount(e.t).addr, 0)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				r := userRecovery(t, e)
+				require.NotEmpty(t, r.Address)
+				require.Len(t, r.Approvals, 1)
+				require.Zero(t, r.Unlock)
+				ev := lastEvent(t, e.accountStub)
+				require.Equal(t, EventApproveRecovery, ev.Type)
+				require.Equal(t, bitxid.DID(e.user.did), ev.DID)
+			},
+		},
+		{
+			name: "approved by enough guardians",
+			run: func(e *testEnv) *boltvm.Response {
+				approved(e)
+				return e.account.GetRecovery(e.user.did)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, testTime+testRecoveryDelay, userRecovery(t, e).Unlock)
+			},
+		},
+		{
+			name: "approve by others",
+			run: func(e *testEnv) *boltvm.Response {
+				guarded(e)
+				return approveRecovery(e, e.admin, newTestAccount(e.t).addr, 0)
+			},
+			code: ErrNotAdmin,
+		},
+		{
+			name: "approve by frozen guardian",
+			run: func(e *testEnv) *boltvm.Response {
+				g := guarded(e)[0]
+				requireOK(e.t, e.as(e.admin).account.Freeze(e.admin.did, g.did, nil))
+				return approveRecovery(e, g, newTestAccount(e.t).addr, 0)
+			},
+			code: ErrInvalidStatus,
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Empty(t, userRecovery(t, e).Approvals)
+			},
+		},
+		{
+			name: "approve twice",
+			run: func(e *testEnv) *boltvm.Response {
+				guardians, next := approved(e)
+				return approveRecovery(e, guardians[0], next.addr, 0)
+			},
+			code: ErrAlreadyExists,
+		},
+		{
+			name: "approve another address",
+			run: func(e *testEnv) *boltvm.Response {
+				guardians, _ := approved(e)
+				return approveRecovery(e, guardians[2], newTestAccount(e.t).addr, 0)
+			},
+			code: ErrInvalidStatus,
+		},
+		{
+			name: "approve invalid address",
+			run: func(e *testEnv) *boltvm.Response {
+				return approveRecovery(e, guarded(e)[0], "0x01", 0)
+			},
+			code: ErrInvalidFormat,
+		},
+		{
+			name: "approve disabled",
+			run: func(e *testEnv) *boltvm.Response {
+				guardians := guarded(e)
+				requireOK(e.t, e.as(e.user).account.SetRecoveryRule(e.user.did, 0, 0))
+				return approveRecovery(e, guardians[0], newTestAccount(e.t).addr, 0)
+			},
+			code: ErrInvalidStatus,
+		},
+		{
+			name: "approve deactivated",
+			run: func(e *testEnv) *boltvm.Response {
+				guardians := guarded(e)
+				userDeactivated(e)
+				return approveRecovery(e, guardians[0], newTestAccount(e.t).addr, 0)
+			},
+			code: ErrInvalidStatus,
+		},
+		{
+			name: "approve with bad signature",
+			run: func(e *testEnv) *boltvm.Response {
+				g := guarded(e)[0]
+				addr := newTestAccount(e.t).addr
+				sig := e.user.sign(e.t, recoveryMsg(bitxid.DID(e.user.did), addr, 0))
+				return e.as(g).account.ApproveRecovery(g.did, e.user.did, addr, sig)
+			},
+			code: ErrSignatureInvalid,
+		},
+		{
+			name: "execute",
+			run: func(e *testEnv) *boltvm.Response {
+				next := recovered(e)
+				_, hash := docOf(e.t, e.user.did, next, nil)
+				return e.as(next).account.Update(e.user.did, testDocAddr, hash, nil)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				r := userRecovery(t, e)
+				require.Empty(t, r.Address)
+				require.Equal(t, uint64(1), r.Nonce)
+				require.NotEqual(t, e.user.addr, r.Controller)
+
+				// the lost key controls the did no more
+				_, hash := e.user.doc(e.t)
+				requireCode(t, e.as(e.user).account.Update(e.user.did, testDocAddr, hash, nil), ErrCallerMismatch)
+			},
+		},
+		{
+			name: "execute posts event",
+			run: func(e *testEnv) *boltvm.Response {
+				recovered(e)
+				return e.account.GetRecovery(e.user.did)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, EventRecover, lastEvent(t, e.accountStub).Type)
+			},
+		},
+		{
+			name: "execute retires current key",
+			run: func(e *testEnv) *boltvm.Response {
+				rotated(e, newTestAccount(e.t))
+				recovered(e)
+				return e.account.GetKeyHistory(e.user.did)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				records := keyHistory(t, e, e.user.did)
+				require.Len(t, records, 2)
+				require.True(t, records[1].Retired)
+				require.Len(t, resolveAccountDID(t, e, e.user.did).RetiredKeys, 2)
+			},
+		},
+		{
+			name: "execute retires original key",
+			run: func(e *testEnv) *boltvm.Response {
+				recovered(e)
+				return e.account.GetKeyHistory(e.user.did)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				records := keyHistory(t, e, e.user.did)
+				require.Len(t, records, 1)
+				require.Equal(t, e.user.addr, records[0].Address)
+				require.True(t, records[0].Retired)
+				require.Equal(t, []string{e.user.addr}, resolveAccountDID(t, e, e.user.did).RetiredKeys)
+			},
+		},
+		{
+			name: "original key signs after execute",
+			setup: func(e *testEnv) {
+				e.newClaimTyp(e.user, testCTID, "name")
+				recovered(e)
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				// the anchored doc still lists the original key
+				c := e.credential(e.user, testCID, testCTID, claimOf(e.t, e.admin.did, map[string]string{"name": "alice"}))
+				cb, err := c.Marshal()
+				require.Nil(e.t, err)
+				return e.asUser().vc.StoreVC(e.user.did, cb, e.userDoc)
+			},
+			code: ErrSignatureInvalid,
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				requireCode(t, selfFreezeUser(e, e.user), ErrSignatureInvalid)
+				requireCode(t, e.as(e.user).vc.AddTrustedIssuer(e.user.did, testCTID, e.admin.did), ErrCallerMismatch)
+				requireCode(t, e.as(e.user).chain.Apply(e.user.did, testAppChainDID, nil), ErrCallerMismatch)
+			},
+		},
+		{
+			name: "approve retired key",
+			run: func(e *testEnv) *boltvm.Response {
+				guardians, _ := approved(e)
+				e.setBlock(testHeight+1, testTime+testRecoveryDelay)
+				requireOK(e.t, e.as(guardians[0]).account.ExecuteRecovery(guardians[0].did, e.user.did))
+				return approveRecovery(e, guardians[0], e.user.addr, 1)
+			},
+			code: ErrInvalidArgument,
+		},
+		{
+			name: "execute locked",
+			run: func(e *testEnv) *boltvm.Response {
+				guardians, _ := approved(e)
+				e.setBlock(testHeight+1, testTime+testRecoveryDelay-1)
+				return e.as(guardians[0]).account.ExecuteRecovery(guardians[0].did, e.user.did)
+			},
+			code: ErrInvalidStatus,
+		},
+		{
+			name: "execute not approved",
+			run: func(e *testEnv) *boltvm.Response {
+				guardians := guarded(e)
+				requireOK(e.t, approveRecovery(e, guardians[0], newTestAccount(e.t).addr, 0))
+				e.setBlock(testHeight+1, testTime+testRecoveryDelay)
+				return e.as(guardians[0]).account.ExecuteRecovery(guardians[0].did, e.user.did)
+			},
+			code: ErrInvalidStatus,
+		},
+		{
+			name: "execute by others",
+			run: func(e *testEnv) *boltvm.Response {
+				approved(e)
+				e.setBlock(testHeight+1, testTime+testRecoveryDelay)
+				return e.as(e.admin).account.ExecuteRecovery(e.admin.did, e.user.did)
+			},
+			code: ErrNotAdmin,
+		},
+		{
+			name: "cancel",
+			run: func(e *testEnv) *boltvm.Response {
+				approved(e)
+				return e.as(e.user).account.CancelRecovery(e.user.did)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				r := userRecovery(t, e)
+				require.Empty(t, r.Address)
+				require.Empty(t, r.Approvals)
+				require.Equal(t, EventCancelRecovery, lastEvent(t, e.accountStub).Type)
+			},
+		},
+		{
+			name: "cancel while self frozen",
+			run: func(e *testEnv) *boltvm.Response {
+				approved(e)
+				requireOK(e.t, selfFreezeUser(e, e.user))
+				return e.as(e.user).account.CancelRecovery(e.user.did)
+			},
+			code: ErrInvalidStatus,
+		},
+		{
+			name: "cancel not pending",
+			run:  func(e *testEnv) *boltvm.Response { return e.as(e.user).account.CancelRecovery(e.user.did) },
+			code: ErrInvalidStatus,
+		},
+		{
+			name: "approval replayed after cancel",
+			run: func(e *testEnv) *boltvm.Response {
+				guardians := guarded(e)
+				addr := newTestAccount(e.t).addr
+				g := guardians[0]
+				old := g.sign(e.t, recoveryMsg(bitxid.DID(e.user.did), addr, 0))
+				requireOK(e.t, e.as(g).account.ApproveRecovery(g.did, e.user.did, addr, old))
+				requireOK(e.t, e.as(e.user).account.CancelRecovery(e.user.did))
+				return e.as(g).account.ApproveRecovery(g.did, e.user.did, addr, old)
+			},
+			code: ErrSignatureInvalid,
+		},
+		{
+			name: "recovered guardian approves",
+			run: func(e *testEnv) *boltvm.Response {
+				// user recovered, then guards another did with its new address
+				next := recovered(e)
+				g, _ := e.newAccount()
+				requireOK(e.t, e.as(g).account.AddGuardian(g.did, e.user.did))
+				requireOK(e.t, e.as(g).account.SetRecoveryRule(g.did, 1, 0))
+				addr := newTestAccount(e.t).addr
+				sig := next.sign(e.t, recoveryMsg(bitxid.DID(g.did), addr, 0))
+				return e.as(next).account.ApproveRecovery(e.user.did, g.did, addr, sig)
+			},
+		},
+	})
+}
@@ -0,0 +1,342 @@
+// Package stubtest provides an in-memory boltvm.Stub, so that contracts of
+// the did registry, or contracts extending them, can be tested without
+// running a BitXHub node.
+//
+// A contract is tested by embedding a Stub in it:
+//
+//	stub := stubtest.New(constant.DIDRegistryContractAddr.String())
+//	dm := &contracts.AccountDIDManager{Stub: stub}
+//	stub.SetCaller(addr)
+//	res := dm.Register(did, docAddr, docHash, nil)
+//
+// Cross invokes are recorded and served by handlers registered by Handle,
+// or by another contract linked by Link.
+package stubtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/meshplus/bitxhub-core/boltvm"
+	"github.com/meshplus/bitxhub-core/validator"
+	"github.com/meshplus/bitxhub-kit/types"
+	"github.com/meshplus/bitxhub-model/pb"
+	"github.com/sirupsen/logrus"
+)
+
+// Handler serves cross invokes of method to an address.
+type Handler func(method string, args ...*pb.Arg) *boltvm.Response
+
+// Invoke records a cross invoke made by the contract.
+type Invoke struct {
+	Address string
+	Method  string
+	Args    []*pb.Arg
+}
+
+// Stub is an in-memory implementation of boltvm.Stub,
+// it also implements BlockContext of the contracts.
+// Stub is not safe for concurrent use, as the ledger of a node is not.
+type Stub struct {
+	caller    string
+	callee    string
+	txHash    *types.Hash
+	txIndex   uint64
+	height    uint64
+	timestamp int64
+
+	state            map[string][]byte
+	events           []interface{}
+	interchainEvents []interface{}
+	invokes          []Invoke
+	handlers         map[string]Handler
+	logger           logrus.FieldLogger
+}
+
+var _ boltvm.Stub = (*Stub)(nil)
+
+// New creates a stub of the contract deployed at callee,
+// logs are discarded unless a logger is set by SetLogger.
+func New(callee string) *Stub {
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+	return &Stub{
+		callee:   callee,
+		txHash:   &types.Hash{},
+		state:    make(map[string][]byte),
+		handlers: make(map[string]Handler),
+		logger:   logger,
+	}
+}
+
+// SetCaller sets tx.From of following calls.
+func (s *Stub) SetCaller(caller string) {
+	s.caller = caller
+}
+
+// SetTx sets hash and index of the current transaction.
+func (s *Stub) SetTx(hash *types.Hash, index uint64) {
+	s.txHash = hash
+	s.txIndex = index
+}
+
+// SetBlock sets height of the current block and timestamp of the current
+// transaction in nanoseconds.
+func (s *Stub) SetBlock(height uint64, timestamp int64) {
+	s.height = height
+	s.timestamp = timestamp
+}
+
+// SetLogger sets logger returned by Logger.
+func (s *Stub) SetLogger(logger logrus.FieldLogger) {
+	s.logger = logger
+}
+
+// Events returns events posted by PostEvent in order.
+func (s *Stub) Events() []interface{} {
+	return s.events
+}
+
+// InterchainEvents returns events posted by PostInterchainEvent in order.
+func (s *Stub) InterchainEvents() []interface{} {
+	return s.interchainEvents
+}
+
+// Invokes returns cross invokes made so far in order.
+func (s *Stub) Invokes() []Invoke {
+	return s.invokes
+}
+
+// ClearRecords drops recorded events and invokes, state is kept.
+func (s *Stub) ClearRecords() {
+	s.events = nil
+	s.interchainEvents = nil
+	s.invokes = nil
+}
+
+// Handle serves cross invokes to address by h,
+// invokes to addresses without handler fail.
+func (s *Stub) Handle(address string, h Handler) {
+	s.handlers[address] = h
+}
+
+// Link serves cross invokes to address by calling exported methods of
+// contract, which runs on stub. Caller and block context of s are passed
+// on to stub before each call, as a node does within one transaction.
+func (s *Stub) Link(address string, contract interface{}, stub *Stub) {
+	route := Route(contract)
+	s.Handle(address, func(method string, args ...*pb.Arg) *boltvm.Response {
+		stub.caller = s.caller
+		stub.txHash, stub.txIndex = s.txHash, s.txIndex
+		stub.height, stub.timestamp = s.height, s.timestamp
+		return route(method, args...)
+	})
+}
+
+// Route returns a handler calling exported methods of contract by name,
+// args are converted according to their types as the boltvm does.
+func Route(contract interface{}) Handler {
+	v := reflect.ValueOf(contract)
+	return func(method string, args ...*pb.Arg) *boltvm.Response {
+		m := v.MethodByName(method)
+		if !m.IsValid() {
+			return boltvm.Error(fmt.Sprintf("method %s not found", method))
+		}
+		if m.Type().NumIn() != len(args) {
+			return boltvm.Error(fmt.Sprintf("method %s wants %d args, got %d", method, m.Type().NumIn(), len(args)))
+		}
+		in := make([]reflect.Value, 0, len(args))
+		for i, arg := range args {
+			val, err := argValue(arg, m.Type().In(i))
+			if err != nil {
+				return boltvm.Error(fmt.Sprintf("method %s arg %d: %s", method, i, err))
+			}
+			in = append(in, val)
+		}
+		out := m.Call(in)
+		if len(out) != 1 {
+			return boltvm.Error(fmt.Sprintf("method %s doesn't return a response", method))
+		}
+		res, ok := out[0].Interface().(*boltvm.Response)
+		if !ok {
+			return boltvm.Error(fmt.Sprintf("method %s doesn't return a response", method))
+		}
+		return res
+	}
+}
+
+func argValue(arg *pb.Arg, typ reflect.Type) (reflect.Value, error) {
+	var (
+		val interface{}
+		err error
+	)
+	switch arg.Type {
+	case pb.Arg_I32:
+		var i int64
+		i, err = strconv.ParseInt(string(arg.Value), 10, 32)
+		val = int32(i)
+	case pb.Arg_I64:
+		val, err = strconv.ParseInt(string(arg.Value), 10, 64)
+	case pb.Arg_U32:
+		var u uint64
+		u, err = strconv.ParseUint(string(arg.Value), 10, 32)
+		val = uint32(u)
+	case pb.Arg_U64:
+		val, err = strconv.ParseUint(string(arg.Value), 10, 64)
+	case pb.Arg_F32:
+		var f float64
+		f, err = strconv.ParseFloat(string(arg.Value), 32)
+		val = float32(f)
+	case pb.Arg_F64:
+		val, err = strconv.ParseFloat(string(arg.Value), 64)
+	case pb.Arg_String:
+		val = string(arg.Value)
+	case pb.Arg_Bytes:
+		val = arg.Value
+	case pb.Arg_Bool:
+		val, err = strconv.ParseBool(string(arg.Value))
+	default:
+		return reflect.Value{}, fmt.Errorf("unknown arg type %s", arg.Type)
+	}
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	rv := reflect.ValueOf(val)
+	if rv.Type() != typ {
+		return reflect.Value{}, fmt.Errorf("want %s, got %s", typ, rv.Type())
+	}
+	return rv, nil
+}
+
+// Caller returns tx.From set by SetCaller.
+func (s *Stub) Caller() string {
+	return s.caller
+}
+
+// Callee returns address of the contract.
+func (s *Stub) Callee() string {
+	return s.callee
+}
+
+// Logger returns the logger.
+func (s *Stub) Logger() logrus.FieldLogger {
+	return s.logger
+}
+
+// GetTxHash returns hash set by SetTx.
+func (s *Stub) GetTxHash() *types.Hash {
+	return s.txHash
+}
+
+// GetTxIndex returns index set by SetTx.
+func (s *Stub) GetTxIndex() uint64 {
+	return s.txIndex
+}
+
+// CurrentHeight returns height set by SetBlock.
+func (s *Stub) CurrentHeight() uint64 {
+	return s.height
+}
+
+// GetTxTimeStamp returns timestamp set by SetBlock.
+func (s *Stub) GetTxTimeStamp() int64 {
+	return s.timestamp
+}
+
+// Has judges key.
+func (s *Stub) Has(key string) bool {
+	_, ok := s.state[key]
+	return ok
+}
+
+// Get gets value by key.
+func (s *Stub) Get(key string) (bool, []byte) {
+	val, ok := s.state[key]
+	if !ok {
+		return false, nil
+	}
+	return true, append([]byte(nil), val...)
+}
+
+// GetObject unmarshals json value of key into ret.
+func (s *Stub) GetObject(key string, ret interface{}) bool {
+	ok, val := s.Get(key)
+	if !ok {
+		return false
+	}
+	return json.Unmarshal(val, ret) == nil
+}
+
+// Set sets k-v.
+func (s *Stub) Set(key string, value []byte) {
+	s.state[key] = append([]byte(nil), value...)
+}
+
+// SetObject sets k with json encoding of value,
+// it panics if value can't be encoded, which is a bug of the contract.
+func (s *Stub) SetObject(key string, value interface{}) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		panic(fmt.Sprintf("stubtest: marshal %s: %s", key, err))
+	}
+	s.Set(key, b)
+}
+
+// AddObject sets k with json encoding of value.
+func (s *Stub) AddObject(key string, value interface{}) {
+	s.SetObject(key, value)
+}
+
+// Delete deletes k-v.
+func (s *Stub) Delete(key string) {
+	delete(s.state, key)
+}
+
+// Query gets values of keys with the prefix in key order.
+func (s *Stub) Query(prefix string) (bool, [][]byte) {
+	var keys []string
+	for key := range s.state {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return false, nil
+	}
+	sort.Strings(keys)
+	vals := make([][]byte, 0, len(keys))
+	for _, key := range keys {
+		vals = append(vals, append([]byte(nil), s.state[key]...))
+	}
+	return true, vals
+}
+
+// PostEvent records event.
+func (s *Stub) PostEvent(event interface{}) {
+	s.events = append(s.events, event)
+}
+
+// PostInterchainEvent records event.
+func (s *Stub) PostInterchainEvent(event interface{}) {
+	s.interchainEvents = append(s.interchainEvents, event)
+}
+
+// ValidationEngine returns nil, no validator is run in memory.
+func (s *Stub) ValidationEngine() validator.Engine {
+	return nil
+}
+
+// CrossInvoke records the invoke and serves it by the handler of address.
+func (s *Stub) CrossInvoke(address, method string, args ...*pb.Arg) *boltvm.Response {
+	s.invokes = append(s.invokes, Invoke{Address: address, Method: method, Args: args})
+	h, ok := s.handlers[address]
+	if !ok {
+		return boltvm.Error("no contract at " + address)
+	}
+	return h(method, args...)
+}
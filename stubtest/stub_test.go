@@ -0,0 +1,81 @@
+package stubtest
+
+import (
+	"testing"
+
+	"github.com/meshplus/bitxhub-core/boltvm"
+	"github.com/meshplus/bitxhub-model/pb"
+	"github.com/stretchr/testify/require"
+)
+
+type counter struct {
+	*Stub
+}
+
+func (c *counter) Add(caller string, n uint64) *boltvm.Response {
+	if c.Caller() != caller {
+		return boltvm.Error("caller mismatch")
+	}
+	var sum uint64
+	c.GetObject("sum", &sum)
+	c.SetObject("sum", sum+n)
+	c.PostEvent(sum + n)
+	return boltvm.Success([]byte(caller))
+}
+
+func TestStub_State(t *testing.T) {
+	s := New("callee")
+	require.False(t, s.Has("a"))
+	s.Set("b-2", []byte("2"))
+	s.SetObject("b-1", 1)
+	s.Set("c", []byte("3"))
+
+	ok, val := s.Get("b-2")
+	require.True(t, ok)
+	require.Equal(t, []byte("2"), val)
+	val[0] = '0'
+	_, val = s.Get("b-2")
+	require.Equal(t, []byte("2"), val)
+
+	var n int
+	require.True(t, s.GetObject("b-1", &n))
+	require.Equal(t, 1, n)
+
+	ok, vals := s.Query("b-")
+	require.True(t, ok)
+	require.Equal(t, [][]byte{[]byte("1"), []byte("2")}, vals)
+
+	s.Delete("c")
+	ok, _ = s.Query("c")
+	require.False(t, ok)
+}
+
+func TestStub_CrossInvoke(t *testing.T) {
+	s := New("caller-contract")
+	s.SetCaller("0xabc")
+	s.SetBlock(3, 1000)
+
+	res := s.CrossInvoke("nowhere", "Add")
+	require.False(t, res.Ok)
+
+	target := New("counter")
+	s.Link("counter", &counter{Stub: target}, target)
+	res = s.CrossInvoke("counter", "Add", pb.String("0xabc"), pb.Uint64(2))
+	require.True(t, res.Ok, string(res.Result))
+	require.Equal(t, []byte("0xabc"), res.Result)
+	require.Equal(t, uint64(3), target.CurrentHeight())
+	require.Equal(t, int64(1000), target.GetTxTimeStamp())
+	require.Equal(t, []interface{}{uint64(2)}, target.Events())
+
+	res = s.CrossInvoke("counter", "Add", pb.String("0xabc"))
+	require.False(t, res.Ok)
+	res = s.CrossInvoke("counter", "Add", pb.String("0xabc"), pb.String("2"))
+	require.False(t, res.Ok)
+	res = s.CrossInvoke("counter", "Missing")
+	require.False(t, res.Ok)
+
+	require.Len(t, s.Invokes(), 5)
+	require.Equal(t, Invoke{Address: "counter", Method: "Add", Args: []*pb.Arg{pb.String("0xabc"), pb.Uint64(2)}}, s.Invokes()[1])
+	s.ClearRecords()
+	require.Empty(t, s.Invokes())
+}
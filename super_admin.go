@@ -0,0 +1,294 @@
+package contracts
+
+import (
+	"github.com/meshplus/bitxhub-core/boltvm"
+	"github.com/meshplus/bitxid"
+)
+
+// names of did registries used in events
+const (
+	chainDIDRegistryName   = "chain-did"
+	accountDIDRegistryName = "account-did"
+)
+
+// admin actions recorded by AdminEvent
+const (
+	AdminAdded             = "AddAdmin"
+	AdminRemoved           = "RemoveAdmin"
+	SuperAdminTransferring = "TransferSuperAdmin"
+	SuperAdminAccepted     = "AcceptSuperAdmin"
+	SuperAdminRecovered    = "RecoverSuperAdmin"
+	RecoveryRejected       = "RejectRecovery"
+	RecoveryThresholdSet   = "SetRecoveryThreshold"
+)
+
+// AdminEvent is posted on every change of registry admins.
+// @Admin: admin added, removed, the new super admin, or did whose role changed
+// @Role: role granted or revoked, only set for role actions
+// @Threshold: the new recovery threshold, only set for SetRecoveryThreshold
+// @Operator: caller of the change, or proposer if approved by quorum
+// @Proposal: id of the approving proposal, only set if Quorum is true
+// @Height: height of the block executing the change
+type AdminEvent struct {
+	Registry  string
+	Action    string
+	Admin     bitxid.DID
+	Role      Role
+	Threshold uint64
+	Operator  bitxid.DID
+	Quorum    bool
+	Proposal  uint64
+	Height    uint64
+}
+
+func postAdminEvent(stub boltvm.Stub, registry, action string, admin, operator bitxid.DID) {
+	stub.PostEvent(&AdminEvent{
+		Registry: registry,
+		Action:   action,
+		Admin:    admin,
+		Operator: operator,
+		Height:   recordHeight(stub),
+	})
+}
+
+func postRecoveryThresholdEvent(stub boltvm.Stub, registry string, threshold uint64, operator bitxid.DID) {
+	stub.PostEvent(&AdminEvent{
+		Registry:  registry,
+		Action:    RecoveryThresholdSet,
+		Threshold: threshold,
+		Operator:  operator,
+		Height:    recordHeight(stub),
+	})
+}
+
+// checkRecoveryThreshold rejects a recovery threshold a single regular admin could reach.
+func checkRecoveryThreshold(threshold uint64) error {
+	if threshold == 1 {
+		return newError(ErrInvalidArgument, "recovery threshold should be 0 to disable recovery or at least 2")
+	}
+	return nil
+}
+
+// postProposalAdminEvent posts admin event of an executed proposal.
+func postProposalAdminEvent(stub boltvm.Stub, r quorumRegistry, p *Proposal) {
+	if p.Status != ProposalExecuted {
+		return
+	}
+	var action string
+	switch p.Action {
+	case ProposalAddAdmin:
+		action = AdminAdded
+	case ProposalRemoveAdmin:
+		action = AdminRemoved
+	case ProposalRecoverSuperAdmin:
+		action = SuperAdminRecovered
+	default:
+		return
+	}
+	stub.PostEvent(&AdminEvent{
+		Registry: r.name(),
+		Action:   action,
+		Admin:    p.Target,
+		Operator: p.Proposer,
+		Quorum:   true,
+		Proposal: p.ID,
+		Height:   recordHeight(stub),
+	})
+}
+
+// superAdmin gets super admin of the registry, registries
+// initialized before SuperAdmin was recorded fall back to the first admin.
+func (mr *ChainDIDRegistry) superAdmin() bitxid.DID {
+	if mr.SuperAdmin != "" {
+		return mr.SuperAdmin
+	}
+	admins := mr.Registry.GetAdmins()
+	if len(admins) == 0 {
+		return ""
+	}
+	return admins[0]
+}
+
+// superAdmin gets super admin of the registry, registries
+// initialized before SuperAdmin was recorded fall back to the first admin.
+func (dr *AccountDIDRegistry) superAdmin() bitxid.DID {
+	if dr.SuperAdmin != "" {
+		return dr.SuperAdmin
+	}
+	admins := dr.Registry.GetAdmins()
+	if len(admins) == 0 {
+		return ""
+	}
+	return admins[0]
+}
+
+func (mr *ChainDIDRegistry) name() string {
+	return chainDIDRegistryName
+}
+
+func (dr *AccountDIDRegistry) name() string {
+	return accountDIDRegistryName
+}
+
+// checkSuperAdminCandidate checks the candidate is an admin other than the super admin.
+func checkSuperAdminCandidate(r quorumRegistry, candidate bitxid.DID) error {
+	if !containsDID(r.admins(), candidate) {
+		return newError(ErrNotFound, "%s is not admin, add it as admin first", candidate)
+	}
+	if candidate == r.superAdmin() {
+		return newError(ErrAlreadyExists, "%s is already super admin", candidate)
+	}
+	return nil
+}
+
+// TransferSuperAdmin starts to transfer super admin to another admin,
+// the transfer takes effect after the new one accepts,
+// caller should be super admin.
+func (mm *ChainDIDManager) TransferSuperAdmin(caller, newSuperAdmin string) *boltvm.Response {
+	mr := mm.getChainDIDRegistry()
+
+	callerDID := bitxid.DID(caller)
+	if res := authorize(mm.Stub, mr.Initalized, caller, requireSuperAdmin(mr, callerDID)); res != nil {
+		return res
+	}
+	if err := checkSuperAdminCandidate(mr, bitxid.DID(newSuperAdmin)); err != nil {
+		return wrapErrorResponse(ErrInvalidArgument, "transfer super admin err, ", err)
+	}
+
+	mr.PendingSuperAdmin = bitxid.DID(newSuperAdmin)
+
+	mm.SetObject(ChainDIDRegistryKey, mr)
+	postAdminEvent(mm.Stub, chainDIDRegistryName, SuperAdminTransferring, mr.PendingSuperAdmin, callerDID)
+	return boltvm.Success(nil)
+}
+
+// AcceptSuperAdmin accepts super admin transferred to the caller.
+func (mm *ChainDIDManager) AcceptSuperAdmin(caller string) *boltvm.Response {
+	mr := mm.getChainDIDRegistry()
+
+	callerDID := bitxid.DID(caller)
+	if res := authorize(mm.Stub, mr.Initalized, caller); res != nil {
+		return res
+	}
+	if mr.PendingSuperAdmin == "" || mr.PendingSuperAdmin != callerDID {
+		return errorResponse(ErrInvalidStatus, "accept super admin err, super admin is not transferring to "+caller)
+	}
+	if err := checkSuperAdminCandidate(mr, callerDID); err != nil {
+		return wrapErrorResponse(ErrInvalidArgument, "accept super admin err, ", err)
+	}
+
+	mr.SuperAdmin = callerDID
+	mr.PendingSuperAdmin = ""
+
+	mm.SetObject(ChainDIDRegistryKey, mr)
+	postAdminEvent(mm.Stub, chainDIDRegistryName, SuperAdminAccepted, callerDID, callerDID)
+	return boltvm.Success(nil)
+}
+
+// GetSuperAdmin gets super admin of the registry.
+func (mm *ChainDIDManager) GetSuperAdmin() *boltvm.Response {
+	mr := mm.getChainDIDRegistry()
+
+	if res := checkInitialized(mr.Initalized); res != nil {
+		return res
+	}
+
+	return stringResponse(string(mr.superAdmin()))
+}
+
+// SetRecoveryThreshold sets approvals of regular admins needed to recover
+// a lost super admin, 0 disables recovery, 1 is rejected; recovery also needs
+// quorum enabled, caller should be super admin, it can not be proposed.
+func (mm *ChainDIDManager) SetRecoveryThreshold(caller string, threshold uint64) *boltvm.Response {
+	mr := mm.getChainDIDRegistry()
+
+	callerDID := bitxid.DID(caller)
+	if res := authorize(mm.Stub, mr.Initalized, caller, requireSuperAdmin(mr, callerDID)); res != nil {
+		return res
+	}
+	if err := checkRecoveryThreshold(threshold); err != nil {
+		return wrapErrorResponse(ErrInvalidArgument, "set recovery threshold err, ", err)
+	}
+
+	mr.Quorum.RecoveryThreshold = threshold
+
+	mm.SetObject(ChainDIDRegistryKey, mr)
+	postRecoveryThresholdEvent(mm.Stub, chainDIDRegistryName, threshold, callerDID)
+	return boltvm.Success(nil)
+}
+
+// TransferSuperAdmin starts to transfer super admin to another admin,
+// the transfer takes effect after the new one accepts,
+// caller should be super admin.
+func (dm *AccountDIDManager) TransferSuperAdmin(caller, newSuperAdmin string) *boltvm.Response {
+	dr := dm.getAccountDIDRegistry()
+
+	callerDID := bitxid.DID(caller)
+	if res := dr.authorize(dm.Stub, caller, requireSuperAdmin(dr, callerDID)); res != nil {
+		return res
+	}
+	if err := checkSuperAdminCandidate(dr, bitxid.DID(newSuperAdmin)); err != nil {
+		return wrapErrorResponse(ErrInvalidArgument, "transfer super admin err, ", err)
+	}
+
+	dr.PendingSuperAdmin = bitxid.DID(newSuperAdmin)
+
+	dm.SetObject(AccountDIDRegistryKey, dr)
+	postAdminEvent(dm.Stub, accountDIDRegistryName, SuperAdminTransferring, dr.PendingSuperAdmin, callerDID)
+	return boltvm.Success(nil)
+}
+
+// AcceptSuperAdmin accepts super admin transferred to the caller.
+func (dm *AccountDIDManager) AcceptSuperAdmin(caller string) *boltvm.Response {
+	dr := dm.getAccountDIDRegistry()
+
+	callerDID := bitxid.DID(caller)
+	if res := dr.authorize(dm.Stub, caller); res != nil {
+		return res
+	}
+	if dr.PendingSuperAdmin == "" || dr.PendingSuperAdmin != callerDID {
+		return errorResponse(ErrInvalidStatus, "accept super admin err, super admin is not transferring to "+caller)
+	}
+	if err := checkSuperAdminCandidate(dr, callerDID); err != nil {
+		return wrapErrorResponse(ErrInvalidArgument, "accept super admin err, ", err)
+	}
+
+	dr.SuperAdmin = callerDID
+	dr.PendingSuperAdmin = ""
+
+	dm.SetObject(AccountDIDRegistryKey, dr)
+	postAdminEvent(dm.Stub, accountDIDRegistryName, SuperAdminAccepted, callerDID, callerDID)
+	return boltvm.Success(nil)
+}
+
+// GetSuperAdmin gets super admin of the registry.
+func (dm *AccountDIDManager) GetSuperAdmin() *boltvm.Response {
+	dr := dm.getAccountDIDRegistry()
+
+	if res := checkInitialized(dr.Initalized); res != nil {
+		return res
+	}
+
+	return stringResponse(string(dr.superAdmin()))
+}
+
+// SetRecoveryThreshold sets approvals of regular admins needed to recover
+// a lost super admin, 0 disables recovery, 1 is rejected; recovery also needs
+// quorum enabled, caller should be super admin, it can not be proposed.
+func (dm *AccountDIDManager) SetRecoveryThreshold(caller string, threshold uint64) *boltvm.Response {
+	dr := dm.getAccountDIDRegistry()
+
+	callerDID := bitxid.DID(caller)
+	if res := dr.authorize(dm.Stub, caller, requireSuperAdmin(dr, callerDID)); res != nil {
+		return res
+	}
+	if err := checkRecoveryThreshold(threshold); err != nil {
+		return wrapErrorResponse(ErrInvalidArgument, "set recovery threshold err, ", err)
+	}
+
+	dr.Quorum.RecoveryThreshold = threshold
+
+	dm.SetObject(AccountDIDRegistryKey, dr)
+	postRecoveryThresholdEvent(dm.Stub, accountDIDRegistryName, threshold, callerDID)
+	return boltvm.Success(nil)
+}
@@ -0,0 +1,438 @@
+package contracts
+
+import (
+	"testing"
+
+	"github.com/meshplus/bitxhub-core/boltvm"
+	"github.com/meshplus/bitxid"
+	"github.com/meshplus/did-registry/didpb"
+	"github.com/stretchr/testify/require"
+)
+
+func superAdmin(t *testing.T, res *boltvm.Response) string {
+	s := &didpb.String{}
+	decode(t, res, s)
+	return s.Value
+}
+
+func TestAccountDIDManager_SuperAdmin(t *testing.T) {
+	var other *testAccount
+	withAdmin := func(e *testEnv) {
+		other = e.newAdmin()
+	}
+	transferred := func(e *testEnv) {
+		withAdmin(e)
+		requireOK(e.t, e.as(e.admin).account.TransferSuperAdmin(e.admin.did, other.did))
+	}
+	runCalls(t, []call{
+		{
+			name: "get",
+			run:  func(e *testEnv) *boltvm.Response { return e.account.GetSuperAdmin() },
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, e.admin.did, superAdmin(t, res))
+			},
+		},
+		{
+			name:  "transfer",
+			setup: withAdmin,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.TransferSuperAdmin(e.admin.did, other.did)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, e.admin.did, superAdmin(t, e.account.GetSuperAdmin()))
+				ev := lastAdminEvent(t, e.accountStub)
+				require.Equal(t, SuperAdminTransferring, ev.Action)
+				require.Equal(t, bitxid.DID(other.did), ev.Admin)
+			},
+		},
+		{
+			name:  "transfer by regular admin",
+			setup: withAdmin,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(other).account.TransferSuperAdmin(other.did, other.did)
+			},
+			code: ErrNotSuperAdmin,
+		},
+		{
+			name: "transfer to non admin",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.TransferSuperAdmin(e.admin.did, e.user.did)
+			},
+			code: ErrNotFound,
+		},
+		{
+			name: "transfer to itself",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.TransferSuperAdmin(e.admin.did, e.admin.did)
+			},
+			code: ErrAlreadyExists,
+		},
+		{
+			name:  "transfer caller mismatch",
+			setup: withAdmin,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(other).account.TransferSuperAdmin(e.admin.did, other.did)
+			},
+			code: ErrCallerMismatch,
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				requireCode(t, e.as(other).account.AcceptSuperAdmin(other.did), ErrInvalidStatus)
+			},
+		},
+		{
+			name:  "accept",
+			setup: transferred,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(other).account.AcceptSuperAdmin(other.did)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, other.did, superAdmin(t, e.account.GetSuperAdmin()))
+				require.Equal(t, SuperAdminAccepted, lastAdminEvent(t, e.accountStub).Action)
+				requireCode(t, e.as(e.admin).account.GrantRole(e.admin.did, e.user.did, string(RoleFreezer)), ErrNotSuperAdmin)
+				requireOK(t, e.as(other).account.GrantRole(other.did, e.user.did, string(RoleFreezer)))
+			},
+		},
+		{
+			name:  "accept by others",
+			setup: transferred,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).account.AcceptSuperAdmin(e.user.did)
+			},
+			code: ErrInvalidStatus,
+		},
+		{
+			name:  "accept caller mismatch",
+			setup: transferred,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).account.AcceptSuperAdmin(other.did)
+			},
+			code: ErrCallerMismatch,
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, e.admin.did, superAdmin(t, e.account.GetSuperAdmin()))
+			},
+		},
+		{
+			name: "accept after removed",
+			setup: func(e *testEnv) {
+				transferred(e)
+				requireOK(e.t, e.as(e.admin).account.RemoveAdmin(e.admin.did, other.did))
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(other).account.AcceptSuperAdmin(other.did)
+			},
+			code: ErrInvalidStatus,
+		},
+		{
+			name: "set recovery threshold",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.SetRecoveryThreshold(e.admin.did, 2)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, uint64(2), accountQuorum(t, e).RecoveryThreshold)
+			},
+		},
+		{
+			name:  "set recovery threshold under quorum",
+			setup: quorumEnabled,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.SetRecoveryThreshold(e.admin.did, 2)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				ev := lastAdminEvent(t, e.accountStub)
+				require.Equal(t, RecoveryThresholdSet, ev.Action)
+				require.Equal(t, uint64(2), ev.Threshold)
+				require.Equal(t, bitxid.DID(e.admin.did), ev.Operator)
+			},
+		},
+		{
+			name: "set recovery threshold to one",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.SetRecoveryThreshold(e.admin.did, 1)
+			},
+			code: ErrInvalidArgument,
+		},
+		{
+			name:  "set recovery threshold by regular admin",
+			setup: withAdmin,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(other).account.SetRecoveryThreshold(other.did, 2)
+			},
+			code: ErrNotSuperAdmin,
+		},
+		{
+			name:  "set recovery threshold caller mismatch",
+			setup: withAdmin,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(other).account.SetRecoveryThreshold(e.admin.did, 2)
+			},
+			code: ErrCallerMismatch,
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Zero(t, accountQuorum(t, e).RecoveryThreshold)
+			},
+		},
+		{
+			name: "propose recovery threshold",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.Propose(e.admin.did, "SetRecoveryThreshold", "", 1)
+			},
+			code: ErrInvalidArgument,
+		},
+	})
+}
+
+func TestAccountDIDManager_RecoverSuperAdmin(t *testing.T) {
+	e := newInitedEnv(t)
+	a1 := e.newAdmin()
+	a2 := e.newAdmin()
+	requireOK(t, e.as(e.admin).account.SetRecoveryThreshold(e.admin.did, 2))
+	// recovery needs quorum
+	requireCode(t, e.as(a1).account.Propose(a1.did, string(ProposalRecoverSuperAdmin), a1.did, 0), ErrInvalidStatus)
+	enableQuorum(e)
+
+	requireCode(t, e.as(a1).account.Propose(a1.did, string(ProposalRecoverSuperAdmin), e.user.did, 0), ErrNotFound)
+	requireOK(t, e.as(a1).account.Propose(a1.did, string(ProposalRecoverSuperAdmin), a1.did, 0))
+	// votes of the lost super admin are not counted
+	p := decodeProposal(t, e.as(e.admin).account.Vote(e.admin.did, 0, true))
+	require.Equal(t, string(ProposalPending), p.Status)
+	require.Equal(t, uint64(2), p.Required)
+
+	// an approved recovery is time-locked
+	p = decodeProposal(t, e.as(a2).account.Vote(a2.did, 0, true))
+	require.Equal(t, string(ProposalApproved), p.Status)
+	require.Equal(t, testTime+superAdminRecoveryDelay, p.Unlock)
+	require.Equal(t, e.admin.did, superAdmin(t, e.account.GetSuperAdmin()))
+	requireCode(t, e.as(a2).account.Vote(a2.did, 0, true), ErrInvalidStatus)
+	requireCode(t, e.as(a1).account.ExecuteProposal(a1.did, 0), ErrInvalidStatus)
+
+	e.setBlock(testHeight+1, testTime+superAdminRecoveryDelay)
+	requireCode(t, e.as(e.user).account.ExecuteProposal(e.user.did, 0), ErrNotAdmin)
+	p = decodeProposal(t, e.as(a1).account.ExecuteProposal(a1.did, 0))
+	require.Equal(t, string(ProposalExecuted), p.Status)
+	require.Equal(t, a1.did, superAdmin(t, e.account.GetSuperAdmin()))
+	ev := lastAdminEvent(t, e.accountStub)
+	require.Equal(t, SuperAdminRecovered, ev.Action)
+	require.True(t, ev.Quorum)
+	require.Equal(t, uint64(0), ev.Proposal)
+	requireCode(t, e.as(a1).account.ExecuteProposal(a1.did, 0), ErrInvalidStatus)
+}
+
+func TestAccountDIDManager_RejectRecovery(t *testing.T) {
+	// recoveryApproved has 2 regular admins approve recovering super admin to a1.
+	var a1 *testAccount
+	recoveryApproved := func(e *testEnv) {
+		a1 = e.newAdmin()
+		a2 := e.newAdmin()
+		requireOK(e.t, e.as(e.admin).account.SetRecoveryThreshold(e.admin.did, 2))
+		enableQuorum(e)
+		requireOK(e.t, e.as(a1).account.Propose(a1.did, string(ProposalRecoverSuperAdmin), a1.did, 0))
+		requireOK(e.t, e.as(a2).account.Vote(a2.did, 0, true))
+	}
+	runCalls(t, []call{
+		{
+			name:  "by super admin",
+			setup: recoveryApproved,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.RejectRecovery(e.admin.did, 0)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, string(ProposalRejected), decodeProposal(t, res).Status)
+				require.Equal(t, RecoveryRejected, lastAdminEvent(t, e.accountStub).Action)
+				e.setBlock(testHeight+1, testTime+superAdminRecoveryDelay)
+				requireCode(t, e.as(a1).account.ExecuteProposal(a1.did, 0), ErrInvalidStatus)
+				require.Equal(t, e.admin.did, superAdmin(t, e.account.GetSuperAdmin()))
+			},
+		},
+		{
+			name:  "by regular admin",
+			setup: recoveryApproved,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(a1).account.RejectRecovery(a1.did, 0)
+			},
+			code: ErrNotSuperAdmin,
+		},
+		{
+			name:  "caller mismatch",
+			setup: recoveryApproved,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(a1).account.RejectRecovery(e.admin.did, 0)
+			},
+			code: ErrCallerMismatch,
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, string(ProposalApproved), decodeProposal(t, e.account.GetProposal(0)).Status)
+			},
+		},
+		{
+			name: "other proposal",
+			setup: func(e *testEnv) {
+				quorumEnabled(e)
+				requireOK(e.t, e.as(e.admin).account.Propose(e.admin.did, string(ProposalFreeze), e.user.did, 0))
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.RejectRecovery(e.admin.did, 0)
+			},
+			code: ErrInvalidArgument,
+		},
+		{
+			name: "not existed",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.RejectRecovery(e.admin.did, 0)
+			},
+			code: ErrNotFound,
+		},
+	})
+}
+
+func TestAccountDIDManager_ExecuteProposal(t *testing.T) {
+	var other *testAccount
+	proposed := func(e *testEnv) {
+		other = enableQuorum(e)
+		requireOK(e.t, e.as(e.admin).account.Propose(e.admin.did, string(ProposalFreeze), e.user.did, 0))
+	}
+	runCalls(t, []call{
+		{
+			name:  "not approved",
+			setup: proposed,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(other).account.ExecuteProposal(other.did, 0)
+			},
+			code: ErrInvalidStatus,
+		},
+		{
+			name:  "by others",
+			setup: proposed,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).account.ExecuteProposal(e.user.did, 0)
+			},
+			code: ErrNotAdmin,
+		},
+		{
+			name:  "caller mismatch",
+			setup: proposed,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).account.ExecuteProposal(other.did, 0)
+			},
+			code: ErrCallerMismatch,
+		},
+		{
+			name: "not existed",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).account.ExecuteProposal(e.admin.did, 0)
+			},
+			code: ErrNotFound,
+		},
+	})
+}
+
+func TestChainDIDManager_SuperAdmin(t *testing.T) {
+	e := newInitedEnv(t)
+	other := e.newAdmin()
+	require.Equal(t, e.admin.did, superAdmin(t, e.chain.GetSuperAdmin()))
+
+	requireCode(t, e.as(other).chain.AcceptSuperAdmin(other.did), ErrInvalidStatus)
+	requireOK(t, e.as(e.admin).chain.TransferSuperAdmin(e.admin.did, other.did))
+	requireOK(t, e.as(other).chain.AcceptSuperAdmin(other.did))
+	require.Equal(t, other.did, superAdmin(t, e.chain.GetSuperAdmin()))
+
+	requireCode(t, e.as(e.admin).chain.SetRecoveryThreshold(e.admin.did, 2), ErrNotSuperAdmin)
+	requireOK(t, e.as(other).chain.SetRecoveryThreshold(other.did, 2))
+	q := &didpb.Quorum{}
+	decode(t, e.chain.GetQuorum(), q)
+	require.Equal(t, uint64(2), q.RecoveryThreshold)
+}
+
+func TestChainDIDManager_SuperAdminErrors(t *testing.T) {
+	var other *testAccount
+	withAdmin := func(e *testEnv) {
+		other = e.newAdmin()
+	}
+	runCalls(t, []call{
+		{
+			name:  "transfer by regular admin",
+			setup: withAdmin,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(other).chain.TransferSuperAdmin(other.did, other.did)
+			},
+			code: ErrNotSuperAdmin,
+		},
+		{
+			name:  "transfer caller mismatch",
+			setup: withAdmin,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(other).chain.TransferSuperAdmin(e.admin.did, other.did)
+			},
+			code: ErrCallerMismatch,
+		},
+		{
+			name: "transfer to non admin",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).chain.TransferSuperAdmin(e.admin.did, e.user.did)
+			},
+			code: ErrNotFound,
+		},
+		{
+			name: "accept caller mismatch",
+			setup: func(e *testEnv) {
+				withAdmin(e)
+				requireOK(e.t, e.as(e.admin).chain.TransferSuperAdmin(e.admin.did, other.did))
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).chain.AcceptSuperAdmin(other.did)
+			},
+			code: ErrCallerMismatch,
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, e.admin.did, superAdmin(t, e.chain.GetSuperAdmin()))
+			},
+		},
+		{
+			name:  "set recovery threshold caller mismatch",
+			setup: withAdmin,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(other).chain.SetRecoveryThreshold(e.admin.did, 2)
+			},
+			code: ErrCallerMismatch,
+		},
+		{
+			name: "execute by others",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).chain.ExecuteProposal(e.user.did, 0)
+			},
+			code: ErrNotAdmin,
+		},
+		{
+			name: "execute caller mismatch",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).chain.ExecuteProposal(e.admin.did, 0)
+			},
+			code: ErrCallerMismatch,
+		},
+		{
+			name: "execute not existed",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).chain.ExecuteProposal(e.admin.did, 0)
+			},
+			code: ErrNotFound,
+		},
+		{
+			name:  "reject by regular admin",
+			setup: withAdmin,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(other).chain.RejectRecovery(other.did, 0)
+			},
+			code: ErrNotSuperAdmin,
+		},
+		{
+			name:  "reject caller mismatch",
+			setup: withAdmin,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(other).chain.RejectRecovery(e.admin.did, 0)
+			},
+			code: ErrCallerMismatch,
+		},
+		{
+			name: "reject not existed",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).chain.RejectRecovery(e.admin.did, 0)
+			},
+			code: ErrNotFound,
+		},
+	})
+}
@@ -0,0 +1,254 @@
+package contracts
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/meshplus/bitxhub-core/boltvm"
+	"github.com/meshplus/bitxid"
+)
+
+// CommittedClaim is the claim content of a selective disclosure credential,
+// only salted hash commitments of claim fields are stored on chain.
+// @ID: holder of the credential, kept in plain form for indexing
+// @Fields: field name to hex encoded commitment, can be omitted if Root is set
+// @Root: hex encoded merkle root over commitments sorted by field name
+type CommittedClaim struct {
+	ID     string            `json:"id"`
+	Fields map[string]string `json:"fields"`
+	Root   string            `json:"root"`
+}
+
+// DisclosureProof proves a commitment is a leaf under the merkle root.
+// @Index: position of the leaf
+// @Size: number of leaves, only used if fields are not committed one by one
+// @Siblings: sibling hashes from the leaf up to the root
+type DisclosureProof struct {
+	Index    uint64
+	Size     uint64
+	Siblings [][]byte
+}
+
+// DisclosureVerdict represents result of verifying a revealed field.
+type DisclosureVerdict struct {
+	ID     string       // id of the credential
+	Field  string       // revealed field name
+	Valid  bool         // whether the revealed value matches the commitment
+	Status VCStatusType // effective status of the credential
+	Error  string       // reason if not valid
+}
+
+// CommitClaimField computes commitment of a claim field,
+// which is sha256 of json encoded [salt, field, value].
+func CommitClaimField(salt, field, value string) []byte {
+	b, _ := json.Marshal([]string{salt, field, value})
+	hash := sha256.Sum256(b)
+	return hash[:]
+}
+
+// ClaimMerkleRoot computes merkle root of commitments,
+// a node without sibling is carried to the upper level as it is.
+func ClaimMerkleRoot(leaves [][]byte) []byte {
+	if len(leaves) == 0 {
+		return nil
+	}
+	level := leaves
+	for len(level) > 1 {
+		var next [][]byte
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+			next = append(next, hashPair(level[i], level[i+1]))
+		}
+		level = next
+	}
+	return level[0]
+}
+
+func hashPair(left, right []byte) []byte {
+	hash := sha256.Sum256(append(append([]byte{}, left...), right...))
+	return hash[:]
+}
+
+// verifyMerkleProof checks leaf is at proof.Index under root of a tree with size leaves.
+func verifyMerkleProof(leaf []byte, proof *DisclosureProof, size uint64, root []byte) bool {
+	if proof.Index >= size {
+		return false
+	}
+	node := leaf
+	index, width := proof.Index, size
+	siblings := proof.Siblings
+	for width > 1 {
+		if index%2 == 1 {
+			if len(siblings) == 0 {
+				return false
+			}
+			node = hashPair(siblings[0], node)
+			siblings = siblings[1:]
+		} else if index+1 < width {
+			if len(siblings) == 0 {
+				return false
+			}
+			node = hashPair(node, siblings[0])
+			siblings = siblings[1:]
+		}
+		index, width = index/2, (width+1)/2
+	}
+	return len(siblings) == 0 && bytes.Equal(node, root)
+}
+
+// StoreCommittedVC stores a selective disclosure credential,
+// claim of the credential should be a json encoded CommittedClaim,
+// caller should be the issuer.
+func (mm *VCManager) StoreCommittedVC(caller string, cb []byte, docb []byte) *boltvm.Response {
+	vcr := mm.getVCRegistry()
+
+	callerDID := bitxid.DID(caller)
+	if res := authorize(mm.Stub, vcr.Initalized, caller); res != nil {
+		return res
+	}
+
+	c := &bitxid.Credential{}
+	err := c.Unmarshal(cb)
+	if err != nil {
+		return wrapErrorResponse(ErrInvalidFormat, "params unmarshal err: ", err)
+	}
+
+	cc, err := parseCommittedClaim(c.Claim)
+	if err != nil {
+		return wrapErrorResponse(ErrInvalidArgument, "store vc err: ", err)
+	}
+
+	res := mm.storeVC(vcr, callerDID, c, docb)
+	if !res.Ok {
+		return res
+	}
+	mm.SetObject(commitmentKey(c.ID), cc)
+
+	return res
+}
+
+// VerifyDisclosure checks a field revealed by the holder against the commitment
+// of the credential, proof is marshaled DisclosureProof and required only
+// if the credential commits to a merkle root without field commitments.
+func (mm *VCManager) VerifyDisclosure(cid, field, value, salt string, proof []byte) *boltvm.Response {
+	vcr := mm.getVCRegistry()
+
+	if res := checkInitialized(vcr.Initalized); res != nil {
+		return res
+	}
+	clk := blockClock(mm.Stub)
+
+	c, err := vcr.Registry.GetVC(cid)
+	if err != nil {
+		return wrapErrorResponse(ErrInternal, "get vc err: ", err)
+	}
+	if c == nil {
+		return errorResponse(ErrNotFound, "get vc err: vc "+cid+" not existed")
+	}
+	cc, ok := mm.getCommittedClaim(cid)
+	if !ok {
+		return errorResponse(ErrInvalidArgument, "verify disclosure err, vc "+cid+" is not a selective disclosure credential")
+	}
+
+	verdict := &DisclosureVerdict{
+		ID:     cid,
+		Field:  field,
+		Status: mm.getVCStatus(c, clk).Status,
+	}
+	if err := verifyCommitment(cc, field, CommitClaimField(salt, field, value), proof); err != nil {
+		verdict.Error = err.Error()
+	} else {
+		verdict.Valid = true
+	}
+
+	return success(verdict.toPB())
+}
+
+// verifyCommitment checks leaf against the field commitment,
+// and against the merkle root if a proof is given or there is no field commitment.
+func verifyCommitment(cc *CommittedClaim, field string, leaf []byte, proofb []byte) error {
+	if commitment, ok := cc.Fields[field]; ok {
+		if hex.EncodeToString(leaf) != commitment {
+			return fmt.Errorf("revealed value of %s not match the commitment", field)
+		}
+	} else if len(cc.Fields) != 0 {
+		return fmt.Errorf("field %s not committed", field)
+	}
+
+	if len(proofb) == 0 && len(cc.Fields) != 0 {
+		return nil
+	}
+	if cc.Root == "" {
+		return fmt.Errorf("no merkle root committed")
+	}
+	proof := &DisclosureProof{}
+	if err := bitxid.Unmarshal(proofb, proof); err != nil {
+		return fmt.Errorf("proof unmarshal: %w", err)
+	}
+	root, err := hex.DecodeString(cc.Root)
+	if err != nil {
+		return fmt.Errorf("decode merkle root: %w", err)
+	}
+	size := proof.Size
+	if len(cc.Fields) != 0 {
+		size = uint64(len(cc.Fields))
+		if names := sortedFields(cc); proof.Index >= size || names[proof.Index] != field {
+			return fmt.Errorf("proof index not match field %s", field)
+		}
+	}
+	if !verifyMerkleProof(leaf, proof, size, root) {
+		return fmt.Errorf("merkle proof of %s not match the root", field)
+	}
+	return nil
+}
+
+// parseCommittedClaim parses and checks claim content of a selective disclosure credential.
+func parseCommittedClaim(claim string) (*CommittedClaim, error) {
+	cc := &CommittedClaim{}
+	if err := json.Unmarshal([]byte(claim), cc); err != nil {
+		return nil, fmt.Errorf("committed claim unmarshal: %w", err)
+	}
+	if len(cc.Fields) == 0 && cc.Root == "" {
+		return nil, fmt.Errorf("committed claim has neither field commitments nor merkle root")
+	}
+	var leaves [][]byte
+	for _, name := range sortedFields(cc) {
+		leaf, err := hex.DecodeString(cc.Fields[name])
+		if err != nil || len(leaf) != sha256.Size {
+			return nil, fmt.Errorf("invalid commitment of field %s", name)
+		}
+		leaves = append(leaves, leaf)
+	}
+	if cc.Root != "" && len(leaves) != 0 {
+		if hex.EncodeToString(ClaimMerkleRoot(leaves)) != cc.Root {
+			return nil, fmt.Errorf("merkle root not match field commitments")
+		}
+	}
+	return cc, nil
+}
+
+func sortedFields(cc *CommittedClaim) []string {
+	var names []string
+	for name := range cc.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (mm *VCManager) getCommittedClaim(cid string) (*CommittedClaim, bool) {
+	cc := &CommittedClaim{}
+	ok := mm.GetObject(commitmentKey(cid), cc)
+	return cc, ok
+}
+
+func commitmentKey(cid string) string {
+	return "commitment-" + cid
+}
@@ -0,0 +1,219 @@
+package contracts
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/meshplus/bitxhub-core/boltvm"
+	"github.com/meshplus/bitxid"
+	"github.com/meshplus/did-registry/didpb"
+	"github.com/stretchr/testify/require"
+)
+
+const testSalt = "salt"
+
+// testFields are claim fields of committed credentials, age sorts before name.
+var testFields = map[string]string{"age": "18", "name": "alice"}
+
+// commitments returns leaves of testFields in field name order.
+func commitments() [][]byte {
+	return [][]byte{
+		CommitClaimField(testSalt, "age", testFields["age"]),
+		CommitClaimField(testSalt, "name", testFields["name"]),
+	}
+}
+
+// committedClaim returns claim content committing to testFields of holder,
+// by field commitments if withFields, and by merkle root if withRoot.
+func committedClaim(t *testing.T, holder string, withFields, withRoot bool) string {
+	leaves := commitments()
+	cc := &CommittedClaim{ID: holder}
+	if withFields {
+		cc.Fields = map[string]string{
+			"age":  hex.EncodeToString(leaves[0]),
+			"name": hex.EncodeToString(leaves[1]),
+		}
+	}
+	if withRoot {
+		cc.Root = hex.EncodeToString(ClaimMerkleRoot(leaves))
+	}
+	b, err := json.Marshal(cc)
+	require.Nil(t, err)
+	return string(b)
+}
+
+// storeCommitted stores testCID of testCTID committing to claim, issued by user.
+func storeCommitted(e *testEnv, claim string) *boltvm.Response {
+	c := e.credential(e.user, testCID, testCTID, claim)
+	cb, err := c.Marshal()
+	require.Nil(e.t, err)
+	return e.as(e.user).vc.StoreCommittedVC(e.user.did, cb, e.userDoc)
+}
+
+// nameProof proves the commitment of name under the root of testFields.
+func nameProof(t *testing.T, index uint64) []byte {
+	proof, err := bitxid.Marshal(&DisclosureProof{
+		Index:    index,
+		Size:     2,
+		Siblings: [][]byte{commitments()[0]},
+	})
+	require.Nil(t, err)
+	return proof
+}
+
+func TestVCManager_Disclosure(t *testing.T) {
+	committed := func(withFields, withRoot bool) func(e *testEnv) {
+		return func(e *testEnv) {
+			e.newClaimTyp(e.user, testCTID, "age", "name")
+			requireOK(e.t, storeCommitted(e, committedClaim(e.t, e.admin.did, withFields, withRoot)))
+		}
+	}
+	disclosed := func(valid bool) func(t *testing.T, e *testEnv, res *boltvm.Response) {
+		return func(t *testing.T, e *testEnv, res *boltvm.Response) {
+			verdict := &didpb.DisclosureVerdict{}
+			decode(t, res, verdict)
+			require.Equal(t, valid, verdict.Valid, verdict.Error)
+			require.Equal(t, string(VCActive), verdict.Status)
+		}
+	}
+	runCalls(t, []call{
+		{
+			name:  "field",
+			setup: committed(true, false),
+			run: func(e *testEnv) *boltvm.Response {
+				return e.vc.VerifyDisclosure(testCID, "name", "alice", testSalt, nil)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				disclosed(true)(t, e, res)
+				require.True(t, verifyVC(t, e, testCID).Valid)
+				_, ids := pageIDs(t, e.vc.ListVCsByHolder(e.admin.did, 0, 0))
+				require.Equal(t, []string{testCID}, ids)
+			},
+		},
+		{
+			name:  "wrong value",
+			setup: committed(true, false),
+			run: func(e *testEnv) *boltvm.Response {
+				return e.vc.VerifyDisclosure(testCID, "name", "bob", testSalt, nil)
+			},
+			check: disclosed(false),
+		},
+		{
+			name:  "field not committed",
+			setup: committed(true, false),
+			run: func(e *testEnv) *boltvm.Response {
+				return e.vc.VerifyDisclosure(testCID, "email", "alice@example.com", testSalt, nil)
+			},
+			check: disclosed(false),
+		},
+		{
+			name:  "root",
+			setup: committed(false, true),
+			run: func(e *testEnv) *boltvm.Response {
+				return e.vc.VerifyDisclosure(testCID, "name", "alice", testSalt, nameProof(e.t, 1))
+			},
+			check: disclosed(true),
+		},
+		{
+			name:  "root with wrong proof",
+			setup: committed(false, true),
+			run: func(e *testEnv) *boltvm.Response {
+				return e.vc.VerifyDisclosure(testCID, "name", "alice", testSalt, nameProof(e.t, 0))
+			},
+			check: disclosed(false),
+		},
+		{
+			name:  "root without proof",
+			setup: committed(false, true),
+			run: func(e *testEnv) *boltvm.Response {
+				return e.vc.VerifyDisclosure(testCID, "name", "alice", testSalt, nil)
+			},
+			check: disclosed(false),
+		},
+		{
+			name:  "fields and root",
+			setup: committed(true, true),
+			run: func(e *testEnv) *boltvm.Response {
+				return e.vc.VerifyDisclosure(testCID, "name", "alice", testSalt, nameProof(e.t, 1))
+			},
+			check: disclosed(true),
+		},
+		{
+			name:  "proof of another field",
+			setup: committed(true, true),
+			run: func(e *testEnv) *boltvm.Response {
+				return e.vc.VerifyDisclosure(testCID, "name", "alice", testSalt, nameProof(e.t, 0))
+			},
+			check: disclosed(false),
+		},
+		{
+			name:  "not committed credential",
+			setup: issued,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.vc.VerifyDisclosure(testCID, "name", "alice", testSalt, nil)
+			},
+			code: ErrInvalidArgument,
+		},
+		{
+			name: "not existed",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.vc.VerifyDisclosure(testCID, "name", "alice", testSalt, nil)
+			},
+			code: ErrNotFound,
+		},
+		{
+			name:  "store without commitment",
+			setup: func(e *testEnv) { e.newClaimTyp(e.user, testCTID, "name") },
+			run: func(e *testEnv) *boltvm.Response {
+				return storeCommitted(e, committedClaim(e.t, e.admin.did, false, false))
+			},
+			code: ErrInvalidArgument,
+		},
+		{
+			name:  "store with root not match",
+			setup: func(e *testEnv) { e.newClaimTyp(e.user, testCTID, "name") },
+			run: func(e *testEnv) *boltvm.Response {
+				claim := committedClaim(e.t, e.admin.did, true, false)
+				cc := &CommittedClaim{}
+				require.Nil(e.t, json.Unmarshal([]byte(claim), cc))
+				cc.Root = cc.Fields["name"]
+				b, err := json.Marshal(cc)
+				require.Nil(e.t, err)
+				return storeCommitted(e, string(b))
+			},
+			code: ErrInvalidArgument,
+		},
+		{
+			name:  "store failed",
+			setup: committed(true, false),
+			run: func(e *testEnv) *boltvm.Response {
+				return storeCommitted(e, committedClaim(e.t, e.admin.did, true, false))
+			},
+			code: ErrAlreadyExists,
+		},
+		{
+			name:  "store by others",
+			setup: func(e *testEnv) { e.newClaimTyp(e.user, testCTID, "age", "name") },
+			run: func(e *testEnv) *boltvm.Response {
+				cb, err := e.credential(e.user, testCID, testCTID, committedClaim(e.t, e.admin.did, true, false)).Marshal()
+				require.Nil(e.t, err)
+				return e.as(e.admin).vc.StoreCommittedVC(e.admin.did, cb, e.userDoc)
+			},
+			code: ErrNotOwner,
+		},
+		{
+			name:  "store caller mismatch",
+			setup: func(e *testEnv) { e.newClaimTyp(e.user, testCTID, "age", "name") },
+			run: func(e *testEnv) *boltvm.Response {
+				cb, err := e.credential(e.user, testCID, testCTID, committedClaim(e.t, e.admin.did, true, false)).Marshal()
+				require.Nil(e.t, err)
+				return e.as(e.admin).vc.StoreCommittedVC(e.user.did, cb, e.userDoc)
+			},
+			code: ErrCallerMismatch,
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				requireCode(t, e.vc.GetVC(testCID), ErrNotFound)
+			},
+		},
+	})
+}
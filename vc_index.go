@@ -0,0 +1,163 @@
+package contracts
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/meshplus/bitxhub-core/boltvm"
+	"github.com/meshplus/bitxid"
+)
+
+// kinds of secondary credential index
+const (
+	vcIndexIssuer   = "issuer"
+	vcIndexHolder   = "holder"
+	vcIndexClaimTyp = "claimtyp"
+)
+
+// maxPageLimit is the max number of items returned by a list query.
+const maxPageLimit = 100
+
+// VCSummary is the content-free view of a credential returned by list queries.
+type VCSummary struct {
+	ID         string
+	Typ        string
+	Issuer     bitxid.DID
+	Holder     bitxid.DID
+	Issued     uint64
+	Expiration uint64
+	Status     VCStatusType
+}
+
+// VCPage represents a page of list query.
+// @Total: number of credentials under the index
+type VCPage struct {
+	Total uint64
+	Items []*VCSummary
+}
+
+// credentialHolder gets holder of the credential,
+// which is the "id" field of the claim (credentialSubject.id in W3C VC).
+func credentialHolder(c *bitxid.Credential) bitxid.DID {
+	subject := struct {
+		ID string `json:"id"`
+	}{}
+	if err := json.Unmarshal([]byte(c.Claim), &subject); err != nil {
+		return ""
+	}
+	return bitxid.DID(subject.ID)
+}
+
+// indexVC adds the credential to issuer, holder and claim type indexes.
+func (mm *VCManager) indexVC(c *bitxid.Credential) {
+	mm.addToIndex(vcIndexIssuer, string(c.Issuer), c.ID)
+	if holder := credentialHolder(c); holder != "" {
+		mm.addToIndex(vcIndexHolder, string(holder), c.ID)
+	}
+	mm.addToIndex(vcIndexClaimTyp, c.Typ, c.ID)
+}
+
+// unindexVC removes the credential from issuer, holder and claim type indexes.
+func (mm *VCManager) unindexVC(c *bitxid.Credential) {
+	mm.removeFromIndex(vcIndexIssuer, string(c.Issuer), c.ID)
+	if holder := credentialHolder(c); holder != "" {
+		mm.removeFromIndex(vcIndexHolder, string(holder), c.ID)
+	}
+	mm.removeFromIndex(vcIndexClaimTyp, c.Typ, c.ID)
+}
+
+// addToIndex appends cid under the index, every entry is stored under its own key
+// so that appending doesn't rewrite the whole index.
+func (mm *VCManager) addToIndex(kind, value, cid string) {
+	var count uint64
+	mm.GetObject(vcIndexCountKey(kind, value), &count)
+	mm.SetObject(vcIndexKey(kind, value, count), cid)
+	mm.SetObject(vcIndexPosKey(kind, value, cid), count)
+	mm.SetObject(vcIndexCountKey(kind, value), count+1)
+}
+
+// removeFromIndex removes cid from the index by moving the last entry into its place.
+func (mm *VCManager) removeFromIndex(kind, value, cid string) {
+	var pos, count uint64
+	if !mm.GetObject(vcIndexPosKey(kind, value, cid), &pos) {
+		return
+	}
+	mm.GetObject(vcIndexCountKey(kind, value), &count)
+	last := count - 1
+	if pos != last {
+		var lastCID string
+		mm.GetObject(vcIndexKey(kind, value, last), &lastCID)
+		mm.SetObject(vcIndexKey(kind, value, pos), lastCID)
+		mm.SetObject(vcIndexPosKey(kind, value, lastCID), pos)
+	}
+	mm.Delete(vcIndexKey(kind, value, last))
+	mm.Delete(vcIndexPosKey(kind, value, cid))
+	mm.SetObject(vcIndexCountKey(kind, value), last)
+}
+
+// ListVCsByIssuer lists credentials issued by the issuer.
+func (mm *VCManager) ListVCsByIssuer(issuer string, offset, limit uint64) *boltvm.Response {
+	return mm.listVCs(vcIndexIssuer, issuer, offset, limit)
+}
+
+// ListVCsByHolder lists credentials held by the holder.
+func (mm *VCManager) ListVCsByHolder(holder string, offset, limit uint64) *boltvm.Response {
+	return mm.listVCs(vcIndexHolder, holder, offset, limit)
+}
+
+// ListVCsByClaimTyp lists credentials of the claim type.
+func (mm *VCManager) ListVCsByClaimTyp(ctid string, offset, limit uint64) *boltvm.Response {
+	return mm.listVCs(vcIndexClaimTyp, ctid, offset, limit)
+}
+
+// listVCs pages through the index in store order, except that a deleted
+// credential's place is taken by the last one, revoked credentials stay
+// listed under status Revoked, limit is capped by maxPageLimit, 0 means maxPageLimit.
+func (mm *VCManager) listVCs(kind, value string, offset, limit uint64) *boltvm.Response {
+	vcr := mm.getVCRegistry()
+
+	if res := checkInitialized(vcr.Initalized); res != nil {
+		return res
+	}
+	clk := blockClock(mm.Stub)
+
+	if limit == 0 || limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+
+	page := &VCPage{}
+	mm.GetObject(vcIndexCountKey(kind, value), &page.Total)
+	for i := offset; i < page.Total && i < offset+limit; i++ {
+		var cid string
+		if !mm.GetObject(vcIndexKey(kind, value, i), &cid) {
+			continue
+		}
+		c, err := vcr.Registry.GetVC(cid)
+		if err != nil || c == nil {
+			continue
+		}
+		page.Items = append(page.Items, &VCSummary{
+			ID:         c.ID,
+			Typ:        c.Typ,
+			Issuer:     c.Issuer,
+			Holder:     credentialHolder(c),
+			Issued:     c.Issued,
+			Expiration: c.Expiration,
+			Status:     mm.getVCStatus(c, clk).Status,
+		})
+	}
+
+	return success(page.toPB())
+}
+
+func vcIndexCountKey(kind, value string) string {
+	return "vcindex-" + kind + "-" + value + "-count"
+}
+
+func vcIndexKey(kind, value string, i uint64) string {
+	return "vcindex-" + kind + "-" + value + "-" + strconv.FormatUint(i, 10)
+}
+
+func vcIndexPosKey(kind, value, cid string) string {
+	return "vcindexpos-" + kind + "-" + value + "-" + cid
+}
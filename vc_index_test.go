@@ -0,0 +1,134 @@
+package contracts
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/meshplus/bitxhub-core/boltvm"
+	"github.com/meshplus/did-registry/didpb"
+	"github.com/stretchr/testify/require"
+)
+
+func pageIDs(t *testing.T, res *boltvm.Response) (uint64, []string) {
+	page := &didpb.VCPage{}
+	decode(t, res, page)
+	var ids []string
+	for _, item := range page.Items {
+		ids = append(ids, item.Id)
+	}
+	return page.Total, ids
+}
+
+func TestVCManager_ListVCs(t *testing.T) {
+	e := newInitedEnv(t)
+	e.newClaimTyp(e.user, "ct-a", "name")
+	e.newClaimTyp(e.user, "ct-b", "name")
+	issuer, issuerDoc := e.newAccount()
+	holder := newTestAccount(t)
+
+	store := func(acc *testAccount, docb []byte, cid, ctid, holder string) {
+		c := e.credential(acc, cid, ctid, claimOf(t, holder, map[string]string{"name": "alice"}))
+		requireOK(t, e.storeVC(acc, docb, c))
+	}
+	store(e.user, e.userDoc, "vc-1", "ct-a", holder.did)
+	store(e.user, e.userDoc, "vc-2", "ct-b", holder.did)
+	store(issuer, issuerDoc, "vc-3", "ct-a", holder.did)
+	store(e.user, e.userDoc, "vc-4", "ct-a", e.admin.did)
+	requireOK(t, e.as(e.user).vc.RevokeVC(e.user.did, "vc-1", "", e.user.sign(t, []byte("vc-1"))))
+
+	total, ids := pageIDs(t, e.vc.ListVCsByIssuer(e.user.did, 0, 0))
+	require.Equal(t, uint64(3), total)
+	require.Equal(t, []string{"vc-1", "vc-2", "vc-4"}, ids)
+
+	total, ids = pageIDs(t, e.vc.ListVCsByHolder(holder.did, 1, 1))
+	require.Equal(t, uint64(3), total)
+	require.Equal(t, []string{"vc-2"}, ids)
+
+	total, ids = pageIDs(t, e.vc.ListVCsByClaimTyp("ct-a", 0, 0))
+	require.Equal(t, uint64(3), total)
+	require.Equal(t, []string{"vc-1", "vc-3", "vc-4"}, ids)
+
+	page := &didpb.VCPage{}
+	decode(t, e.vc.ListVCsByHolder(holder.did, 0, 1), page)
+	require.Equal(t, &didpb.VCSummary{
+		Id:     "vc-1",
+		Typ:    "ct-a",
+		Issuer: e.user.did,
+		Holder: holder.did,
+		Issued: uint64(testTime),
+		Status: string(VCRevoked),
+	}, page.Items[0])
+
+	total, ids = pageIDs(t, e.vc.ListVCsByIssuer(holder.did, 0, 0))
+	require.Zero(t, total)
+	require.Empty(t, ids)
+}
+
+func TestVCManager_ListVCsAfterDelete(t *testing.T) {
+	e := newInitedEnv(t)
+	e.newClaimTyp(e.user, "ct-a", "name")
+	holder := newTestAccount(t)
+	for _, cid := range []string{"vc-1", "vc-2", "vc-3"} {
+		c := e.credential(e.user, cid, "ct-a", claimOf(t, holder.did, map[string]string{"name": "alice"}))
+		requireOK(t, e.storeVC(e.user, e.userDoc, c))
+	}
+	requireOK(t, e.as(e.user).vc.DeleteVC(e.user.did, "vc-1"))
+	requireOK(t, e.as(e.user).vc.RevokeVC(e.user.did, "vc-2", "", e.user.sign(t, []byte("vc-2"))))
+
+	// the last one takes place of the deleted one, the revoked one stays
+	for _, res := range []*boltvm.Response{
+		e.vc.ListVCsByIssuer(e.user.did, 0, 0),
+		e.vc.ListVCsByHolder(holder.did, 0, 0),
+		e.vc.ListVCsByClaimTyp("ct-a", 0, 0),
+	} {
+		total, ids := pageIDs(t, res)
+		require.Equal(t, uint64(2), total)
+		require.Equal(t, []string{"vc-3", "vc-2"}, ids)
+	}
+
+	requireOK(t, e.as(e.user).vc.DeleteVC(e.user.did, "vc-3"))
+	total, ids := pageIDs(t, e.vc.ListVCsByIssuer(e.user.did, 0, 0))
+	require.Equal(t, uint64(1), total)
+	require.Equal(t, []string{"vc-2"}, ids)
+	require.False(t, e.vcStub.Has(vcIndexKey(vcIndexIssuer, e.user.did, 1)))
+}
+
+func TestVCManager_ListVCsPaging(t *testing.T) {
+	runCalls(t, []call{
+		{
+			name:  "offset past total",
+			setup: issued,
+			run:   func(e *testEnv) *boltvm.Response { return e.vc.ListVCsByIssuer(e.user.did, 1, 0) },
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				total, ids := pageIDs(t, res)
+				require.Equal(t, uint64(1), total)
+				require.Empty(t, ids)
+			},
+		},
+		{
+			name: "limit over max",
+			setup: func(e *testEnv) {
+				e.newClaimTyp(e.user, testCTID, "name")
+				for i := 0; i <= maxPageLimit; i++ {
+					c := e.credential(e.user, fmt.Sprintf("vc-%d", i), testCTID, claimOf(e.t, e.admin.did, map[string]string{"name": "alice"}))
+					requireOK(e.t, e.storeVC(e.user, e.userDoc, c))
+				}
+			},
+			run: func(e *testEnv) *boltvm.Response { return e.vc.ListVCsByClaimTyp(testCTID, 0, maxPageLimit+1) },
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				total, ids := pageIDs(t, res)
+				require.Equal(t, uint64(maxPageLimit+1), total)
+				require.Len(t, ids, maxPageLimit)
+			},
+		},
+		{
+			name: "unknown holder",
+			run:  func(e *testEnv) *boltvm.Response { return e.vc.ListVCsByHolder(e.user.did, 0, 0) },
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				total, ids := pageIDs(t, res)
+				require.Zero(t, total)
+				require.Empty(t, ids)
+			},
+		},
+	})
+}
@@ -0,0 +1,137 @@
+package contracts
+
+import (
+	"fmt"
+
+	"github.com/meshplus/bitxhub-core/boltvm"
+	"github.com/meshplus/bitxid"
+)
+
+// Presentation represents a verifiable presentation submitted by a holder,
+// every presented credential should be held by the holder.
+// @Challenge: nonce given by the relying party to prevent replay, required
+// @Domain: relying party the presentation is made for, optional
+// @HolderDoc: doc content of the holder, checked against the anchored doc hash
+// @Signature: holder signature over the presentation without HolderDoc and Signature
+type Presentation struct {
+	Holder        bitxid.DID
+	CredentialIDs []string
+	Challenge     string
+	Domain        string
+	HolderDoc     []byte
+	Signature     bitxid.Sig
+}
+
+// PresentationVerdict represents result of verifying a presentation.
+type PresentationVerdict struct {
+	Holder      bitxid.DID   // holder of the presentation
+	Challenge   string       // challenge signed by the holder
+	Domain      string       // domain signed by the holder
+	Valid       bool         // whether holder and all credentials passed
+	HolderValid bool         // whether holder signature passed
+	Errors      []string     // reasons of failed holder checks
+	Credentials []*VCVerdict // verdict of every referenced credential
+}
+
+// presentationDigest returns content signed by the holder,
+// binding the credentials to the challenge and domain of the relying party.
+func presentationDigest(vp *Presentation) ([]byte, error) {
+	return bitxid.Marshal(struct {
+		Holder        bitxid.DID
+		CredentialIDs []string
+		Challenge     string
+		Domain        string
+	}{
+		Holder:        vp.Holder,
+		CredentialIDs: vp.CredentialIDs,
+		Challenge:     vp.Challenge,
+		Domain:        vp.Domain,
+	})
+}
+
+// VerifyPresentation checks holder signature of the presentation against
+// the holder's account doc, and checks every referenced credential,
+// returns the verdict of the presentation and each credential.
+func (mm *VCManager) VerifyPresentation(vpBytes []byte) *boltvm.Response {
+	vcr := mm.getVCRegistry()
+
+	if res := checkInitialized(vcr.Initalized); res != nil {
+		return res
+	}
+	clk := blockClock(mm.Stub)
+
+	vp := &Presentation{}
+	if err := bitxid.Unmarshal(vpBytes, vp); err != nil {
+		return wrapErrorResponse(ErrInvalidFormat, "params unmarshal err: ", err)
+	}
+
+	verdict := &PresentationVerdict{
+		Holder:    vp.Holder,
+		Challenge: vp.Challenge,
+		Domain:    vp.Domain,
+	}
+	if err := mm.verifyHolder(vp); err != nil {
+		verdict.Errors = append(verdict.Errors, err.Error())
+	} else {
+		verdict.HolderValid = true
+	}
+
+	verdict.Valid = verdict.HolderValid && len(vp.CredentialIDs) != 0
+	for _, cid := range vp.CredentialIDs {
+		v := mm.verifyVC(vcr, cid, clk)
+		if err := checkPresentedHolder(vcr, cid, vp.Holder); err != nil {
+			v.Errors = append(v.Errors, err.Error())
+			v.Valid = false
+		}
+		verdict.Valid = verdict.Valid && v.Valid
+		verdict.Credentials = append(verdict.Credentials, v)
+	}
+
+	return success(verdict.toPB())
+}
+
+// checkPresentedHolder checks the credential is held by the presenting holder,
+// credentials failed to load are reported by verifyVC.
+func checkPresentedHolder(vcr *VCRegistry, cid string, holder bitxid.DID) error {
+	c, err := vcr.Registry.GetVC(cid)
+	if err != nil || c == nil {
+		return nil
+	}
+	if subject := credentialHolder(c); subject != holder {
+		return fmt.Errorf("vc %s is held by %s, not the holder %s", cid, subject, holder)
+	}
+	return nil
+}
+
+// verifyHolder checks the holder is an active account did
+// and the presentation over a challenge is signed by one of its doc keys not retired.
+func (mm *VCManager) verifyHolder(vp *Presentation) error {
+	if vp.Holder.GetType() != int(bitxid.AccountDIDType) {
+		return fmt.Errorf("holder %s is not an account did", vp.Holder)
+	}
+	if vp.Challenge == "" {
+		return fmt.Errorf("presentation has no challenge")
+	}
+	holder, err := mm.resolveDID(vp.Holder)
+	if err != nil {
+		return err
+	}
+	if holder.Status != bitxid.Normal {
+		return fmt.Errorf("holder %s is under status: %s", vp.Holder, holder.Status)
+	}
+	doc, err := loadDoc(vp.Holder, vp.HolderDoc, holder.DocHash)
+	if err != nil {
+		return err
+	}
+	doc = dropKeys(doc, holder.RetiredKeys)
+	sig, err := decodeSig(vp.Signature)
+	if err != nil {
+		return err
+	}
+	msg, err := presentationDigest(vp)
+	if err != nil {
+		return err
+	}
+	_, err = verifyDocSig(doc, msg, sig)
+	return err
+}
@@ -0,0 +1,203 @@
+package contracts
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/meshplus/bitxhub-core/boltvm"
+	"github.com/meshplus/bitxid"
+	"github.com/meshplus/did-registry/didpb"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testChallenge = "nonce-1"
+	testDomain    = "verifier.example"
+)
+
+// held stores testCID of testCTID issued by user to itself.
+func held(e *testEnv) {
+	e.newClaimTyp(e.user, testCTID, "name")
+	c := e.credential(e.user, testCID, testCTID, claimOf(e.t, e.user.did, map[string]string{"name": "alice"}))
+	requireOK(e.t, e.storeVC(e.user, e.userDoc, c))
+}
+
+// presentation returns the marshaled presentation of cids by holder,
+// signed by signer with holder doc docb.
+func presentation(t *testing.T, holder, signer *testAccount, docb []byte, cids ...string) []byte {
+	return signPresentation(t, &Presentation{
+		Holder:        bitxid.DID(holder.did),
+		CredentialIDs: cids,
+		Challenge:     testChallenge,
+		Domain:        testDomain,
+	}, signer, docb)
+}
+
+func signPresentation(t *testing.T, vp *Presentation, signer *testAccount, docb []byte) []byte {
+	msg, err := presentationDigest(vp)
+	require.Nil(t, err)
+	vp.HolderDoc = docb
+	vp.Signature = bitxid.Sig{
+		Typ:     "Secp256k1",
+		Content: base64.StdEncoding.EncodeToString(signer.sign(t, msg)),
+	}
+	vpb, err := bitxid.Marshal(vp)
+	require.Nil(t, err)
+	return vpb
+}
+
+func TestVCManager_VerifyPresentation(t *testing.T) {
+	verdictOf := func(t *testing.T, res *boltvm.Response) *didpb.PresentationVerdict {
+		verdict := &didpb.PresentationVerdict{}
+		decode(t, res, verdict)
+		return verdict
+	}
+	runCalls(t, []call{
+		{
+			name:  "valid",
+			setup: held,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.vc.VerifyPresentation(presentation(e.t, e.user, e.user, e.userDoc, testCID))
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				verdict := verdictOf(t, res)
+				require.True(t, verdict.Valid, verdict.Errors)
+				require.True(t, verdict.HolderValid)
+				require.Equal(t, e.user.did, verdict.Holder)
+				require.Equal(t, testChallenge, verdict.Challenge)
+				require.Equal(t, testDomain, verdict.Domain)
+				require.Len(t, verdict.Credentials, 1)
+				require.True(t, verdict.Credentials[0].Valid)
+			},
+		},
+		{
+			name:  "signed by others",
+			setup: held,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.vc.VerifyPresentation(presentation(e.t, e.user, e.admin, e.userDoc, testCID))
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				verdict := verdictOf(t, res)
+				require.False(t, verdict.Valid)
+				require.False(t, verdict.HolderValid)
+				require.Len(t, verdict.Errors, 1)
+				require.True(t, verdict.Credentials[0].Valid)
+			},
+		},
+		{
+			name:  "holder doc not match",
+			setup: held,
+			run: func(e *testEnv) *boltvm.Response {
+				docb, _ := e.admin.doc(e.t)
+				return e.vc.VerifyPresentation(presentation(e.t, e.user, e.user, docb, testCID))
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.False(t, verdictOf(t, res).HolderValid)
+			},
+		},
+		{
+			name:  "holder frozen",
+			setup: func(e *testEnv) { held(e); userFrozen(e) },
+			run: func(e *testEnv) *boltvm.Response {
+				return e.vc.VerifyPresentation(presentation(e.t, e.user, e.user, e.userDoc))
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.False(t, verdictOf(t, res).HolderValid)
+			},
+		},
+		{
+			name:  "revoked credential",
+			setup: held,
+			run: func(e *testEnv) *boltvm.Response {
+				requireOK(e.t, e.as(e.user).vc.DeleteVC(e.user.did, testCID))
+				return e.vc.VerifyPresentation(presentation(e.t, e.user, e.user, e.userDoc, testCID, "vc-missing"))
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				verdict := verdictOf(t, res)
+				require.False(t, verdict.Valid)
+				require.True(t, verdict.HolderValid)
+				require.Len(t, verdict.Credentials, 2)
+				require.Equal(t, string(VCRevoked), verdict.Credentials[0].Status)
+				require.False(t, verdict.Credentials[1].Valid)
+			},
+		},
+		{
+			name:  "credential of others",
+			setup: issued,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.vc.VerifyPresentation(presentation(e.t, e.user, e.user, e.userDoc, testCID))
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				verdict := verdictOf(t, res)
+				require.False(t, verdict.Valid)
+				require.True(t, verdict.HolderValid)
+				require.False(t, verdict.Credentials[0].Valid)
+			},
+		},
+		{
+			name:  "no challenge",
+			setup: held,
+			run: func(e *testEnv) *boltvm.Response {
+				vp := &Presentation{Holder: bitxid.DID(e.user.did), CredentialIDs: []string{testCID}}
+				return e.vc.VerifyPresentation(signPresentation(e.t, vp, e.user, e.userDoc))
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.False(t, verdictOf(t, res).HolderValid)
+			},
+		},
+		{
+			name:  "challenge replaced",
+			setup: held,
+			run: func(e *testEnv) *boltvm.Response {
+				vp := &Presentation{}
+				require.Nil(e.t, bitxid.Unmarshal(presentation(e.t, e.user, e.user, e.userDoc, testCID), vp))
+				vp.Challenge = "nonce-2"
+				vpb, err := bitxid.Marshal(vp)
+				require.Nil(e.t, err)
+				return e.vc.VerifyPresentation(vpb)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.False(t, verdictOf(t, res).HolderValid)
+			},
+		},
+		{
+			name: "no credential",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.vc.VerifyPresentation(presentation(e.t, e.user, e.user, e.userDoc))
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				verdict := verdictOf(t, res)
+				require.False(t, verdict.Valid)
+				require.True(t, verdict.HolderValid)
+			},
+		},
+		{
+			name: "holder not registered",
+			run: func(e *testEnv) *boltvm.Response {
+				holder := newTestAccount(e.t)
+				docb, _ := holder.doc(e.t)
+				return e.vc.VerifyPresentation(presentation(e.t, holder, holder, docb))
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				verdict := verdictOf(t, res)
+				require.False(t, verdict.HolderValid)
+				require.Len(t, verdict.Errors, 1)
+			},
+		},
+		{
+			name: "chain did holder",
+			run: func(e *testEnv) *boltvm.Response {
+				holder := &testAccount{key: e.user.key, did: testChainDID}
+				return e.vc.VerifyPresentation(presentation(e.t, holder, e.user, e.userDoc))
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.False(t, verdictOf(t, res).HolderValid)
+			},
+		},
+		{
+			name: "malformed",
+			run:  func(e *testEnv) *boltvm.Response { return e.vc.VerifyPresentation([]byte("{")) },
+			code: ErrInvalidFormat,
+		},
+	})
+}
@@ -1,13 +1,16 @@
 package contracts
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"github.com/meshplus/bitxhub-core/agency"
 	"github.com/meshplus/bitxhub-core/boltvm"
 	"github.com/meshplus/bitxhub-model/constant"
+	"github.com/meshplus/bitxhub-model/pb"
 	"github.com/meshplus/bitxid"
 	"github.com/meshplus/did-registry/converter"
+	"github.com/meshplus/did-registry/didpb"
 )
 
 const (
@@ -24,6 +27,39 @@ func init() {
 	agency.RegisterContractConstructor("vc registry", constant.VCRegistryContractAddr.Address(), NewVCManager)
 }
 
+// VCStatusType represents status of a credential.
+type VCStatusType string
+
+// the rule of vc status:
+// @VCRevoked: revoked by issuer, can not be changed any more
+// @VCSuspended: hold by issuer, can be unsuspended
+// @VCExpired: expiration time of the credential has passed
+// @VCUnknown: not revoked nor suspended, but whether it expired can't be told
+// since the stub provides no block time
+const (
+	VCActive    VCStatusType = "Active"
+	VCRevoked   VCStatusType = "Revoked"
+	VCSuspended VCStatusType = "Suspended"
+	VCExpired   VCStatusType = "Expired"
+	VCUnknown   VCStatusType = "Unknown"
+)
+
+// VCStatus represents revocation record of a credential.
+type VCStatus struct {
+	ID        string       // id of the credential
+	Status    VCStatusType // status of the credential
+	Reason    string       // reason of the last status change
+	Timestamp int64        // time of the last status change, 0 if the stub provided no block time
+}
+
+// VCVerdict represents result of verifying a credential.
+type VCVerdict struct {
+	ID     string       // id of the credential
+	Valid  bool         // whether all checks passed
+	Status VCStatusType // effective status of the credential
+	Errors []string     // reasons of failed checks
+}
+
 // VCManager presents verifiable credential registry
 type VCManager struct {
 	boltvm.Stub
@@ -33,8 +69,10 @@ type VCManager struct {
 // @SelfID: self Method ID
 // @ChildIDs: Method IDs of the child chain
 type VCRegistry struct {
-	Initalized bool
-	Registry   *bitxid.VCRegistry
+	Initalized          bool
+	Registry            *bitxid.VCRegistry
+	Admins              []bitxid.DID    // admins of the registry, the first one is super admin
+	DeprecatedClaimTyps map[string]bool // claim types no longer accepted
 }
 
 func (vm *VCManager) getVCRegistry() *VCRegistry {
@@ -56,96 +94,125 @@ func (vr *VCRegistry) loadStore(stub boltvm.Stub) error {
 	return nil
 }
 
-func (mm *VCManager) Init() *boltvm.Response { // caller string
+// Init sets up the whole registry,
+// caller will be admin of the registry,
+// the registry is seeded from genesis config set by the node, if any.
+func (mm *VCManager) Init(caller string) *boltvm.Response {
 	vcr := mm.getVCRegistry()
 
-	// var admin string
-	// mm.GetObject(adminVCKey, &admin)
-	// mm.Logger().Info("admin get: " + string(admin))
+	var admin string
+	mm.GetObject(adminVCKey, &admin)
+	mm.Logger().Info("admin get: " + string(admin))
+
+	callerDID := bitxid.DID(caller)
+	if mm.Caller() != admin {
+		return errorResponse(ErrNotAdmin, "caller ("+mm.Caller()+") is not admin("+admin+")")
+	}
+
+	if mm.Caller() != callerDID.GetAddress() {
+		return errorResponse(ErrCallerMismatch, callerNotMatchError(mm.Caller(), caller))
+	}
 
 	if vcr.Initalized {
-		return boltvm.Error("init err, already init")
+		return errorResponse(ErrAlreadyInitialized, "init err, already init")
 	}
 
+	config := getGenesisConfig()
 	s := converter.StubToStorage(mm.Stub)
 	r, err := bitxid.NewVCRegistry(s)
 	if err != nil {
-		return boltvm.Error("init err, " + err.Error())
+		return wrapErrorResponse(ErrRegistryRejected, "init err, ", err)
 	}
 	vcr.Registry = r
+	vcr.Admins = []bitxid.DID{callerDID}
+	vcr.DeprecatedClaimTyps = make(map[string]bool)
+
+	if config != nil {
+		if err := mm.seedGenesis(vcr, &config.VC, callerDID); err != nil {
+			return wrapErrorResponse(ErrInvalidArgument, "init genesis err, ", err)
+		}
+		mm.Logger().Info("VC Registry seeded from genesis")
+	}
 	vcr.Initalized = true
 
 	mm.SetObject(VCRegistryKey, vcr)
-	mm.Logger().Info("vc init success 2")
+	mm.Logger().Info("VC Registry init success with admin: " + string(callerDID))
 
 	return boltvm.Success(nil)
 }
 
-func (mm *VCManager) CreateClaimTyp(ctb []byte) *boltvm.Response {
+// CreateClaimTyp creates a claim type,
+// caller will be owner of the claim type.
+func (mm *VCManager) CreateClaimTyp(caller string, ctb []byte) *boltvm.Response {
 	mm.Logger().Info("vc in CreateClaimTyp")
 	vcr := mm.getVCRegistry()
 
-	if !vcr.Initalized {
-		return boltvm.Error("Registry not initialized")
+	callerDID := bitxid.DID(caller)
+	if res := authorize(mm.Stub, vcr.Initalized, caller); res != nil {
+		return res
 	}
 
 	ct := &bitxid.ClaimTyp{}
 	err := ct.Unmarshal(ctb)
 	if err != nil {
-		return boltvm.Error("params unmarshal err: " + err.Error())
+		return wrapErrorResponse(ErrInvalidFormat, "params unmarshal err: ", err)
+	}
+	if old, _ := vcr.Registry.GetClaimTyp(ct.ID); old != nil {
+		return errorResponse(ErrAlreadyExists, "create claim type err, claim type "+ct.ID+" already existed")
 	}
 
 	ctid, err := vcr.Registry.CreateClaimTyp(ct)
 	if err != nil {
-		return boltvm.Error("create claim type err, " + err.Error())
+		return wrapErrorResponse(ErrRegistryRejected, "create claim type err, ", err)
 	}
 	mm.SetObject(VCRegistryKey, vcr)
+	mm.SetObject(claimTypPolicyKey(ctid), &ClaimTypPolicy{ID: ctid, Owner: callerDID})
+	postVCEvent(mm.Stub, EventCreateClaimTyp, ctid, callerDID, "", "", callerDID)
 
-	return boltvm.Success([]byte(ctid))
+	return stringResponse(ctid)
 }
 
 func (mm *VCManager) GetClaimTyp(ctid string) *boltvm.Response {
 	mm.Logger().Info("vc in GetClaimTyp")
 	vcr := mm.getVCRegistry()
 
-	if !vcr.Initalized {
-		return boltvm.Error("Registry not initialized")
+	if res := checkInitialized(vcr.Initalized); res != nil {
+		return res
 	}
 
 	ct, err := vcr.Registry.GetClaimTyp(ctid)
 	if err != nil {
-		return boltvm.Error("get claim type err, " + err.Error())
+		return wrapErrorResponse(ErrInternal, "get claim type err, ", err)
 	}
 
-	mm.Logger().Info("vc get: ", ct)
-
-	b, err := ct.Marshal()
-	if err != nil {
-		return boltvm.Error("claim unmarshal err, " + err.Error())
+	if ct == nil {
+		return errorResponse(ErrNotFound, "get claim type err, claim type "+ctid+" not existed")
 	}
 
-	return boltvm.Success(b)
+	mm.Logger().Info("vc get: ", ct)
+
+	return success(claimTypToPB(ct))
 }
 
 func (mm *VCManager) GetAllClaimTyps() *boltvm.Response {
 	mm.Logger().Info("vc in GetAllClaimTyps")
 	vcr := mm.getVCRegistry()
 
-	if !vcr.Initalized {
-		return boltvm.Error("Registry not initialized")
+	if res := checkInitialized(vcr.Initalized); res != nil {
+		return res
 	}
 
 	ctlist, err := vcr.Registry.GetAllClaimTyps()
 	if err != nil {
-		return boltvm.Error("get all claim types err: " + err.Error())
+		return wrapErrorResponse(ErrInternal, "get all claim types err: ", err)
 	}
 
-	b, err := bitxid.Marshal(ctlist)
-	if err != nil {
-		return boltvm.Error("marshal ctlist err: " + err.Error())
+	list := &didpb.ClaimTypList{}
+	for _, ct := range ctlist {
+		list.ClaimTyps = append(list.ClaimTyps, claimTypToPB(ct))
 	}
 
-	return boltvm.Success(b)
+	return success(list)
 }
 
 // // this function should not exist
@@ -153,71 +220,614 @@ func (mm *VCManager) GetAllClaimTyps() *boltvm.Response {
 // 	vcr := mm.getVCRegistry()
 
 // 	if !vcr.Initalized {
-// 		return boltvm.Error("Registry not initialized")
+// 		return notInitializedResponse()
 // 	}
 
 // 	vcr.Registry.DeleteClaimtyp(ctid)
 // 	return boltvm.Success(nil)
 // }
 
-func (mm *VCManager) StoreVC(cb []byte) *boltvm.Response {
+// StoreVC stores a credential signed by its issuer,
+// caller should be the issuer (or owner of the issuer if it is a chain did),
+// docb is doc content of the issuer which is checked against the anchored doc hash.
+func (mm *VCManager) StoreVC(caller string, cb []byte, docb []byte) *boltvm.Response {
 	vcr := mm.getVCRegistry()
 
-	if !vcr.Initalized {
-		return boltvm.Error("Registry not initialized")
+	callerDID := bitxid.DID(caller)
+	if res := authorize(mm.Stub, vcr.Initalized, caller); res != nil {
+		return res
 	}
 
 	c := &bitxid.Credential{}
 	err := c.Unmarshal(cb)
 	if err != nil {
-		return boltvm.Error("params unmarshal err: " + err.Error())
+		return wrapErrorResponse(ErrInvalidFormat, "params unmarshal err: ", err)
+	}
+
+	return mm.storeVC(vcr, callerDID, c, docb)
+}
+
+// storeVC checks the issuer and its signature, then stores the credential.
+func (mm *VCManager) storeVC(vcr *VCRegistry, callerDID bitxid.DID, c *bitxid.Credential, docb []byte) *boltvm.Response {
+	if old, _ := vcr.Registry.GetVC(c.ID); old != nil || mm.Has(vcStatusKey(c.ID)) {
+		return errorResponse(ErrAlreadyExists, "store vc err, vc "+c.ID+" already existed")
+	}
+	if vcr.DeprecatedClaimTyps[c.Typ] {
+		return errorResponse(ErrDeprecated, "store vc err, claim type "+c.Typ+" was deprecated")
+	}
+	if !mm.getClaimTypPolicy(c.Typ).trusts(c.Issuer) {
+		return errorResponse(ErrUntrustedIssuer, "store vc err, issuer("+string(c.Issuer)+") is not trusted by claim type "+c.Typ)
+	}
+	if c.Expiration != 0 {
+		now, err := blockTime(mm.Stub)
+		if err != nil {
+			return wrapErrorResponse(ErrNoBlockContext, "store vc err, expiration can't be checked: ", err)
+		}
+		if c.Expiration <= uint64(now) {
+			return errorResponse(ErrExpired, "store vc err, vc "+c.ID+" was already expired")
+		}
+	}
+
+	issuer, err := mm.resolveDID(c.Issuer)
+	if err != nil {
+		return wrapErrorResponse(ErrInternal, "store vc err: ", err)
+	}
+	if issuer.Status != bitxid.Normal {
+		return errorResponse(ErrInvalidStatus, "store vc err, issuer("+string(c.Issuer)+") is under status: "+string(issuer.Status))
+	}
+	if issuer.Owner != callerDID {
+		return errorResponse(ErrNotOwner, "store vc err, caller("+string(callerDID)+") is not issuer("+string(c.Issuer)+")")
+	}
+	doc, err := loadDoc(c.Issuer, docb, issuer.DocHash)
+	if err != nil {
+		return wrapErrorResponse(ErrInternal, "store vc err: ", err)
+	}
+	doc = dropKeys(doc, issuer.RetiredKeys)
+	if issuer.Controller != "" {
+		// keys added by Update without rotation don't sign for the did
+		doc = keepKeys(doc, []string{issuer.Controller})
+	}
+	sig, err := decodeSig(c.Signature)
+	if err != nil {
+		return wrapErrorResponse(ErrInternal, "store vc err: ", err)
+	}
+	msg, err := credentialDigest(c)
+	if err != nil {
+		return wrapErrorResponse(ErrInternal, "store vc err: ", err)
+	}
+	if _, err := verifyDocSig(doc, msg, sig); err != nil {
+		return wrapErrorResponse(ErrInternal, "store vc err: ", err)
 	}
 
 	cid, err := vcr.Registry.StoreVC(c)
 	if err != nil {
-		return boltvm.Error("store vc err: " + err.Error())
+		return wrapErrorResponse(ErrRegistryRejected, "store vc err: ", err)
 	}
-	return boltvm.Success([]byte(cid))
+	mm.indexVC(c)
+	postVCEvent(mm.Stub, EventStoreVC, cid, c.Issuer, "", VCActive, callerDID)
+
+	return stringResponse(cid)
 }
 
+// GetVC gets the credential together with its effective status.
 func (mm *VCManager) GetVC(cid string) *boltvm.Response {
 	vcr := mm.getVCRegistry()
 
-	if !vcr.Initalized {
-		return boltvm.Error("Registry not initialized")
+	if res := checkInitialized(vcr.Initalized); res != nil {
+		return res
 	}
+	clk := blockClock(mm.Stub)
 
 	c, err := vcr.Registry.GetVC(cid)
 	if err != nil {
-		return boltvm.Error("get vc err: " + err.Error())
+		return wrapErrorResponse(ErrInternal, "get vc err: ", err)
+	}
+	if c == nil {
+		return errorResponse(ErrNotFound, "get vc err: vc "+cid+" not existed")
 	}
 
-	b, err := c.Marshal()
-	if err != nil {
-		return boltvm.Error("credential unmarshal err, " + err.Error())
+	return success(&didpb.VCInfo{
+		Credential: credentialToPB(c),
+		Status:     mm.getVCStatus(c, clk).toPB(),
+	})
+}
+
+// VerifyVC checks expiry, revocation, issuer status and claim type
+// of the credential, returns the verdict.
+func (mm *VCManager) VerifyVC(cid string) *boltvm.Response {
+	vcr := mm.getVCRegistry()
+
+	if res := checkInitialized(vcr.Initalized); res != nil {
+		return res
 	}
 
-	return boltvm.Success(b)
+	clk := blockClock(mm.Stub)
+
+	return success(mm.verifyVC(vcr, cid, clk).toPB())
 }
 
+// DeleteVC revokes the credential and keeps its record,
+// it's no longer listed by issuer, holder or claim type, caller should be the issuer.
+// Deprecated: use RevokeVC.
 func (mm *VCManager) DeleteVC(caller, cid string) *boltvm.Response {
 	vcr := mm.getVCRegistry()
 
-	if !vcr.Initalized {
-		return boltvm.Error("Registry not initialized")
+	callerDID := bitxid.DID(caller)
+	if res := authorize(mm.Stub, vcr.Initalized, caller); res != nil {
+		return res
+	}
+
+	vc, err := mm.getIssuedVC(vcr, callerDID, cid)
+	if err != nil {
+		return wrapErrorResponse(ErrInternal, "delete vc err, ", err)
+	}
+	clk := blockClock(mm.Stub)
+
+	status := mm.getVCStatus(vc, clk)
+	if status.Status == VCRevoked {
+		return errorResponse(ErrInvalidStatus, "delete vc err, vc "+cid+" was already revoked")
+	}
+	mm.setVCStatus(cid, VCRevoked, "deleted by issuer", clk.now)
+	mm.unindexVC(vc)
+	postVCEvent(mm.Stub, EventDeleteVC, cid, vc.Issuer, status.Status, VCRevoked, callerDID)
+
+	return boltvm.Success(nil)
+}
+
+// RevokeVC revokes the credential permanently, it stays listed under status Revoked,
+// caller should be the issuer.
+func (mm *VCManager) RevokeVC(caller, cid, reason string, sig []byte) *boltvm.Response {
+	vcr := mm.getVCRegistry()
+
+	callerDID := bitxid.DID(caller)
+	if res := authorize(mm.Stub, vcr.Initalized, caller, requireSig(mm.Stub, callerDID, []byte(cid+reason), sig)); res != nil {
+		return res
+	}
+
+	vc, err := mm.getIssuedVC(vcr, callerDID, cid)
+	if err != nil {
+		return wrapErrorResponse(ErrInternal, "revoke vc err, ", err)
+	}
+	clk := blockClock(mm.Stub)
+
+	status := mm.getVCStatus(vc, clk)
+	if status.Status == VCRevoked {
+		return errorResponse(ErrInvalidStatus, "revoke vc err, vc "+cid+" was already revoked")
+	}
+	mm.setVCStatus(cid, VCRevoked, reason, clk.now)
+	postVCEvent(mm.Stub, EventRevokeVC, cid, vc.Issuer, status.Status, VCRevoked, callerDID)
+
+	return boltvm.Success(nil)
+}
+
+// SuspendVC holds the credential temporarily,
+// caller should be the issuer.
+func (mm *VCManager) SuspendVC(caller, cid, reason string, sig []byte) *boltvm.Response {
+	vcr := mm.getVCRegistry()
+
+	callerDID := bitxid.DID(caller)
+	if res := authorize(mm.Stub, vcr.Initalized, caller, requireSig(mm.Stub, callerDID, []byte(cid+reason), sig)); res != nil {
+		return res
+	}
+
+	vc, err := mm.getIssuedVC(vcr, callerDID, cid)
+	if err != nil {
+		return wrapErrorResponse(ErrInternal, "suspend vc err, ", err)
+	}
+	clk := blockClock(mm.Stub)
+
+	status := mm.getVCStatus(vc, clk)
+	if status.Status == VCRevoked || status.Status == VCSuspended {
+		return errorResponse(ErrInvalidStatus, "suspend vc err, vc "+cid+" is under status: "+string(status.Status))
+	}
+	mm.setVCStatus(cid, VCSuspended, reason, clk.now)
+	postVCEvent(mm.Stub, EventSuspendVC, cid, vc.Issuer, status.Status, VCSuspended, callerDID)
+
+	return boltvm.Success(nil)
+}
+
+// UnsuspendVC lifts the hold of a suspended credential,
+// caller should be the issuer.
+func (mm *VCManager) UnsuspendVC(caller, cid string, sig []byte) *boltvm.Response {
+	vcr := mm.getVCRegistry()
+
+	callerDID := bitxid.DID(caller)
+	if res := authorize(mm.Stub, vcr.Initalized, caller, requireSig(mm.Stub, callerDID, []byte(cid), sig)); res != nil {
+		return res
+	}
+
+	vc, err := mm.getIssuedVC(vcr, callerDID, cid)
+	if err != nil {
+		return wrapErrorResponse(ErrInternal, "unsuspend vc err, ", err)
+	}
+	clk := blockClock(mm.Stub)
+
+	status := mm.getVCStatus(vc, clk)
+	if status.Status != VCSuspended {
+		return errorResponse(ErrInvalidStatus, "unsuspend vc err, vc "+cid+" was not suspended")
+	}
+	mm.setVCStatus(cid, VCActive, "", clk.now)
+	postVCEvent(mm.Stub, EventUnsuspendVC, cid, vc.Issuer, status.Status, VCActive, callerDID)
+
+	return boltvm.Success(nil)
+}
+
+// GetVCStatus gets status of the credential,
+// which is one of Active, Revoked, Suspended, Expired and Unknown.
+func (mm *VCManager) GetVCStatus(cid string) *boltvm.Response {
+	vcr := mm.getVCRegistry()
+
+	if res := checkInitialized(vcr.Initalized); res != nil {
+		return res
+	}
+	clk := blockClock(mm.Stub)
+
+	vc, err := vcr.Registry.GetVC(cid)
+	if err != nil {
+		return wrapErrorResponse(ErrInternal, "get vc err: ", err)
+	}
+	if vc == nil {
+		return errorResponse(ErrNotFound, "get vc err: vc "+cid+" not existed")
+	}
+
+	return success(mm.getVCStatus(vc, clk).toPB())
+}
+
+// ForceRevokeVC revokes the credential regardless of its issuer,
+// used against compromised issuers, caller should be admin.
+func (mm *VCManager) ForceRevokeVC(caller, cid, reason string, sig []byte) *boltvm.Response {
+	vcr := mm.getVCRegistry()
+
+	callerDID := bitxid.DID(caller)
+	if res := authorize(mm.Stub, vcr.Initalized, caller,
+		requireAdmin(vcr, callerDID),
+		requireSig(mm.Stub, callerDID, []byte(cid+reason), sig),
+	); res != nil {
+		return res
 	}
 
 	vc, err := vcr.Registry.GetVC(cid)
 	if err != nil {
-		return boltvm.Error("get vc err, " + err.Error())
+		return wrapErrorResponse(ErrInternal, "force revoke vc err, ", err)
 	}
+	if vc == nil {
+		return errorResponse(ErrNotFound, "force revoke vc err, vc "+cid+" not existed")
+	}
+	clk := blockClock(mm.Stub)
+
+	status := mm.getVCStatus(vc, clk)
+	if status.Status == VCRevoked {
+		return errorResponse(ErrInvalidStatus, "force revoke vc err, vc "+cid+" was already revoked")
+	}
+	mm.setVCStatus(cid, VCRevoked, reason, clk.now)
+	postVCEvent(mm.Stub, EventRevokeVC, cid, vc.Issuer, status.Status, VCRevoked, callerDID)
+
+	return boltvm.Success(nil)
+}
+
+// DeprecateClaimTyp stops the claim type from being used by new credentials,
+// credentials of the claim type no longer pass verification,
+// caller should be admin.
+func (mm *VCManager) DeprecateClaimTyp(caller, ctid string, sig []byte) *boltvm.Response {
+	vcr := mm.getVCRegistry()
+
+	callerDID := bitxid.DID(caller)
+	if res := authorize(mm.Stub, vcr.Initalized, caller,
+		requireAdmin(vcr, callerDID),
+		requireSig(mm.Stub, callerDID, []byte(ctid), sig),
+	); res != nil {
+		return res
+	}
+
+	ct, err := vcr.Registry.GetClaimTyp(ctid)
+	if err != nil {
+		return wrapErrorResponse(ErrInternal, "get claim type err, ", err)
+	}
+	if ct == nil {
+		return errorResponse(ErrNotFound, "deprecate claim type err, claim type "+ctid+" not existed")
+	}
+	if vcr.DeprecatedClaimTyps[ctid] {
+		return errorResponse(ErrDeprecated, "deprecate claim type err, claim type "+ctid+" was already deprecated")
+	}
+	if vcr.DeprecatedClaimTyps == nil {
+		vcr.DeprecatedClaimTyps = make(map[string]bool)
+	}
+	vcr.DeprecatedClaimTyps[ctid] = true
+
+	mm.SetObject(VCRegistryKey, vcr)
+	postVCEvent(mm.Stub, EventDeprecateClaimTyp, ctid, callerDID, "", "", callerDID)
+	return boltvm.Success(nil)
+}
+
+// isSuperAdmin querys whether caller is the super admin of the registry.
+func (vr *VCRegistry) isSuperAdmin(caller bitxid.DID) bool {
+	return len(vr.Admins) != 0 && vr.Admins[0] == caller
+}
+
+func (vr *VCRegistry) hasAdmin(caller bitxid.DID) bool {
+	for _, admin := range vr.Admins {
+		if admin == caller {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAdmin querys whether caller is an admin of the registry.
+func (mm *VCManager) HasAdmin(caller string) *boltvm.Response {
+	vcr := mm.getVCRegistry()
+
+	if res := checkInitialized(vcr.Initalized); res != nil {
+		return res
+	}
+
+	return boolResponse(vcr.hasAdmin(bitxid.DID(caller)))
+}
+
+// GetAdmins get admin list of the registry.
+func (mm *VCManager) GetAdmins() *boltvm.Response {
+	vcr := mm.getVCRegistry()
+
+	if res := checkInitialized(vcr.Initalized); res != nil {
+		return res
+	}
+
+	return didsResponse(vcr.Admins)
+}
+
+// AddAdmin adds caller to the admin of the registry,
+// caller should be super admin.
+func (mm *VCManager) AddAdmin(caller string, adminToAdd string) *boltvm.Response {
+	vcr := mm.getVCRegistry()
 
 	callerDID := bitxid.DID(caller)
-	if callerDID != vc.Issuer {
-		return boltvm.Error("delete vc err, caller(" + string(callerDID) + ") is not issuer(" + string(vc.Issuer) + ")")
+	if res := authorize(mm.Stub, vcr.Initalized, caller, requireSuperAdmin(vcr, callerDID)); res != nil {
+		return res
+	}
+	adminDID := bitxid.DID(adminToAdd)
+	if !adminDID.IsValidFormat() || adminDID.GetType() != int(bitxid.AccountDIDType) {
+		return errorResponse(ErrInvalidFormat, "add admin err, "+adminToAdd+" is not a valid account did")
+	}
+	if vcr.hasAdmin(adminDID) {
+		return errorResponse(ErrAlreadyExists, "caller "+adminToAdd+" is already an admin")
 	}
 
-	vcr.Registry.DeleteVC(cid)
+	vcr.Admins = append(vcr.Admins, adminDID)
 
+	mm.SetObject(VCRegistryKey, vcr)
+	postAdminEvent(mm.Stub, vcRegistryName, AdminAdded, adminDID, callerDID)
 	return boltvm.Success(nil)
 }
+
+// RemoveAdmin remove admin of the registry,
+// caller should be super admin, super admin can not rm self.
+func (mm *VCManager) RemoveAdmin(caller string, adminToRm string) *boltvm.Response {
+	vcr := mm.getVCRegistry()
+
+	callerDID := bitxid.DID(caller)
+	if res := authorize(mm.Stub, vcr.Initalized, caller, requireSuperAdmin(vcr, callerDID)); res != nil {
+		return res
+	}
+	if vcr.isSuperAdmin(bitxid.DID(adminToRm)) {
+		return errorResponse(ErrInvalidArgument, "cannot rm super admin")
+	}
+
+	for i, admin := range vcr.Admins {
+		if admin == bitxid.DID(adminToRm) {
+			vcr.Admins = append(vcr.Admins[:i], vcr.Admins[i+1:]...)
+			mm.SetObject(VCRegistryKey, vcr)
+			postAdminEvent(mm.Stub, vcRegistryName, AdminRemoved, admin, callerDID)
+			return boltvm.Success(nil)
+		}
+	}
+	return errorResponse(ErrNotFound, "caller "+adminToRm+" is not an admin")
+}
+
+// getIssuedVC gets the credential and checks it is issued by caller.
+func (mm *VCManager) getIssuedVC(vcr *VCRegistry, caller bitxid.DID, cid string) (*bitxid.Credential, error) {
+	vc, err := vcr.Registry.GetVC(cid)
+	if err != nil {
+		return nil, newError(ErrInternal, "get vc: %s", err.Error())
+	}
+	if vc == nil {
+		return nil, newError(ErrNotFound, "vc %s not existed", cid)
+	}
+	if err := mm.checkIssuer(vc.Issuer, caller); err != nil {
+		return nil, err
+	}
+	return vc, nil
+}
+
+// checkIssuer checks caller controls the issuer and the issuer is under Normal,
+// the owner of a chain did issuer is treated as issuer.
+func (mm *VCManager) checkIssuer(issuer bitxid.DID, caller bitxid.DID) error {
+	info, err := mm.resolveDID(issuer)
+	if caller != issuer && (err != nil || issuer.GetType() != int(bitxid.ChainDIDType) || info.Owner != caller) {
+		return newError(ErrNotOwner, "caller(%s) is not issuer(%s)", caller, issuer)
+	}
+	if err != nil {
+		return err
+	}
+	if info.Status != bitxid.Normal {
+		return newError(ErrInvalidStatus, "issuer %s is under status: %s", issuer, info.Status)
+	}
+	return nil
+}
+
+// verifyVC verifies the credential against block time clk and registries,
+// all failed checks are collected in the verdict.
+// The issuance time is only checked if clk is known, while a credential
+// with an expiration is unknown then and fails.
+func (mm *VCManager) verifyVC(vcr *VCRegistry, cid string, clk clock) *VCVerdict {
+	verdict := &VCVerdict{ID: cid}
+
+	c, err := vcr.Registry.GetVC(cid)
+	if err != nil {
+		verdict.Errors = append(verdict.Errors, "get vc: "+err.Error())
+		return verdict
+	}
+	if c == nil {
+		verdict.Errors = append(verdict.Errors, "vc "+cid+" not existed")
+		return verdict
+	}
+
+	status := mm.getVCStatus(c, clk)
+	verdict.Status = status.Status
+	if status.Status != VCActive {
+		verdict.Errors = append(verdict.Errors, "vc "+cid+" is under status: "+string(status.Status))
+	}
+	if clk.known && c.Issued > uint64(clk.now) {
+		verdict.Errors = append(verdict.Errors, "vc "+cid+" is not valid yet")
+	}
+
+	issuer, err := mm.resolveDID(c.Issuer)
+	if err != nil {
+		verdict.Errors = append(verdict.Errors, err.Error())
+	} else if issuer.Status != bitxid.Normal {
+		verdict.Errors = append(verdict.Errors, "issuer "+string(c.Issuer)+" is under status: "+string(issuer.Status))
+	}
+
+	if err := mm.checkClaim(vcr, c); err != nil {
+		verdict.Errors = append(verdict.Errors, err.Error())
+	}
+
+	verdict.Valid = len(verdict.Errors) == 0
+	return verdict
+}
+
+// checkClaim checks the claim of the credential has every field of its claim type,
+// fields of a selective disclosure credential are checked by their commitments.
+func (mm *VCManager) checkClaim(vcr *VCRegistry, c *bitxid.Credential) error {
+	ct, err := vcr.Registry.GetClaimTyp(c.Typ)
+	if err != nil {
+		return fmt.Errorf("get claim type %s: %w", c.Typ, err)
+	}
+	if ct == nil {
+		return fmt.Errorf("claim type %s not existed", c.Typ)
+	}
+	if vcr.DeprecatedClaimTyps[c.Typ] {
+		return fmt.Errorf("claim type %s was deprecated", c.Typ)
+	}
+	if !mm.getClaimTypPolicy(c.Typ).trusts(c.Issuer) {
+		return fmt.Errorf("issuer %s is not trusted by claim type %s", c.Issuer, c.Typ)
+	}
+	claim := make(map[string]interface{})
+	if cc, ok := mm.getCommittedClaim(c.ID); ok {
+		if len(cc.Fields) == 0 {
+			return nil
+		}
+		for name := range cc.Fields {
+			claim[name] = nil
+		}
+	} else if err := json.Unmarshal([]byte(c.Claim), &claim); err != nil {
+		return fmt.Errorf("claim unmarshal: %w", err)
+	}
+	for _, field := range ct.Content {
+		if _, ok := claim[field.Field]; !ok {
+			return fmt.Errorf("claim misses field %s of claim type %s", field.Field, c.Typ)
+		}
+	}
+	return nil
+}
+
+// getVCStatus gets the effective status of the credential at clk,
+// revocation and suspension take precedence over expiration.
+func (mm *VCManager) getVCStatus(vc *bitxid.Credential, clk clock) *VCStatus {
+	status := &VCStatus{}
+	if !mm.GetObject(vcStatusKey(vc.ID), status) {
+		status = &VCStatus{ID: vc.ID, Status: VCActive}
+	}
+	if status.Status == VCActive {
+		status.Status, status.Reason = mm.listStatus(vc.ID)
+	}
+	if status.Status == VCActive && vc.Expiration != 0 {
+		switch {
+		case !clk.known:
+			status.Status = VCUnknown
+		case uint64(clk.now) > vc.Expiration:
+			status.Status = VCExpired
+		}
+	}
+	return status
+}
+
+func (mm *VCManager) setVCStatus(cid string, typ VCStatusType, reason string, now int64) {
+	mm.SetObject(vcStatusKey(cid), &VCStatus{
+		ID:        cid,
+		Status:    typ,
+		Reason:    reason,
+		Timestamp: now,
+	})
+}
+
+func vcStatusKey(cid string) string {
+	return "vcstatus-" + cid
+}
+
+// didRecord is what the did registries know about a did.
+// @Owner: did who controls the did, which is the did itself for account did
+// @RetiredKeys: addresses of keys retired by key rotation, only for account did
+// @Controller: address of the key controlling the did, only for account did
+type didRecord struct {
+	Owner       bitxid.DID
+	DocHash     []byte
+	Status      bitxid.StatusType
+	RetiredKeys []string
+	Controller  string
+}
+
+// resolveDID resolves did from the did registry it belongs to,
+// returns err if the did doesn't exist.
+func (mm *VCManager) resolveDID(did bitxid.DID) (*didRecord, error) {
+	if !did.IsValidFormat() {
+		return nil, newError(ErrInvalidFormat, "not valid did format: %s", did)
+	}
+
+	if did.GetType() == int(bitxid.ChainDIDType) {
+		res := mm.CrossInvoke(constant.MethodRegistryContractAddr.String(), "Resolve", pb.String(string(did)))
+		if !res.Ok {
+			re := ParseError(res.Result)
+			return nil, newError(re.Code, "resolve did %s: %s", did, re.Message)
+		}
+		info := &didpb.ChainDIDInfo{}
+		if err := info.Unmarshal(res.Result); err != nil {
+			return nil, newError(ErrInternal, "resolve did %s: %s", did, err.Error())
+		}
+		if info.ChainDid == "" {
+			return nil, newError(ErrNotFound, "did %s not existed", did)
+		}
+		return &didRecord{
+			Owner:   bitxid.DID(info.Owner),
+			DocHash: info.DocHash,
+			Status:  bitxid.StatusType(info.Status),
+		}, nil
+	}
+
+	res := mm.CrossInvoke(constant.DIDRegistryContractAddr.String(), "Resolve", pb.String(string(did)))
+	if !res.Ok {
+		re := ParseError(res.Result)
+		return nil, newError(re.Code, "resolve did %s: %s", did, re.Message)
+	}
+	info := &didpb.DIDInfo{}
+	if err := info.Unmarshal(res.Result); err != nil {
+		return nil, newError(ErrInternal, "resolve did %s: %s", did, err.Error())
+	}
+	if info.Did == "" {
+		return nil, newError(ErrNotFound, "did %s not existed", did)
+	}
+	return &didRecord{
+		Owner:       did,
+		DocHash:     info.DocHash,
+		Status:      bitxid.StatusType(info.Status),
+		RetiredKeys: info.RetiredKeys,
+		Controller:  info.Controller,
+	}, nil
+}
+
+// credentialDigest returns content signed by the issuer,
+// which is the credential without its signature.
+func credentialDigest(c *bitxid.Credential) ([]byte, error) {
+	unsigned := *c
+	unsigned.Signature = bitxid.Sig{}
+	return unsigned.Marshal()
+}
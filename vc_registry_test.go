@@ -0,0 +1,815 @@
+package contracts
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/meshplus/bitxhub-core/boltvm"
+	"github.com/meshplus/bitxid"
+	"github.com/meshplus/did-registry/didpb"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testCTID = "ct-name"
+	testCID  = "vc-1"
+)
+
+// claimOf returns claim content of holder with the fields.
+func claimOf(t *testing.T, holder string, fields map[string]string) string {
+	claim := map[string]string{"id": holder}
+	for k, v := range fields {
+		claim[k] = v
+	}
+	b, err := json.Marshal(claim)
+	require.Nil(t, err)
+	return string(b)
+}
+
+// issued stores testCID of testCTID issued by user to admin.
+func issued(e *testEnv) {
+	e.newClaimTyp(e.user, testCTID, "name")
+	c := e.credential(e.user, testCID, testCTID, claimOf(e.t, e.admin.did, map[string]string{"name": "alice"}))
+	requireOK(e.t, e.storeVC(e.user, e.userDoc, c))
+}
+
+func vcStatus(t *testing.T, e *testEnv, cid string) *didpb.VCStatus {
+	status := &didpb.VCStatus{}
+	decode(t, e.vc.GetVCStatus(cid), status)
+	return status
+}
+
+func verifyVC(t *testing.T, e *testEnv, cid string) *didpb.VCVerdict {
+	verdict := &didpb.VCVerdict{}
+	decode(t, e.vc.VerifyVC(cid), verdict)
+	return verdict
+}
+
+func TestVCManager_Init(t *testing.T) {
+	e := newTestEnv(t)
+	stranger := newTestAccount(t)
+
+	requireCode(t, e.as(stranger).vc.Init(stranger.did), ErrNotAdmin)
+	requireCode(t, e.as(e.admin).vc.Init(stranger.did), ErrCallerMismatch)
+	requireOK(t, e.vc.Init(e.admin.did))
+	requireCode(t, e.vc.Init(e.admin.did), ErrAlreadyInitialized)
+
+	admins := &didpb.StringSlice{}
+	decode(t, e.vc.GetAdmins(), admins)
+	require.Equal(t, []string{e.admin.did}, admins.Slice)
+}
+
+func TestVCManager_NotInitialized(t *testing.T) {
+	e := newTestEnv(t)
+	e.as(e.admin)
+	admin := e.admin.did
+	calls := map[string]func() *boltvm.Response{
+		"CreateClaimTyp":        func() *boltvm.Response { return e.vc.CreateClaimTyp(admin, nil) },
+		"GetClaimTyp":           func() *boltvm.Response { return e.vc.GetClaimTyp(testCTID) },
+		"GetAllClaimTyps":       func() *boltvm.Response { return e.vc.GetAllClaimTyps() },
+		"StoreVC":               func() *boltvm.Response { return e.vc.StoreVC(admin, nil, nil) },
+		"GetVC":                 func() *boltvm.Response { return e.vc.GetVC(testCID) },
+		"VerifyVC":              func() *boltvm.Response { return e.vc.VerifyVC(testCID) },
+		"DeleteVC":              func() *boltvm.Response { return e.vc.DeleteVC(admin, testCID) },
+		"RevokeVC":              func() *boltvm.Response { return e.vc.RevokeVC(admin, testCID, "", nil) },
+		"SuspendVC":             func() *boltvm.Response { return e.vc.SuspendVC(admin, testCID, "", nil) },
+		"UnsuspendVC":           func() *boltvm.Response { return e.vc.UnsuspendVC(admin, testCID, nil) },
+		"GetVCStatus":           func() *boltvm.Response { return e.vc.GetVCStatus(testCID) },
+		"ForceRevokeVC":         func() *boltvm.Response { return e.vc.ForceRevokeVC(admin, testCID, "", nil) },
+		"DeprecateClaimTyp":     func() *boltvm.Response { return e.vc.DeprecateClaimTyp(admin, testCTID, nil) },
+		"HasAdmin":              func() *boltvm.Response { return e.vc.HasAdmin(admin) },
+		"GetAdmins":             func() *boltvm.Response { return e.vc.GetAdmins() },
+		"AddAdmin":              func() *boltvm.Response { return e.vc.AddAdmin(admin, admin) },
+		"RemoveAdmin":           func() *boltvm.Response { return e.vc.RemoveAdmin(admin, admin) },
+		"CreateStatusList":      func() *boltvm.Response { return e.vc.CreateStatusList(admin, admin, "sl", "revocation", 0) },
+		"UpdateStatusList":      func() *boltvm.Response { return e.vc.UpdateStatusList(admin, "sl", nil, true) },
+		"GetStatusList":         func() *boltvm.Response { return e.vc.GetStatusList("sl") },
+		"SetVCStatusEntry":      func() *boltvm.Response { return e.vc.SetVCStatusEntry(admin, testCID, "sl", 0) },
+		"SetClaimTypRestricted": func() *boltvm.Response { return e.vc.SetClaimTypRestricted(admin, testCTID, true) },
+		"AddTrustedIssuer":      func() *boltvm.Response { return e.vc.AddTrustedIssuer(admin, testCTID, admin) },
+		"RemoveTrustedIssuer":   func() *boltvm.Response { return e.vc.RemoveTrustedIssuer(admin, testCTID, admin) },
+		"GetClaimTypPolicy":     func() *boltvm.Response { return e.vc.GetClaimTypPolicy(testCTID) },
+		"VerifyPresentation":    func() *boltvm.Response { return e.vc.VerifyPresentation(nil) },
+		"StoreCommittedVC":      func() *boltvm.Response { return e.vc.StoreCommittedVC(admin, nil, nil) },
+		"VerifyDisclosure":      func() *boltvm.Response { return e.vc.VerifyDisclosure(testCID, "name", "", "", nil) },
+		"ListVCsByIssuer":       func() *boltvm.Response { return e.vc.ListVCsByIssuer(admin, 0, 0) },
+		"ListVCsByHolder":       func() *boltvm.Response { return e.vc.ListVCsByHolder(admin, 0, 0) },
+		"ListVCsByClaimTyp":     func() *boltvm.Response { return e.vc.ListVCsByClaimTyp(testCTID, 0, 0) },
+	}
+	for name, fn := range calls {
+		res := fn()
+		require.False(t, res.Ok, name)
+		require.Equal(t, ErrNotInitialized, ParseError(res.Result).Code, name)
+	}
+	require.Empty(t, e.vcStub.Events())
+}
+
+func TestVCManager_ClaimTyp(t *testing.T) {
+	runCalls(t, []call{
+		{
+			name: "create",
+			run: func(e *testEnv) *boltvm.Response {
+				ctb, err := (&bitxid.ClaimTyp{ID: testCTID, Content: []*bitxid.FieldTyp{{Field: "name", Typ: "string"}}}).Marshal()
+				require.Nil(e.t, err)
+				return e.as(e.user).vc.CreateClaimTyp(e.user.did, ctb)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				ctid := &didpb.String{}
+				decode(t, res, ctid)
+				require.Equal(t, testCTID, ctid.Value)
+				ct := &didpb.ClaimTyp{}
+				decode(t, e.vc.GetClaimTyp(testCTID), ct)
+				require.Equal(t, []*didpb.FieldTyp{{Field: "name", Typ: "string"}}, ct.Content)
+				ev := lastEvent(t, e.vcStub)
+				require.Equal(t, EventCreateClaimTyp, ev.Type)
+				require.Equal(t, testCTID, ev.ID)
+			},
+		},
+		{
+			name:  "create twice",
+			setup: func(e *testEnv) { e.newClaimTyp(e.user, testCTID, "name") },
+			run: func(e *testEnv) *boltvm.Response {
+				ctb, err := (&bitxid.ClaimTyp{ID: testCTID}).Marshal()
+				require.Nil(e.t, err)
+				return e.as(e.admin).vc.CreateClaimTyp(e.admin.did, ctb)
+			},
+			code: ErrAlreadyExists,
+		},
+		{
+			name: "create malformed",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).vc.CreateClaimTyp(e.user.did, []byte("{"))
+			},
+			code: ErrInvalidFormat,
+		},
+		{
+			name: "create caller mismatch",
+			run: func(e *testEnv) *boltvm.Response {
+				ctb, err := (&bitxid.ClaimTyp{ID: testCTID}).Marshal()
+				require.Nil(e.t, err)
+				return e.as(e.admin).vc.CreateClaimTyp(e.user.did, ctb)
+			},
+			code: ErrCallerMismatch,
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				requireCode(t, e.vc.GetClaimTyp(testCTID), ErrNotFound)
+			},
+		},
+		{
+			name: "get not existed",
+			run:  func(e *testEnv) *boltvm.Response { return e.vc.GetClaimTyp(testCTID) },
+			code: ErrNotFound,
+		},
+		{
+			name: "get all",
+			setup: func(e *testEnv) {
+				e.newClaimTyp(e.user, "ct-a", "a")
+				e.newClaimTyp(e.user, "ct-b", "b")
+			},
+			run: func(e *testEnv) *boltvm.Response { return e.vc.GetAllClaimTyps() },
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				list := &didpb.ClaimTypList{}
+				decode(t, res, list)
+				var ids []string
+				for _, ct := range list.ClaimTyps {
+					ids = append(ids, ct.Id)
+				}
+				require.ElementsMatch(t, []string{"ct-a", "ct-b"}, ids)
+			},
+		},
+	})
+}
+
+func TestVCManager_StoreVC(t *testing.T) {
+	claim := func(e *testEnv) string {
+		return claimOf(e.t, e.admin.did, map[string]string{"name": "alice"})
+	}
+	withClaimTyp := func(e *testEnv) { e.newClaimTyp(e.user, testCTID, "name") }
+	runCalls(t, []call{
+		{
+			name:  "success",
+			setup: withClaimTyp,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.storeVC(e.user, e.userDoc, e.credential(e.user, testCID, testCTID, claim(e)))
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				info := &didpb.VCInfo{}
+				decode(t, e.vc.GetVC(testCID), info)
+				require.Equal(t, e.user.did, info.Credential.Issuer)
+				require.Equal(t, string(VCActive), info.Status.Status)
+				ev := lastEvent(t, e.vcStub)
+				require.Equal(t, EventStoreVC, ev.Type)
+				require.Equal(t, string(VCActive), ev.NewStatus)
+				require.True(t, verifyVC(t, e, testCID).Valid)
+			},
+		},
+		{
+			name:  "stored twice",
+			setup: issued,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.storeVC(e.user, e.userDoc, e.credential(e.user, testCID, testCTID, claim(e)))
+			},
+			code: ErrAlreadyExists,
+		},
+		{
+			name: "deprecated claim type",
+			setup: func(e *testEnv) {
+				withClaimTyp(e)
+				requireOK(e.t, e.as(e.admin).vc.DeprecateClaimTyp(e.admin.did, testCTID, e.admin.sign(e.t, []byte(testCTID))))
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.storeVC(e.user, e.userDoc, e.credential(e.user, testCID, testCTID, claim(e)))
+			},
+			code: ErrDeprecated,
+		},
+		{
+			name:  "expired",
+			setup: withClaimTyp,
+			run: func(e *testEnv) *boltvm.Response {
+				c := e.credential(e.user, testCID, testCTID, claim(e))
+				c.Expiration = uint64(testTime)
+				e.signCredential(e.user, c)
+				return e.storeVC(e.user, e.userDoc, c)
+			},
+			code: ErrExpired,
+		},
+		{
+			name:  "issuer not registered",
+			setup: withClaimTyp,
+			run: func(e *testEnv) *boltvm.Response {
+				acc := newTestAccount(e.t)
+				docb, _ := acc.doc(e.t)
+				return e.storeVC(acc, docb, e.credential(acc, testCID, testCTID, claim(e)))
+			},
+			code: ErrNotFound,
+		},
+		{
+			name: "issuer frozen",
+			setup: func(e *testEnv) {
+				withClaimTyp(e)
+				userFrozen(e)
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.storeVC(e.user, e.userDoc, e.credential(e.user, testCID, testCTID, claim(e)))
+			},
+			code: ErrInvalidStatus,
+		},
+		{
+			name:  "caller not issuer",
+			setup: withClaimTyp,
+			run: func(e *testEnv) *boltvm.Response {
+				c := e.credential(e.user, testCID, testCTID, claim(e))
+				cb, err := c.Marshal()
+				require.Nil(e.t, err)
+				return e.as(e.admin).vc.StoreVC(e.admin.did, cb, e.userDoc)
+			},
+			code: ErrNotOwner,
+		},
+		{
+			name:  "caller mismatch",
+			setup: withClaimTyp,
+			run: func(e *testEnv) *boltvm.Response {
+				c := e.credential(e.user, testCID, testCTID, claim(e))
+				cb, err := c.Marshal()
+				require.Nil(e.t, err)
+				return e.as(e.admin).vc.StoreVC(e.user.did, cb, e.userDoc)
+			},
+			code: ErrCallerMismatch,
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Empty(t, e.vcStub.Events())
+			},
+		},
+		{
+			name:  "doc not match",
+			setup: withClaimTyp,
+			run: func(e *testEnv) *boltvm.Response {
+				docb, _ := e.admin.doc(e.t)
+				return e.storeVC(e.user, docb, e.credential(e.user, testCID, testCTID, claim(e)))
+			},
+			code: ErrInvalidArgument,
+		},
+		{
+			name:  "malformed signature",
+			setup: withClaimTyp,
+			run: func(e *testEnv) *boltvm.Response {
+				c := e.credential(e.user, testCID, testCTID, claim(e))
+				c.Signature.Content = "!"
+				return e.storeVC(e.user, e.userDoc, c)
+			},
+			code: ErrInvalidFormat,
+		},
+		{
+			name:  "signed by others",
+			setup: withClaimTyp,
+			run: func(e *testEnv) *boltvm.Response {
+				c := e.credential(e.user, testCID, testCTID, claim(e))
+				e.signCredential(e.admin, c)
+				return e.storeVC(e.user, e.userDoc, c)
+			},
+			code: ErrSignatureInvalid,
+		},
+		{
+			name:  "signed by key added without rotation",
+			setup: withClaimTyp,
+			run: func(e *testEnv) *boltvm.Response {
+				other := newTestAccount(e.t)
+				docb, hash := docOf(e.t, e.user.did, other, nil)
+				requireOK(e.t, e.asUser().account.Update(e.user.did, testDocAddr, hash, nil))
+				c := e.credential(e.user, testCID, testCTID, claim(e))
+				e.signCredential(other, c)
+				return e.storeVC(e.user, docb, c)
+			},
+			code: ErrSignatureInvalid,
+		},
+		{
+			name: "malformed credential",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).vc.StoreVC(e.user.did, []byte("{"), e.userDoc)
+			},
+			code: ErrInvalidFormat,
+		},
+	})
+}
+
+func TestVCManager_VerifyVC(t *testing.T) {
+	runCalls(t, []call{
+		{
+			name:  "valid",
+			setup: issued,
+			run:   func(e *testEnv) *boltvm.Response { return e.vc.VerifyVC(testCID) },
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				verdict := &didpb.VCVerdict{}
+				decode(t, res, verdict)
+				require.True(t, verdict.Valid, verdict.Errors)
+				require.Equal(t, string(VCActive), verdict.Status)
+			},
+		},
+		{
+			name: "issuer frozen",
+			setup: func(e *testEnv) {
+				issued(e)
+				userFrozen(e)
+			},
+			run: func(e *testEnv) *boltvm.Response { return e.vc.VerifyVC(testCID) },
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				verdict := &didpb.VCVerdict{}
+				decode(t, res, verdict)
+				require.False(t, verdict.Valid)
+				require.Len(t, verdict.Errors, 1)
+			},
+		},
+		{
+			name: "revoked",
+			setup: func(e *testEnv) {
+				issued(e)
+				requireOK(e.t, e.as(e.user).vc.RevokeVC(e.user.did, testCID, "", e.user.sign(e.t, []byte(testCID))))
+			},
+			run: func(e *testEnv) *boltvm.Response { return e.vc.VerifyVC(testCID) },
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				verdict := &didpb.VCVerdict{}
+				decode(t, res, verdict)
+				require.False(t, verdict.Valid)
+				require.Equal(t, string(VCRevoked), verdict.Status)
+			},
+		},
+		{
+			name: "suspended",
+			setup: func(e *testEnv) {
+				issued(e)
+				requireOK(e.t, e.as(e.user).vc.SuspendVC(e.user.did, testCID, "", e.user.sign(e.t, []byte(testCID))))
+			},
+			run: func(e *testEnv) *boltvm.Response { return e.vc.VerifyVC(testCID) },
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				verdict := &didpb.VCVerdict{}
+				decode(t, res, verdict)
+				require.False(t, verdict.Valid)
+				require.Equal(t, string(VCSuspended), verdict.Status)
+			},
+		},
+		{
+			name: "not valid yet",
+			setup: func(e *testEnv) {
+				issued(e)
+				e.setBlock(testHeight, testTime-1)
+			},
+			run: func(e *testEnv) *boltvm.Response { return e.vc.VerifyVC(testCID) },
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				verdict := &didpb.VCVerdict{}
+				decode(t, res, verdict)
+				require.False(t, verdict.Valid)
+			},
+		},
+		{
+			name: "not existed",
+			run:  func(e *testEnv) *boltvm.Response { return e.vc.VerifyVC(testCID) },
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				verdict := &didpb.VCVerdict{}
+				decode(t, res, verdict)
+				require.False(t, verdict.Valid)
+				require.NotEmpty(t, verdict.Errors)
+			},
+		},
+		{
+			name: "get not existed",
+			run:  func(e *testEnv) *boltvm.Response { return e.vc.GetVC(testCID) },
+			code: ErrNotFound,
+		},
+	})
+}
+
+func TestVCManager_Status(t *testing.T) {
+	suspended := func(e *testEnv) {
+		issued(e)
+		requireOK(e.t, e.as(e.user).vc.SuspendVC(e.user.did, testCID, "lost", e.user.sign(e.t, []byte(testCID+"lost"))))
+	}
+	runCalls(t, []call{
+		{
+			name:  "revoke",
+			setup: issued,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).vc.RevokeVC(e.user.did, testCID, "leaked", e.user.sign(e.t, []byte(testCID+"leaked")))
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				status := vcStatus(t, e, testCID)
+				require.Equal(t, string(VCRevoked), status.Status)
+				require.Equal(t, "leaked", status.Reason)
+				require.Equal(t, testTime, status.Timestamp)
+				ev := lastEvent(t, e.vcStub)
+				require.Equal(t, EventRevokeVC, ev.Type)
+				require.Equal(t, string(VCActive), ev.OldStatus)
+				require.False(t, verifyVC(t, e, testCID).Valid)
+			},
+		},
+		{
+			name:  "revoke with invalid signature",
+			setup: issued,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).vc.RevokeVC(e.user.did, testCID, "leaked", e.user.sign(e.t, []byte(testCID)))
+			},
+			code: ErrSignatureInvalid,
+		},
+		{
+			name: "revoke by self frozen issuer",
+			setup: func(e *testEnv) {
+				issued(e)
+				requireOK(e.t, selfFreezeUser(e, e.user))
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).vc.RevokeVC(e.user.did, testCID, "leaked", e.user.sign(e.t, []byte(testCID+"leaked")))
+			},
+			code: ErrInvalidStatus,
+		},
+		{
+			name:  "revoke by others",
+			setup: issued,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).vc.RevokeVC(e.admin.did, testCID, "leaked", e.admin.sign(e.t, []byte(testCID+"leaked")))
+			},
+			code: ErrNotOwner,
+		},
+		{
+			name: "revoke twice",
+			setup: func(e *testEnv) {
+				issued(e)
+				requireOK(e.t, e.as(e.user).vc.DeleteVC(e.user.did, testCID))
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).vc.RevokeVC(e.user.did, testCID, "leaked", e.user.sign(e.t, []byte(testCID+"leaked")))
+			},
+			code: ErrInvalidStatus,
+		},
+		{
+			name: "revoke not existed",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).vc.RevokeVC(e.user.did, testCID, "", e.user.sign(e.t, []byte(testCID)))
+			},
+			code: ErrNotFound,
+		},
+		{
+			name:  "delete",
+			setup: issued,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).vc.DeleteVC(e.user.did, testCID)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, string(VCRevoked), vcStatus(t, e, testCID).Status)
+				require.Equal(t, EventDeleteVC, lastEvent(t, e.vcStub).Type)
+			},
+		},
+		{
+			name:  "delete by others",
+			setup: issued,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).vc.DeleteVC(e.admin.did, testCID)
+			},
+			code: ErrNotOwner,
+		},
+		{
+			name:  "suspend",
+			setup: suspended,
+			run:   func(e *testEnv) *boltvm.Response { return e.vc.GetVCStatus(testCID) },
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				status := &didpb.VCStatus{}
+				decode(t, res, status)
+				require.Equal(t, string(VCSuspended), status.Status)
+				require.Equal(t, "lost", status.Reason)
+			},
+		},
+		{
+			name:  "suspend twice",
+			setup: suspended,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).vc.SuspendVC(e.user.did, testCID, "lost", e.user.sign(e.t, []byte(testCID+"lost")))
+			},
+			code: ErrInvalidStatus,
+		},
+		{
+			name:  "unsuspend",
+			setup: suspended,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).vc.UnsuspendVC(e.user.did, testCID, e.user.sign(e.t, []byte(testCID)))
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, string(VCActive), vcStatus(t, e, testCID).Status)
+				require.Equal(t, EventUnsuspendVC, lastEvent(t, e.vcStub).Type)
+				require.True(t, verifyVC(t, e, testCID).Valid)
+			},
+		},
+		{
+			name:  "unsuspend not suspended",
+			setup: issued,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).vc.UnsuspendVC(e.user.did, testCID, e.user.sign(e.t, []byte(testCID)))
+			},
+			code: ErrInvalidStatus,
+		},
+		{
+			name: "expired",
+			setup: func(e *testEnv) {
+				e.newClaimTyp(e.user, testCTID, "name")
+				c := e.credential(e.user, testCID, testCTID, claimOf(e.t, e.admin.did, map[string]string{"name": "alice"}))
+				c.Expiration = uint64(testTime + 60)
+				e.signCredential(e.user, c)
+				requireOK(e.t, e.storeVC(e.user, e.userDoc, c))
+				e.setBlock(testHeight+1, testTime+61)
+			},
+			run: func(e *testEnv) *boltvm.Response { return e.vc.GetVCStatus(testCID) },
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				status := &didpb.VCStatus{}
+				decode(t, res, status)
+				require.Equal(t, string(VCExpired), status.Status)
+			},
+		},
+		{
+			name: "status of not existed",
+			run:  func(e *testEnv) *boltvm.Response { return e.vc.GetVCStatus(testCID) },
+			code: ErrNotFound,
+		},
+	})
+}
+
+func TestVCManager_StatusErrors(t *testing.T) {
+	// status changes by acc as caller, each signed by acc
+	changes := map[string]func(e *testEnv, acc *testAccount, caller string) *boltvm.Response{
+		"revoke": func(e *testEnv, acc *testAccount, caller string) *boltvm.Response {
+			return e.as(acc).vc.RevokeVC(caller, testCID, "", acc.sign(e.t, []byte(testCID)))
+		},
+		"suspend": func(e *testEnv, acc *testAccount, caller string) *boltvm.Response {
+			return e.as(acc).vc.SuspendVC(caller, testCID, "", acc.sign(e.t, []byte(testCID)))
+		},
+		"unsuspend": func(e *testEnv, acc *testAccount, caller string) *boltvm.Response {
+			return e.as(acc).vc.UnsuspendVC(caller, testCID, acc.sign(e.t, []byte(testCID)))
+		},
+		"delete": func(e *testEnv, acc *testAccount, caller string) *boltvm.Response {
+			return e.as(acc).vc.DeleteVC(caller, testCID)
+		},
+	}
+	var calls []call
+	for name, change := range changes {
+		change := change
+		calls = append(calls,
+			call{
+				name:  name + " caller mismatch",
+				setup: issued,
+				run:   func(e *testEnv) *boltvm.Response { return change(e, e.admin, e.user.did) },
+				code:  ErrCallerMismatch,
+				check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+					require.Equal(t, string(VCActive), vcStatus(t, e, testCID).Status)
+				},
+			},
+			call{
+				name:  name + " by others",
+				setup: issued,
+				run:   func(e *testEnv) *boltvm.Response { return change(e, e.admin, e.admin.did) },
+				code:  ErrNotOwner,
+			},
+			call{
+				name: name + " not existed",
+				run:  func(e *testEnv) *boltvm.Response { return change(e, e.user, e.user.did) },
+				code: ErrNotFound,
+			},
+		)
+	}
+	runCalls(t, calls)
+}
+
+func TestVCManager_ForceRevokeVC(t *testing.T) {
+	runCalls(t, []call{
+		{
+			name:  "by admin",
+			setup: issued,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).vc.ForceRevokeVC(e.admin.did, testCID, "compromised", e.admin.sign(e.t, []byte(testCID+"compromised")))
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, string(VCRevoked), vcStatus(t, e, testCID).Status)
+				ev := lastEvent(t, e.vcStub)
+				require.Equal(t, EventRevokeVC, ev.Type)
+				require.Equal(t, bitxid.DID(e.user.did), ev.DID)
+				require.Equal(t, bitxid.DID(e.admin.did), ev.Actor)
+			},
+		},
+		{
+			name:  "by issuer",
+			setup: issued,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).vc.ForceRevokeVC(e.user.did, testCID, "", e.user.sign(e.t, []byte(testCID)))
+			},
+			code: ErrNotAdmin,
+		},
+		{
+			name:  "caller mismatch",
+			setup: issued,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).vc.ForceRevokeVC(e.admin.did, testCID, "", e.user.sign(e.t, []byte(testCID)))
+			},
+			code: ErrCallerMismatch,
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, string(VCActive), vcStatus(t, e, testCID).Status)
+			},
+		},
+		{
+			name:  "invalid signature",
+			setup: issued,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).vc.ForceRevokeVC(e.admin.did, testCID, "", e.user.sign(e.t, []byte(testCID)))
+			},
+			code: ErrSignatureInvalid,
+		},
+		{
+			name: "not existed",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).vc.ForceRevokeVC(e.admin.did, testCID, "", e.admin.sign(e.t, []byte(testCID)))
+			},
+			code: ErrNotFound,
+		},
+	})
+}
+
+func TestVCManager_DeprecateClaimTyp(t *testing.T) {
+	deprecate := func(e *testEnv, acc *testAccount) *boltvm.Response {
+		return e.as(acc).vc.DeprecateClaimTyp(acc.did, testCTID, acc.sign(e.t, []byte(testCTID)))
+	}
+	runCalls(t, []call{
+		{
+			name:  "success",
+			setup: issued,
+			run:   func(e *testEnv) *boltvm.Response { return deprecate(e, e.admin) },
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, EventDeprecateClaimTyp, lastEvent(t, e.vcStub).Type)
+				require.False(t, verifyVC(t, e, testCID).Valid)
+			},
+		},
+		{
+			name:  "by others",
+			setup: issued,
+			run:   func(e *testEnv) *boltvm.Response { return deprecate(e, e.user) },
+			code:  ErrNotAdmin,
+		},
+		{
+			name:  "caller mismatch",
+			setup: issued,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).vc.DeprecateClaimTyp(e.admin.did, testCTID, e.user.sign(e.t, []byte(testCTID)))
+			},
+			code: ErrCallerMismatch,
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.True(t, verifyVC(t, e, testCID).Valid)
+			},
+		},
+		{
+			name: "twice",
+			setup: func(e *testEnv) {
+				issued(e)
+				requireOK(e.t, deprecate(e, e.admin))
+			},
+			run:  func(e *testEnv) *boltvm.Response { return deprecate(e, e.admin) },
+			code: ErrDeprecated,
+		},
+		{
+			name: "not existed",
+			run:  func(e *testEnv) *boltvm.Response { return deprecate(e, e.admin) },
+			code: ErrNotFound,
+		},
+	})
+}
+
+func TestVCManager_Admins(t *testing.T) {
+	admins := func(e *testEnv) []string {
+		res := &didpb.StringSlice{}
+		decode(e.t, e.vc.GetAdmins(), res)
+		return res.Slice
+	}
+	runCalls(t, []call{
+		{
+			name: "add",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).vc.AddAdmin(e.admin.did, e.user.did)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, []string{e.admin.did, e.user.did}, admins(e))
+				has := &didpb.Bool{}
+				decode(t, e.vc.HasAdmin(e.user.did), has)
+				require.True(t, has.Value)
+				ev := lastAdminEvent(t, e.vcStub)
+				require.Equal(t, AdminAdded, ev.Action)
+				require.Equal(t, vcRegistryName, ev.Registry)
+				require.Equal(t, bitxid.DID(e.user.did), ev.Admin)
+			},
+		},
+		{
+			name: "add invalid did",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).vc.AddAdmin(e.admin.did, testAppChainDID)
+			},
+			code: ErrInvalidFormat,
+		},
+		{
+			name: "add by others",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).vc.AddAdmin(e.user.did, e.user.did)
+			},
+			code: ErrNotSuperAdmin,
+		},
+		{
+			name: "add caller mismatch",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).vc.AddAdmin(e.admin.did, e.user.did)
+			},
+			code: ErrCallerMismatch,
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, []string{e.admin.did}, admins(e))
+			},
+		},
+		{
+			name: "add existing",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).vc.AddAdmin(e.admin.did, e.admin.did)
+			},
+			code: ErrAlreadyExists,
+		},
+		{
+			name:  "remove",
+			setup: func(e *testEnv) { requireOK(e.t, e.as(e.admin).vc.AddAdmin(e.admin.did, e.user.did)) },
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).vc.RemoveAdmin(e.admin.did, e.user.did)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, []string{e.admin.did}, admins(e))
+				require.Equal(t, AdminRemoved, lastAdminEvent(t, e.vcStub).Action)
+			},
+		},
+		{
+			name:  "remove by others",
+			setup: func(e *testEnv) { requireOK(e.t, e.as(e.admin).vc.AddAdmin(e.admin.did, e.user.did)) },
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).vc.RemoveAdmin(e.user.did, e.user.did)
+			},
+			code: ErrNotSuperAdmin,
+		},
+		{
+			name:  "remove caller mismatch",
+			setup: func(e *testEnv) { requireOK(e.t, e.as(e.admin).vc.AddAdmin(e.admin.did, e.user.did)) },
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).vc.RemoveAdmin(e.admin.did, e.user.did)
+			},
+			code: ErrCallerMismatch,
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, []string{e.admin.did, e.user.did}, admins(e))
+			},
+		},
+		{
+			name: "remove super admin",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).vc.RemoveAdmin(e.admin.did, e.admin.did)
+			},
+			code: ErrInvalidArgument,
+		},
+		{
+			name: "remove non admin",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).vc.RemoveAdmin(e.admin.did, e.user.did)
+			},
+			code: ErrNotFound,
+		},
+	})
+}
@@ -0,0 +1,298 @@
+package contracts
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/meshplus/bitxhub-core/boltvm"
+	"github.com/meshplus/bitxid"
+)
+
+// purposes of a status list
+const (
+	StatusPurposeRevocation = "revocation"
+	StatusPurposeSuspension = "suspension"
+)
+
+// minStatusListLength is the minimum bitstring length (16KB) suggested
+// by Status List 2021 for group privacy.
+const minStatusListLength = 131072
+
+// maxStatusListLength bounds the bitstring (128KB) an update decompresses.
+const maxStatusListLength = 1 << 20
+
+// StatusList represents a Status List 2021 style bitstring of an issuer,
+// bit at index i set means credential referencing i is revoked or suspended,
+// bits of a revocation list can not be cleared.
+type StatusList struct {
+	ID      string     // id of the status list
+	Issuer  bitxid.DID // issuer who manages the list
+	Purpose string     // revocation or suspension
+	Length  uint64     // number of bits in the list
+	List    []byte     // gzip compressed bitstring, mostly zeros so kept small in state
+	Updated int64      // time of the last update, 0 if the stub provided no block time
+}
+
+// EncodedStatusList is the verifier-facing form of a status list.
+// @EncodedList: base64 encoded gzip compressed bitstring
+type EncodedStatusList struct {
+	ID          string
+	Issuer      bitxid.DID
+	Purpose     string
+	Length      uint64
+	EncodedList string
+	Updated     int64
+}
+
+// StatusListEntry references an index in a status list from a credential.
+type StatusListEntry struct {
+	ListID string
+	Index  uint64
+}
+
+// bitstring is a decompressed status list.
+type bitstring []byte
+
+func (bs bitstring) get(index uint64) bool {
+	return bs[index/8]&(0x80>>(index%8)) != 0
+}
+
+func (bs bitstring) set(index uint64, value bool) {
+	if value {
+		bs[index/8] |= 0x80 >> (index % 8)
+	} else {
+		bs[index/8] &^= 0x80 >> (index % 8)
+	}
+}
+
+// bits decompresses the list, which is never longer than Length bits.
+func (sl *StatusList) bits() (bitstring, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(sl.List))
+	if err != nil {
+		return nil, err
+	}
+	size := int64((sl.Length + 7) / 8)
+	bs, err := ioutil.ReadAll(io.LimitReader(zr, size))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(bs)) != size {
+		return nil, fmt.Errorf("status list %s has %d bytes, want %d", sl.ID, len(bs), size)
+	}
+	return bs, nil
+}
+
+// setBits compresses bs into the list.
+func (sl *StatusList) setBits(bs bitstring) error {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(bs); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	sl.List = buf.Bytes()
+	return nil
+}
+
+// encode returns the list with its bitstring as Status List 2021 encodedList.
+func (sl *StatusList) encode() *EncodedStatusList {
+	return &EncodedStatusList{
+		ID:          sl.ID,
+		Issuer:      sl.Issuer,
+		Purpose:     sl.Purpose,
+		Length:      sl.Length,
+		EncodedList: base64.StdEncoding.EncodeToString(sl.List),
+		Updated:     sl.Updated,
+	}
+}
+
+// CreateStatusList creates an empty status list for the issuer,
+// caller should be the issuer (or owner of the issuer if it is a chain did).
+func (mm *VCManager) CreateStatusList(caller, issuer, listID, purpose string, length uint64) *boltvm.Response {
+	vcr := mm.getVCRegistry()
+
+	callerDID := bitxid.DID(caller)
+	if res := authorize(mm.Stub, vcr.Initalized, caller); res != nil {
+		return res
+	}
+	if purpose != StatusPurposeRevocation && purpose != StatusPurposeSuspension {
+		return errorResponse(ErrInvalidArgument, "create status list err, unknown purpose: "+purpose)
+	}
+	if mm.Has(statusListKey(listID)) {
+		return errorResponse(ErrAlreadyExists, "create status list err, status list "+listID+" already existed")
+	}
+
+	info, err := mm.resolveDID(bitxid.DID(issuer))
+	if err != nil {
+		return wrapErrorResponse(ErrInternal, "create status list err, ", err)
+	}
+	if info.Status != bitxid.Normal {
+		return errorResponse(ErrInvalidStatus, "create status list err, issuer("+issuer+") is under status: "+string(info.Status))
+	}
+	if info.Owner != callerDID {
+		return errorResponse(ErrNotOwner, "create status list err, caller("+caller+") is not issuer("+issuer+")")
+	}
+
+	if length > maxStatusListLength {
+		return errorResponse(ErrInvalidArgument, fmt.Sprintf("create status list err, length %d exceeds %d", length, maxStatusListLength))
+	}
+	if length < minStatusListLength {
+		length = minStatusListLength
+	}
+	clk := blockClock(mm.Stub)
+	sl := &StatusList{
+		ID:      listID,
+		Issuer:  bitxid.DID(issuer),
+		Purpose: purpose,
+		Length:  length,
+		Updated: clk.now,
+	}
+	if err := sl.setBits(make(bitstring, (length+7)/8)); err != nil {
+		return wrapErrorResponse(ErrInternal, "create status list err, ", err)
+	}
+	mm.SetObject(statusListKey(listID), sl)
+	postVCEvent(mm.Stub, EventCreateStatusList, listID, sl.Issuer, "", "", callerDID)
+
+	return stringResponse(listID)
+}
+
+// UpdateStatusList sets bits of the status list in batch,
+// indexesb is marshaled []uint64, caller should be the list issuer,
+// bits can only be cleared on a suspension list since revocation is permanent.
+func (mm *VCManager) UpdateStatusList(caller, listID string, indexesb []byte, value bool) *boltvm.Response {
+	vcr := mm.getVCRegistry()
+
+	callerDID := bitxid.DID(caller)
+	if res := authorize(mm.Stub, vcr.Initalized, caller); res != nil {
+		return res
+	}
+
+	var indexes []uint64
+	if err := bitxid.Unmarshal(indexesb, &indexes); err != nil {
+		return wrapErrorResponse(ErrInvalidFormat, "params unmarshal err: ", err)
+	}
+
+	sl, err := mm.getStatusList(listID)
+	if err != nil {
+		return wrapErrorResponse(ErrInternal, "update status list err, ", err)
+	}
+	if err := mm.checkIssuer(sl.Issuer, callerDID); err != nil {
+		return wrapErrorResponse(ErrInternal, "update status list err, ", err)
+	}
+	if !value && sl.Purpose != StatusPurposeSuspension {
+		return errorResponse(ErrInvalidArgument, "update status list err, bits of "+sl.Purpose+" list "+listID+" can not be cleared")
+	}
+
+	bs, err := sl.bits()
+	if err != nil {
+		return wrapErrorResponse(ErrInternal, "update status list err, ", err)
+	}
+	clk := blockClock(mm.Stub)
+	for _, index := range indexes {
+		if index >= sl.Length {
+			return errorResponse(ErrInvalidArgument, fmt.Sprintf("update status list err, index %d out of range %d", index, sl.Length))
+		}
+		bs.set(index, value)
+	}
+	if err := sl.setBits(bs); err != nil {
+		return wrapErrorResponse(ErrInternal, "update status list err, ", err)
+	}
+	sl.Updated = clk.now
+
+	mm.SetObject(statusListKey(listID), sl)
+	postVCEvent(mm.Stub, EventUpdateStatusList, listID, sl.Issuer, "", "", callerDID)
+	return boltvm.Success(nil)
+}
+
+// GetStatusList gets the encoded status list, verifiers can cache it.
+func (mm *VCManager) GetStatusList(listID string) *boltvm.Response {
+	vcr := mm.getVCRegistry()
+
+	if res := checkInitialized(vcr.Initalized); res != nil {
+		return res
+	}
+
+	sl, err := mm.getStatusList(listID)
+	if err != nil {
+		return wrapErrorResponse(ErrInternal, "get status list err, ", err)
+	}
+
+	return success(sl.encode().toPB())
+}
+
+// SetVCStatusEntry makes the credential reference an index in the status list,
+// caller should be issuer of both the credential and the list.
+// The entry can't be changed once set, otherwise a credential revoked by
+// its bit could be pointed at a clear bit and come back.
+func (mm *VCManager) SetVCStatusEntry(caller, cid, listID string, index uint64) *boltvm.Response {
+	vcr := mm.getVCRegistry()
+
+	callerDID := bitxid.DID(caller)
+	if res := authorize(mm.Stub, vcr.Initalized, caller); res != nil {
+		return res
+	}
+
+	vc, err := mm.getIssuedVC(vcr, callerDID, cid)
+	if err != nil {
+		return wrapErrorResponse(ErrInternal, "set vc status entry err, ", err)
+	}
+	if mm.Has(statusEntryKey(cid)) {
+		return errorResponse(ErrAlreadyExists, "set vc status entry err, vc "+cid+" already references a status list")
+	}
+	sl, err := mm.getStatusList(listID)
+	if err != nil {
+		return wrapErrorResponse(ErrInternal, "set vc status entry err, ", err)
+	}
+	if sl.Issuer != vc.Issuer {
+		return errorResponse(ErrNotOwner, "set vc status entry err, status list "+listID+" is not issued by "+string(vc.Issuer))
+	}
+	if index >= sl.Length {
+		return errorResponse(ErrInvalidArgument, fmt.Sprintf("set vc status entry err, index %d out of range %d", index, sl.Length))
+	}
+
+	mm.SetObject(statusEntryKey(cid), &StatusListEntry{ListID: listID, Index: index})
+	return boltvm.Success(nil)
+}
+
+// listStatus looks up status of the credential from the status list it references,
+// returns VCActive if there is no reference or the bit is not set.
+func (mm *VCManager) listStatus(cid string) (VCStatusType, string) {
+	entry := &StatusListEntry{}
+	if !mm.GetObject(statusEntryKey(cid), entry) {
+		return VCActive, ""
+	}
+	sl, err := mm.getStatusList(entry.ListID)
+	if err != nil || entry.Index >= sl.Length {
+		return VCActive, ""
+	}
+	bs, err := sl.bits()
+	if err != nil || !bs.get(entry.Index) {
+		return VCActive, ""
+	}
+	if sl.Purpose == StatusPurposeSuspension {
+		return VCSuspended, "status list " + sl.ID
+	}
+	return VCRevoked, "status list " + sl.ID
+}
+
+func (mm *VCManager) getStatusList(listID string) (*StatusList, error) {
+	sl := &StatusList{}
+	if !mm.GetObject(statusListKey(listID), sl) {
+		return nil, newError(ErrNotFound, "status list %s not existed", listID)
+	}
+	return sl, nil
+}
+
+func statusListKey(listID string) string {
+	return "statuslist-" + listID
+}
+
+func statusEntryKey(cid string) string {
+	return "statusentry-" + cid
+}
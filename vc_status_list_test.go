@@ -0,0 +1,302 @@
+package contracts
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io/ioutil"
+	"math"
+	"testing"
+
+	"github.com/meshplus/bitxhub-core/boltvm"
+	"github.com/meshplus/bitxid"
+	"github.com/meshplus/did-registry/didpb"
+	"github.com/stretchr/testify/require"
+)
+
+const testListID = "sl-1"
+
+// listCreated creates testListID of the purpose issued by user.
+func listCreated(e *testEnv, purpose string) {
+	requireOK(e.t, e.as(e.user).vc.CreateStatusList(e.user.did, e.user.did, testListID, purpose, 0))
+}
+
+func indexes(t *testing.T, idx ...uint64) []byte {
+	b, err := bitxid.Marshal(idx)
+	require.Nil(t, err)
+	return b
+}
+
+// decodeList decompresses the encoded bitstring of the status list.
+func decodeList(t *testing.T, sl *didpb.StatusList) []byte {
+	gz, err := base64.StdEncoding.DecodeString(sl.EncodedList)
+	require.Nil(t, err)
+	zr, err := gzip.NewReader(bytes.NewReader(gz))
+	require.Nil(t, err)
+	bits, err := ioutil.ReadAll(zr)
+	require.Nil(t, err)
+	return bits
+}
+
+func TestVCManager_StatusList(t *testing.T) {
+	entried := func(e *testEnv, purpose string) {
+		issued(e)
+		listCreated(e, purpose)
+		requireOK(e.t, e.as(e.user).vc.SetVCStatusEntry(e.user.did, testCID, testListID, 42))
+	}
+	runCalls(t, []call{
+		{
+			name: "create",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).vc.CreateStatusList(e.user.did, e.user.did, testListID, StatusPurposeRevocation, 8)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				sl := &didpb.StatusList{}
+				decode(t, e.vc.GetStatusList(testListID), sl)
+				require.Equal(t, e.user.did, sl.Issuer)
+				require.Equal(t, uint64(minStatusListLength), sl.Length)
+				require.Equal(t, make([]byte, minStatusListLength/8), decodeList(t, sl))
+				ev := lastEvent(t, e.vcStub)
+				require.Equal(t, EventCreateStatusList, ev.Type)
+				require.Equal(t, testListID, ev.ID)
+			},
+		},
+		{
+			name: "create longest",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).vc.CreateStatusList(e.user.did, e.user.did, testListID, StatusPurposeRevocation, maxStatusListLength)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				// state keeps the compressed list only
+				stored := &StatusList{}
+				require.True(t, e.vcStub.GetObject(statusListKey(testListID), stored))
+				require.Less(t, len(stored.List), 1024)
+				requireOK(t, e.as(e.user).vc.UpdateStatusList(e.user.did, testListID, indexes(t, maxStatusListLength-1), true))
+				sl := &didpb.StatusList{}
+				decode(t, e.vc.GetStatusList(testListID), sl)
+				require.Equal(t, byte(0x01), decodeList(t, sl)[maxStatusListLength/8-1])
+			},
+		},
+		{
+			name: "create too long",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).vc.CreateStatusList(e.user.did, e.user.did, testListID, StatusPurposeRevocation, maxStatusListLength+1)
+			},
+			code: ErrInvalidArgument,
+		},
+		{
+			name: "create with overflowing length",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).vc.CreateStatusList(e.user.did, e.user.did, testListID, StatusPurposeRevocation, math.MaxUint64)
+			},
+			code: ErrInvalidArgument,
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				requireCode(t, e.vc.GetStatusList(testListID), ErrNotFound)
+			},
+		},
+		{
+			name: "create with unknown purpose",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).vc.CreateStatusList(e.user.did, e.user.did, testListID, "other", 0)
+			},
+			code: ErrInvalidArgument,
+		},
+		{
+			name:  "create twice",
+			setup: func(e *testEnv) { listCreated(e, StatusPurposeRevocation) },
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).vc.CreateStatusList(e.user.did, e.user.did, testListID, StatusPurposeRevocation, 0)
+			},
+			code: ErrAlreadyExists,
+		},
+		{
+			name: "create for others",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).vc.CreateStatusList(e.admin.did, e.user.did, testListID, StatusPurposeRevocation, 0)
+			},
+			code: ErrNotOwner,
+		},
+		{
+			name: "update",
+			setup: func(e *testEnv) {
+				listCreated(e, StatusPurposeRevocation)
+				e.setBlock(testHeight+1, testTime+1)
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).vc.UpdateStatusList(e.user.did, testListID, indexes(e.t, 0, 9), true)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				sl := &didpb.StatusList{}
+				decode(t, e.vc.GetStatusList(testListID), sl)
+				bits := decodeList(t, sl)
+				require.Equal(t, byte(0x80), bits[0])
+				require.Equal(t, byte(0x40), bits[1])
+				require.Equal(t, testTime+1, sl.Updated)
+				require.Equal(t, EventUpdateStatusList, lastEvent(t, e.vcStub).Type)
+			},
+		},
+		{
+			name:  "update by others",
+			setup: func(e *testEnv) { listCreated(e, StatusPurposeRevocation) },
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).vc.UpdateStatusList(e.admin.did, testListID, indexes(e.t, 0), true)
+			},
+			code: ErrNotOwner,
+		},
+		{
+			name:  "update out of range",
+			setup: func(e *testEnv) { listCreated(e, StatusPurposeRevocation) },
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).vc.UpdateStatusList(e.user.did, testListID, indexes(e.t, minStatusListLength), true)
+			},
+			code: ErrInvalidArgument,
+		},
+		{
+			name: "update not existed",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).vc.UpdateStatusList(e.user.did, testListID, indexes(e.t, 0), true)
+			},
+			code: ErrNotFound,
+		},
+		{
+			name:  "revoked by list",
+			setup: func(e *testEnv) { entried(e, StatusPurposeRevocation) },
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).vc.UpdateStatusList(e.user.did, testListID, indexes(e.t, 42), true)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				status := vcStatus(t, e, testCID)
+				require.Equal(t, string(VCRevoked), status.Status)
+				require.Equal(t, "status list "+testListID, status.Reason)
+				require.False(t, verifyVC(t, e, testCID).Valid)
+			},
+		},
+		{
+			name: "clear revoked by list",
+			setup: func(e *testEnv) {
+				entried(e, StatusPurposeRevocation)
+				requireOK(e.t, e.as(e.user).vc.UpdateStatusList(e.user.did, testListID, indexes(e.t, 42), true))
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).vc.UpdateStatusList(e.user.did, testListID, indexes(e.t, 42), false)
+			},
+			code: ErrInvalidArgument,
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, string(VCRevoked), vcStatus(t, e, testCID).Status)
+			},
+		},
+		{
+			name: "suspended by list",
+			setup: func(e *testEnv) {
+				entried(e, StatusPurposeSuspension)
+				requireOK(e.t, e.as(e.user).vc.UpdateStatusList(e.user.did, testListID, indexes(e.t, 42), true))
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).vc.UpdateStatusList(e.user.did, testListID, indexes(e.t, 42), false)
+			},
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, string(VCActive), vcStatus(t, e, testCID).Status)
+			},
+		},
+		{
+			name: "entry repointed",
+			setup: func(e *testEnv) {
+				entried(e, StatusPurposeRevocation)
+				requireOK(e.t, e.as(e.user).vc.UpdateStatusList(e.user.did, testListID, indexes(e.t, 42), true))
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).vc.SetVCStatusEntry(e.user.did, testCID, testListID, 43)
+			},
+			code: ErrAlreadyExists,
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, string(VCRevoked), vcStatus(t, e, testCID).Status)
+			},
+		},
+		{
+			name: "entry of others list",
+			setup: func(e *testEnv) {
+				issued(e)
+				requireOK(e.t, e.as(e.admin).vc.CreateStatusList(e.admin.did, e.admin.did, testListID, StatusPurposeRevocation, 0))
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).vc.SetVCStatusEntry(e.user.did, testCID, testListID, 0)
+			},
+			code: ErrNotOwner,
+		},
+		{
+			name: "entry out of range",
+			setup: func(e *testEnv) {
+				issued(e)
+				listCreated(e, StatusPurposeRevocation)
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).vc.SetVCStatusEntry(e.user.did, testCID, testListID, minStatusListLength)
+			},
+			code: ErrInvalidArgument,
+		},
+		{
+			name: "entry by others",
+			setup: func(e *testEnv) {
+				issued(e)
+				listCreated(e, StatusPurposeRevocation)
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).vc.SetVCStatusEntry(e.admin.did, testCID, testListID, 0)
+			},
+			code: ErrNotOwner,
+		},
+		{
+			name:  "entry of not existed vc",
+			setup: func(e *testEnv) { listCreated(e, StatusPurposeRevocation) },
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).vc.SetVCStatusEntry(e.user.did, testCID, testListID, 0)
+			},
+			code: ErrNotFound,
+		},
+		{
+			name:  "entry of not existed list",
+			setup: issued,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).vc.SetVCStatusEntry(e.user.did, testCID, testListID, 0)
+			},
+			code: ErrNotFound,
+		},
+		{
+			name: "create caller mismatch",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).vc.CreateStatusList(e.user.did, e.user.did, testListID, StatusPurposeRevocation, 0)
+			},
+			code: ErrCallerMismatch,
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				requireCode(t, e.vc.GetStatusList(testListID), ErrNotFound)
+			},
+		},
+		{
+			name:  "update caller mismatch",
+			setup: func(e *testEnv) { listCreated(e, StatusPurposeRevocation) },
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).vc.UpdateStatusList(e.user.did, testListID, indexes(e.t, 0), true)
+			},
+			code: ErrCallerMismatch,
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Empty(t, e.vcStub.Events())
+			},
+		},
+		{
+			name: "entry caller mismatch",
+			setup: func(e *testEnv) {
+				issued(e)
+				listCreated(e, StatusPurposeRevocation)
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).vc.SetVCStatusEntry(e.user.did, testCID, testListID, 0)
+			},
+			code: ErrCallerMismatch,
+		},
+		{
+			name: "get not existed",
+			run:  func(e *testEnv) *boltvm.Response { return e.vc.GetStatusList(testListID) },
+			code: ErrNotFound,
+		},
+	})
+}
@@ -0,0 +1,154 @@
+package contracts
+
+import (
+	"github.com/meshplus/bitxhub-core/boltvm"
+	"github.com/meshplus/bitxid"
+)
+
+// ClaimTypPolicy represents issuer policy of a claim type.
+// @Restricted: only trusted issuers can issue credentials of the claim type
+// @TrustedIssuers: account dids, or chain dids which trust every did under the chain
+type ClaimTypPolicy struct {
+	ID             string
+	Owner          bitxid.DID
+	Restricted     bool
+	TrustedIssuers []bitxid.DID
+}
+
+// trusts checks whether issuer is allowed to issue credentials of the claim type.
+func (p *ClaimTypPolicy) trusts(issuer bitxid.DID) bool {
+	if !p.Restricted {
+		return true
+	}
+	for _, trusted := range p.TrustedIssuers {
+		if trusted == issuer {
+			return true
+		}
+		if trusted.GetType() == int(bitxid.ChainDIDType) && trusted == issuer.GetChainDID() {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *ClaimTypPolicy) hasTrustedIssuer(issuer bitxid.DID) bool {
+	for _, trusted := range p.TrustedIssuers {
+		if trusted == issuer {
+			return true
+		}
+	}
+	return false
+}
+
+// SetClaimTypRestricted sets whether the claim type only accepts trusted issuers,
+// caller should be owner of the claim type or admin.
+func (mm *VCManager) SetClaimTypRestricted(caller, ctid string, restricted bool) *boltvm.Response {
+	vcr := mm.getVCRegistry()
+
+	callerDID := bitxid.DID(caller)
+	if res := authorize(mm.Stub, vcr.Initalized, caller); res != nil {
+		return res
+	}
+
+	policy, err := mm.getOwnedClaimTypPolicy(vcr, callerDID, ctid)
+	if err != nil {
+		return wrapErrorResponse(ErrInternal, "set claim type restricted err, ", err)
+	}
+	policy.Restricted = restricted
+
+	mm.SetObject(claimTypPolicyKey(ctid), policy)
+	return boltvm.Success(nil)
+}
+
+// AddTrustedIssuer adds issuer to the allow-list of the claim type,
+// a chain did issuer delegates trust to every did under the chain,
+// caller should be owner of the claim type or admin.
+func (mm *VCManager) AddTrustedIssuer(caller, ctid, issuer string) *boltvm.Response {
+	vcr := mm.getVCRegistry()
+
+	callerDID := bitxid.DID(caller)
+	if res := authorize(mm.Stub, vcr.Initalized, caller); res != nil {
+		return res
+	}
+	if !bitxid.DID(issuer).IsValidFormat() {
+		return errorResponse(ErrInvalidFormat, "not valid issuer format")
+	}
+
+	policy, err := mm.getOwnedClaimTypPolicy(vcr, callerDID, ctid)
+	if err != nil {
+		return wrapErrorResponse(ErrInternal, "add trusted issuer err, ", err)
+	}
+	if policy.hasTrustedIssuer(bitxid.DID(issuer)) {
+		return errorResponse(ErrAlreadyExists, "add trusted issuer err, "+issuer+" is already trusted by "+ctid)
+	}
+	policy.TrustedIssuers = append(policy.TrustedIssuers, bitxid.DID(issuer))
+
+	mm.SetObject(claimTypPolicyKey(ctid), policy)
+	return boltvm.Success(nil)
+}
+
+// RemoveTrustedIssuer removes issuer from the allow-list of the claim type,
+// caller should be owner of the claim type or admin.
+func (mm *VCManager) RemoveTrustedIssuer(caller, ctid, issuer string) *boltvm.Response {
+	vcr := mm.getVCRegistry()
+
+	callerDID := bitxid.DID(caller)
+	if res := authorize(mm.Stub, vcr.Initalized, caller); res != nil {
+		return res
+	}
+
+	policy, err := mm.getOwnedClaimTypPolicy(vcr, callerDID, ctid)
+	if err != nil {
+		return wrapErrorResponse(ErrInternal, "remove trusted issuer err, ", err)
+	}
+	for i, trusted := range policy.TrustedIssuers {
+		if trusted == bitxid.DID(issuer) {
+			policy.TrustedIssuers = append(policy.TrustedIssuers[:i], policy.TrustedIssuers[i+1:]...)
+			mm.SetObject(claimTypPolicyKey(ctid), policy)
+			return boltvm.Success(nil)
+		}
+	}
+	return errorResponse(ErrNotFound, "remove trusted issuer err, "+issuer+" is not trusted by "+ctid)
+}
+
+// GetClaimTypPolicy gets issuer policy of the claim type.
+func (mm *VCManager) GetClaimTypPolicy(ctid string) *boltvm.Response {
+	vcr := mm.getVCRegistry()
+
+	if res := checkInitialized(vcr.Initalized); res != nil {
+		return res
+	}
+
+	return success(mm.getClaimTypPolicy(ctid).toPB())
+}
+
+// getClaimTypPolicy gets policy of the claim type,
+// claim types without policy are unrestricted.
+func (mm *VCManager) getClaimTypPolicy(ctid string) *ClaimTypPolicy {
+	policy := &ClaimTypPolicy{}
+	if !mm.GetObject(claimTypPolicyKey(ctid), policy) {
+		return &ClaimTypPolicy{ID: ctid}
+	}
+	return policy
+}
+
+// getOwnedClaimTypPolicy gets policy of an existing claim type
+// and checks caller is its owner or admin.
+func (mm *VCManager) getOwnedClaimTypPolicy(vcr *VCRegistry, caller bitxid.DID, ctid string) (*ClaimTypPolicy, error) {
+	ct, err := vcr.Registry.GetClaimTyp(ctid)
+	if err != nil {
+		return nil, err
+	}
+	if ct == nil {
+		return nil, newError(ErrNotFound, "claim type %s not existed", ctid)
+	}
+	policy := mm.getClaimTypPolicy(ctid)
+	if policy.Owner != caller && !vcr.hasAdmin(caller) {
+		return nil, newError(ErrNotOwner, "caller(%s) is not owner of claim type %s", caller, ctid)
+	}
+	return policy, nil
+}
+
+func claimTypPolicyKey(ctid string) string {
+	return "claimtyppolicy-" + ctid
+}
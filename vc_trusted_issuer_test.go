@@ -0,0 +1,199 @@
+package contracts
+
+import (
+	"testing"
+
+	"github.com/meshplus/bitxhub-core/boltvm"
+	"github.com/meshplus/did-registry/didpb"
+	"github.com/stretchr/testify/require"
+)
+
+// restricted creates testCTID owned by admin which only trusts issuers added later.
+func restricted(e *testEnv) {
+	e.newClaimTyp(e.admin, testCTID, "name")
+	requireOK(e.t, e.as(e.admin).vc.SetClaimTypRestricted(e.admin.did, testCTID, true))
+}
+
+func claimTypPolicy(t *testing.T, e *testEnv) *didpb.ClaimTypPolicy {
+	policy := &didpb.ClaimTypPolicy{}
+	decode(t, e.vc.GetClaimTypPolicy(testCTID), policy)
+	return policy
+}
+
+func TestVCManager_TrustedIssuer(t *testing.T) {
+	store := func(e *testEnv) *boltvm.Response {
+		claim := claimOf(e.t, e.admin.did, map[string]string{"name": "alice"})
+		return e.storeVC(e.user, e.userDoc, e.credential(e.user, testCID, testCTID, claim))
+	}
+	runCalls(t, []call{
+		{
+			name:  "untrusted",
+			setup: restricted,
+			run:   store,
+			code:  ErrUntrustedIssuer,
+		},
+		{
+			name: "trusted",
+			setup: func(e *testEnv) {
+				restricted(e)
+				requireOK(e.t, e.as(e.admin).vc.AddTrustedIssuer(e.admin.did, testCTID, e.user.did))
+			},
+			run: store,
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				policy := claimTypPolicy(t, e)
+				require.Equal(t, e.admin.did, policy.Owner)
+				require.True(t, policy.Restricted)
+				require.Equal(t, []string{e.user.did}, policy.TrustedIssuers)
+			},
+		},
+		{
+			name: "trusted by chain",
+			setup: func(e *testEnv) {
+				restricted(e)
+				requireOK(e.t, e.as(e.admin).vc.AddTrustedIssuer(e.admin.did, testCTID, testChainDID))
+			},
+			run: store,
+		},
+		{
+			name: "trust removed",
+			setup: func(e *testEnv) {
+				restricted(e)
+				requireOK(e.t, e.as(e.admin).vc.AddTrustedIssuer(e.admin.did, testCTID, e.user.did))
+				requireOK(e.t, store(e))
+				requireOK(e.t, e.as(e.admin).vc.RemoveTrustedIssuer(e.admin.did, testCTID, e.user.did))
+			},
+			run: func(e *testEnv) *boltvm.Response { return e.vc.VerifyVC(testCID) },
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				verdict := &didpb.VCVerdict{}
+				decode(t, res, verdict)
+				require.False(t, verdict.Valid)
+			},
+		},
+		{
+			name: "unrestricted",
+			setup: func(e *testEnv) {
+				restricted(e)
+				requireOK(e.t, e.vc.SetClaimTypRestricted(e.admin.did, testCTID, false))
+			},
+			run: store,
+		},
+		{
+			name:  "restrict by others",
+			setup: func(e *testEnv) { e.newClaimTyp(e.admin, testCTID, "name") },
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).vc.SetClaimTypRestricted(e.user.did, testCTID, true)
+			},
+			code: ErrNotOwner,
+		},
+		{
+			name:  "restrict by admin",
+			setup: func(e *testEnv) { e.newClaimTyp(e.user, testCTID, "name") },
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).vc.SetClaimTypRestricted(e.admin.did, testCTID, true)
+			},
+		},
+		{
+			name: "restrict not existed",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).vc.SetClaimTypRestricted(e.admin.did, testCTID, true)
+			},
+			code: ErrNotFound,
+		},
+		{
+			name:  "add invalid issuer",
+			setup: restricted,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).vc.AddTrustedIssuer(e.admin.did, testCTID, "issuer")
+			},
+			code: ErrInvalidFormat,
+		},
+		{
+			name: "add twice",
+			setup: func(e *testEnv) {
+				restricted(e)
+				requireOK(e.t, e.as(e.admin).vc.AddTrustedIssuer(e.admin.did, testCTID, e.user.did))
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).vc.AddTrustedIssuer(e.admin.did, testCTID, e.user.did)
+			},
+			code: ErrAlreadyExists,
+		},
+		{
+			name:  "remove not trusted",
+			setup: restricted,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).vc.RemoveTrustedIssuer(e.admin.did, testCTID, e.user.did)
+			},
+			code: ErrNotFound,
+		},
+		{
+			name:  "restrict caller mismatch",
+			setup: func(e *testEnv) { e.newClaimTyp(e.user, testCTID, "name") },
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).vc.SetClaimTypRestricted(e.user.did, testCTID, true)
+			},
+			code: ErrCallerMismatch,
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.False(t, claimTypPolicy(t, e).Restricted)
+			},
+		},
+		{
+			name:  "add by others",
+			setup: restricted,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).vc.AddTrustedIssuer(e.user.did, testCTID, e.user.did)
+			},
+			code: ErrNotOwner,
+		},
+		{
+			name:  "add caller mismatch",
+			setup: restricted,
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).vc.AddTrustedIssuer(e.admin.did, testCTID, e.user.did)
+			},
+			code: ErrCallerMismatch,
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Empty(t, claimTypPolicy(t, e).TrustedIssuers)
+			},
+		},
+		{
+			name: "add to not existed",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).vc.AddTrustedIssuer(e.admin.did, testCTID, e.user.did)
+			},
+			code: ErrNotFound,
+		},
+		{
+			name: "remove by others",
+			setup: func(e *testEnv) {
+				restricted(e)
+				requireOK(e.t, e.as(e.admin).vc.AddTrustedIssuer(e.admin.did, testCTID, e.user.did))
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).vc.RemoveTrustedIssuer(e.user.did, testCTID, e.user.did)
+			},
+			code: ErrNotOwner,
+		},
+		{
+			name: "remove caller mismatch",
+			setup: func(e *testEnv) {
+				restricted(e)
+				requireOK(e.t, e.as(e.admin).vc.AddTrustedIssuer(e.admin.did, testCTID, e.user.did))
+			},
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.user).vc.RemoveTrustedIssuer(e.admin.did, testCTID, e.user.did)
+			},
+			code: ErrCallerMismatch,
+			check: func(t *testing.T, e *testEnv, res *boltvm.Response) {
+				require.Equal(t, []string{e.user.did}, claimTypPolicy(t, e).TrustedIssuers)
+			},
+		},
+		{
+			name: "remove from not existed",
+			run: func(e *testEnv) *boltvm.Response {
+				return e.as(e.admin).vc.RemoveTrustedIssuer(e.admin.did, testCTID, e.user.did)
+			},
+			code: ErrNotFound,
+		},
+	})
+}